@@ -0,0 +1,125 @@
+// Package agent provide ready-made pairing agents, registered with bluez through
+// the org.bluez.AgentManager1 interface
+package agent
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/bluez"
+	"github.com/saurabh-newera/BLE/bluez/profile"
+)
+
+// BasePath the object path prefix of the registered agents
+const BasePath = "/org/bluez/agent/go"
+
+var (
+	agentsLock sync.Mutex
+	agentsSeq  int
+)
+
+// Agent a registered pairing agent
+type Agent struct {
+	agent   *profile.Agent1
+	manager *profile.AgentManager1
+}
+
+// Register export handler and register it as the default agent of bluez, with an
+// IO capability, eg. profile.AgentCapabilityKeyboardDisplay
+func Register(handler profile.AgentHandler, capability string, opts ...profile.Option) (*Agent, error) {
+
+	agentsLock.Lock()
+	agentsSeq++
+	path := fmt.Sprintf("%s%d", BasePath, agentsSeq)
+	agentsLock.Unlock()
+
+	a := &Agent{
+		agent:   profile.NewAgent1(path, handler, opts...),
+		manager: profile.NewAgentManager1(opts...),
+	}
+
+	err := a.agent.Export()
+	if err != nil {
+		a.close()
+		return nil, err
+	}
+	err = a.manager.RegisterAgent(a.agent.Path, capability)
+	if err != nil {
+		a.close()
+		return nil, err
+	}
+	err = a.manager.RequestDefaultAgent(a.agent.Path)
+	if err != nil {
+		a.manager.UnregisterAgent(a.agent.Path)
+		a.close()
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// Path return the object path of the agent
+func (a *Agent) Path() dbus.ObjectPath {
+	return a.agent.Path
+}
+
+// Close unregister the agent and remove it from the bus
+func (a *Agent) Close() error {
+	err := a.manager.UnregisterAgent(a.agent.Path)
+	a.close()
+	return err
+}
+
+func (a *Agent) close() {
+	a.agent.Close()
+	a.manager.Close()
+}
+
+// Base reject every request, embed it to implement only some callbacks of profile.AgentHandler
+type Base struct{}
+
+// Release does nothing
+func (Base) Release() error {
+	return nil
+}
+
+// RequestPinCode reject the request
+func (Base) RequestPinCode(device dbus.ObjectPath) (string, error) {
+	return "", bluez.ErrRejected
+}
+
+// DisplayPinCode does nothing
+func (Base) DisplayPinCode(device dbus.ObjectPath, pincode string) error {
+	return nil
+}
+
+// RequestPasskey reject the request
+func (Base) RequestPasskey(device dbus.ObjectPath) (uint32, error) {
+	return 0, bluez.ErrRejected
+}
+
+// DisplayPasskey does nothing
+func (Base) DisplayPasskey(device dbus.ObjectPath, passkey uint32, entered uint16) error {
+	return nil
+}
+
+// RequestConfirmation reject the request
+func (Base) RequestConfirmation(device dbus.ObjectPath, passkey uint32) error {
+	return bluez.ErrRejected
+}
+
+// RequestAuthorization reject the request
+func (Base) RequestAuthorization(device dbus.ObjectPath) error {
+	return bluez.ErrRejected
+}
+
+// AuthorizeService reject the request
+func (Base) AuthorizeService(device dbus.ObjectPath, uuid string) error {
+	return bluez.ErrRejected
+}
+
+// Cancel does nothing
+func (Base) Cancel() error {
+	return nil
+}
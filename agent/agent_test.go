@@ -0,0 +1,126 @@
+package agent
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/saurabh-newera/BLE/bluez/profile"
+)
+
+const device = "/org/bluez/hci0/dev_00_11_22_33_44_55"
+
+func TestFixedPin(t *testing.T) {
+
+	agent := profile.NewAgent1("/test/agent", NewFixedPin("123456"))
+
+	passkey, err := agent.RequestPasskey(device)
+	if err != nil || passkey != 123456 {
+		t.Fatalf("Unexpected passkey %d, %v", passkey, err)
+	}
+	if err := agent.RequestConfirmation(device, 123456); err != nil {
+		t.Fatal(err)
+	}
+	if err := agent.RequestConfirmation(device, 654321); err == nil || err.Name != "org.bluez.Error.Rejected" {
+		t.Fatalf("Expected org.bluez.Error.Rejected, got %v", err)
+	}
+
+	// the PIN cannot be bypassed
+	if err := agent.RequestAuthorization(device); err == nil || err.Name != "org.bluez.Error.Rejected" {
+		t.Fatalf("Expected org.bluez.Error.Rejected, got %v", err)
+	}
+	if err := agent.AuthorizeService(device, "0000110b-0000-1000-8000-00805f9b34fb"); err == nil {
+		t.Fatal("Service authorized without opt-in")
+	}
+
+	pin, err := profile.NewAgent1("/test/agent", NewFixedPin("0000abc")).RequestPinCode(device)
+	if err != nil || pin != "0000abc" {
+		t.Fatalf("Unexpected PIN code %s, %v", pin, err)
+	}
+
+	// a literal without passkey never confirms 000000
+	agent = profile.NewAgent1("/test/agent", &FixedPin{Pin: "1234"})
+	if err := agent.RequestConfirmation(device, 0); err == nil || err.Name != "org.bluez.Error.Rejected" {
+		t.Fatalf("Expected org.bluez.Error.Rejected, got %v", err)
+	}
+	if _, err := agent.RequestPasskey(device); err == nil || err.Name != "org.bluez.Error.Rejected" {
+		t.Fatalf("Expected org.bluez.Error.Rejected, got %v", err)
+	}
+
+	fixed := NewFixedPin("000000")
+	fixed.AuthorizeServices = true
+	agent = profile.NewAgent1("/test/agent", fixed)
+	if err := agent.RequestConfirmation(device, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := agent.AuthorizeService(device, "00001124-0000-1000-8000-00805f9b34fb"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInteractive(t *testing.T) {
+
+	out := new(bytes.Buffer)
+	agent := profile.NewAgent1("/test/agent", NewInteractive(strings.NewReader("042\nno\n"), out))
+
+	passkey, err := agent.RequestPasskey(device)
+	if err != nil || passkey != 42 {
+		t.Fatalf("Unexpected passkey %d, %v", passkey, err)
+	}
+	if err := agent.AuthorizeService(device, "0000110b-0000-1000-8000-00805f9b34fb"); err == nil || err.Name != "org.bluez.Error.Rejected" {
+		t.Fatalf("Expected org.bluez.Error.Rejected, got %v", err)
+	}
+	// no more input
+	if _, err := agent.RequestPinCode(device); err == nil || err.Name != "org.bluez.Error.Canceled" {
+		t.Fatalf("Expected org.bluez.Error.Canceled, got %v", err)
+	}
+	if !strings.Contains(out.String(), "Enter passkey for "+device) {
+		t.Fatalf("Unexpected prompts %q", out.String())
+	}
+}
+
+// promptWriter signal each prompt written by the agent
+type promptWriter chan string
+
+func (w promptWriter) Write(p []byte) (int, error) {
+	w <- string(p)
+	return len(p), nil
+}
+
+func TestInteractiveCancel(t *testing.T) {
+
+	in, _ := io.Pipe()
+	out := make(promptWriter, 2)
+	interactive := NewInteractive(in, out)
+	agent := profile.NewAgent1("/test/agent", interactive)
+
+	result := make(chan error, 1)
+	go (func() {
+		_, err := agent.RequestPinCode(device)
+		if err != nil {
+			result <- errors.New(err.Name)
+			return
+		}
+		result <- nil
+	})()
+
+	select {
+	case <-out:
+	case <-time.After(time.Second):
+		t.Fatal("No prompt")
+	}
+	// no answer is typed, the prompt is aborted
+	interactive.Cancel()
+
+	select {
+	case err := <-result:
+		if err == nil || err.Error() != "org.bluez.Error.Canceled" {
+			t.Fatalf("Expected org.bluez.Error.Canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Prompt not canceled")
+	}
+}
@@ -0,0 +1,178 @@
+package agent
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/bluez"
+)
+
+// NewStdin create an interactive agent using the terminal
+func NewStdin() *Interactive {
+	return NewInteractive(os.Stdin, os.Stdout)
+}
+
+// NewInteractive create an agent asking the user, reading the answers from in and
+// writing the prompts to out
+func NewInteractive(in io.Reader, out io.Writer) *Interactive {
+	return &Interactive{
+		in:    bufio.NewReader(in),
+		out:   out,
+		lines: make(chan string),
+	}
+}
+
+// Interactive an agent prompting the user for each request
+type Interactive struct {
+	// lock serialize the requests, one prompt is pending at once
+	lock sync.Mutex
+	in   *bufio.Reader
+	// lines receives the answers, read by a goroutine so a prompt can be canceled
+	lines  chan string
+	reader sync.Once
+
+	// outLock guard out and canceled
+	outLock  sync.Mutex
+	out      io.Writer
+	canceled chan struct{}
+}
+
+// read forward the lines of in, the channel is closed at the end of the input
+func (i *Interactive) read() {
+	defer close(i.lines)
+	for {
+		line, err := i.in.ReadString('\n')
+		if err != nil && line == "" {
+			return
+		}
+		i.lines <- line
+	}
+}
+
+// ask print a prompt and return the answer, one request is handled at once.
+// A pending prompt is aborted by Cancel
+func (i *Interactive) ask(format string, args ...interface{}) (string, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	i.reader.Do(func() {
+		go i.read()
+	})
+
+	canceled := make(chan struct{})
+	i.outLock.Lock()
+	i.canceled = canceled
+	fmt.Fprintf(i.out, format, args...)
+	i.outLock.Unlock()
+
+	defer (func() {
+		i.outLock.Lock()
+		i.canceled = nil
+		i.outLock.Unlock()
+	})()
+
+	select {
+	case line, ok := <-i.lines:
+		if !ok {
+			return "", bluez.ErrCanceled
+		}
+		return strings.TrimSpace(line), nil
+	case <-canceled:
+		return "", bluez.ErrCanceled
+	}
+}
+
+// confirm ask a yes/no question
+func (i *Interactive) confirm(format string, args ...interface{}) error {
+	answer, err := i.ask(format+" (yes/no): ", args...)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(answer) {
+	case "y", "yes":
+		return nil
+	}
+	return bluez.ErrRejected
+}
+
+// show print a message, it does not wait for a pending prompt
+func (i *Interactive) show(format string, args ...interface{}) {
+	i.outLock.Lock()
+	defer i.outLock.Unlock()
+	fmt.Fprintf(i.out, format+"\n", args...)
+}
+
+// Release print a notice
+func (i *Interactive) Release() error {
+	i.show("Agent released")
+	return nil
+}
+
+// RequestPinCode ask the PIN code
+func (i *Interactive) RequestPinCode(device dbus.ObjectPath) (string, error) {
+	pin, err := i.ask("Enter PIN code for %s: ", device)
+	if err != nil {
+		return "", err
+	}
+	if pin == "" {
+		return "", bluez.ErrRejected
+	}
+	return pin, nil
+}
+
+// DisplayPinCode print the PIN code
+func (i *Interactive) DisplayPinCode(device dbus.ObjectPath, pincode string) error {
+	i.show("PIN code for %s: %s", device, pincode)
+	return nil
+}
+
+// RequestPasskey ask the passkey
+func (i *Interactive) RequestPasskey(device dbus.ObjectPath) (uint32, error) {
+	answer, err := i.ask("Enter passkey for %s: ", device)
+	if err != nil {
+		return 0, err
+	}
+	passkey, err := strconv.ParseUint(answer, 10, 32)
+	if err != nil || passkey > 999999 {
+		return 0, bluez.ErrRejected
+	}
+	return uint32(passkey), nil
+}
+
+// DisplayPasskey print the passkey
+func (i *Interactive) DisplayPasskey(device dbus.ObjectPath, passkey uint32, entered uint16) error {
+	i.show("Passkey for %s: %06d (%d digits entered)", device, passkey, entered)
+	return nil
+}
+
+// RequestConfirmation ask to confirm the passkey
+func (i *Interactive) RequestConfirmation(device dbus.ObjectPath, passkey uint32) error {
+	return i.confirm("Confirm passkey %06d for %s", passkey, device)
+}
+
+// RequestAuthorization ask to authorize the pairing
+func (i *Interactive) RequestAuthorization(device dbus.ObjectPath) error {
+	return i.confirm("Authorize pairing with %s", device)
+}
+
+// AuthorizeService ask to authorize the service
+func (i *Interactive) AuthorizeService(device dbus.ObjectPath, uuid string) error {
+	return i.confirm("Authorize service %s for %s", uuid, device)
+}
+
+// Cancel abort the pending prompt and print a notice
+func (i *Interactive) Cancel() error {
+	i.outLock.Lock()
+	if i.canceled != nil {
+		close(i.canceled)
+		i.canceled = nil
+	}
+	i.outLock.Unlock()
+	i.show("Request canceled")
+	return nil
+}
@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"strconv"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/bluez"
+)
+
+// AutoAccept an agent accepting every request, the PIN code is 0000 and the passkey 0.
+// Use it with profile.AgentCapabilityNoInputNoOutput for "just works" pairing
+type AutoAccept struct {
+	Base
+}
+
+// RequestPinCode return 0000
+func (AutoAccept) RequestPinCode(device dbus.ObjectPath) (string, error) {
+	return "0000", nil
+}
+
+// RequestPasskey return 0
+func (AutoAccept) RequestPasskey(device dbus.ObjectPath) (uint32, error) {
+	return 0, nil
+}
+
+// RequestConfirmation accept the passkey
+func (AutoAccept) RequestConfirmation(device dbus.ObjectPath, passkey uint32) error {
+	return nil
+}
+
+// RequestAuthorization accept the pairing
+func (AutoAccept) RequestAuthorization(device dbus.ObjectPath) error {
+	return nil
+}
+
+// AuthorizeService accept the connection
+func (AutoAccept) AuthorizeService(device dbus.ObjectPath, uuid string) error {
+	return nil
+}
+
+// NewFixedPin create an agent answering with a fixed PIN code, eg. "123456". When the
+// PIN is numeric it is used as passkey too, and only matching passkeys are confirmed.
+// Pairing without PIN nor passkey ("just works") is rejected, as are the service
+// connections of untrusted devices unless AuthorizeServices is set
+func NewFixedPin(pin string) *FixedPin {
+	f := &FixedPin{Pin: pin}
+	if passkey, err := strconv.ParseUint(pin, 10, 32); err == nil && passkey <= 999999 {
+		f.SetPasskey(uint32(passkey))
+	}
+	return f
+}
+
+// FixedPin an agent answering with a fixed PIN code and passkey. A FixedPin{Pin: "1234"}
+// literal has no passkey, passkey requests and confirmations are rejected
+type FixedPin struct {
+	Base
+	Pin string
+	// Passkey is the expected passkey, used once set with SetPasskey or NewFixedPin
+	Passkey    uint32
+	hasPasskey bool
+	// AuthorizeServices accept the service connections of paired devices. It defaults
+	// to false: bluez asks only for untrusted devices, eg. a paired keyboard is
+	// rejected until the device is trusted or AuthorizeServices is set
+	AuthorizeServices bool
+}
+
+// SetPasskey set the expected passkey, between 0 and 999999
+func (f *FixedPin) SetPasskey(passkey uint32) {
+	f.Passkey = passkey
+	f.hasPasskey = true
+}
+
+// RequestPinCode return Pin
+func (f *FixedPin) RequestPinCode(device dbus.ObjectPath) (string, error) {
+	return f.Pin, nil
+}
+
+// RequestPasskey return Passkey
+func (f *FixedPin) RequestPasskey(device dbus.ObjectPath) (uint32, error) {
+	if !f.hasPasskey {
+		return 0, bluez.ErrRejected
+	}
+	return f.Passkey, nil
+}
+
+// RequestConfirmation accept only the expected passkey
+func (f *FixedPin) RequestConfirmation(device dbus.ObjectPath, passkey uint32) error {
+	if !f.hasPasskey || passkey != f.Passkey {
+		return bluez.ErrRejected
+	}
+	return nil
+}
+
+// AuthorizeService accept the connection only if AuthorizeServices is set
+func (f *FixedPin) AuthorizeService(device dbus.ObjectPath, uuid string) error {
+	if !f.AuthorizeServices {
+		return bluez.ErrRejected
+	}
+	return nil
+}
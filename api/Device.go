@@ -1,9 +1,10 @@
 package api
 
 import (
+	"context"
 	"errors"
-	"reflect"
 	"strings"
+	"sync"
 
 	"fmt"
 	"github.com/godbus/dbus"
@@ -17,8 +18,12 @@ import (
 var log = logging.MustGetLogger("examples")
 var deviceRegistry = make(map[string]*Device)
 
-// NewDevice creates a new Device
-func NewDevice(path string) *Device {
+// watchLock guard the property and battery watches of the devices, Device values
+// are copied by GetDevices
+var watchLock sync.Mutex
+
+// NewDevice creates a new Device, the options default to those of the manager
+func NewDevice(path string, opts ...profile.Option) *Device {
 
 	if _, ok := deviceRegistry[path]; ok {
 		// fmt.Sprintf("Reusing cache instance %s", path)
@@ -27,7 +32,8 @@ func NewDevice(path string) *Device {
 
 	d := new(Device)
 	d.Path = path
-	d.client = profile.NewDevice1(path)
+	d.opts = defaultOptions(opts)
+	d.client = profile.NewDevice1(path, d.opts...)
 
 	d.client.GetProperties()
 	d.Properties = d.client.Properties
@@ -45,6 +51,7 @@ func ClearDevice(d *Device) {
 
 	d.Disconnect()
 	d.unwatchProperties()
+	d.unwatchBattery()
 	c, err := d.GetClient()
 	if err == nil {
 		c.Close()
@@ -56,12 +63,13 @@ func ClearDevice(d *Device) {
 
 }
 
-// ParseDevice parse a Device from a ObjectManager map
-func ParseDevice(path dbus.ObjectPath, propsMap map[string]dbus.Variant) (*Device, error) {
+// ParseDevice parse a Device from a ObjectManager map, the options default to those of the manager
+func ParseDevice(path dbus.ObjectPath, propsMap map[string]dbus.Variant, opts ...profile.Option) (*Device, error) {
 
 	d := new(Device)
 	d.Path = string(path)
-	d.client = profile.NewDevice1(d.Path)
+	d.opts = defaultOptions(opts)
+	d.client = profile.NewDevice1(d.Path, d.opts...)
 
 	props := new(profile.Device1Properties)
 	util.MapToStruct(props, propsMap)
@@ -78,61 +86,56 @@ func (d *Device) watchProperties() error {
 
 	fmt.Sprintf("watch-prop: watching properties")
 
-	channel, err := d.client.Register()
+	watchLock.Lock()
+	watching := d.watch != nil
+	watchLock.Unlock()
+	if watching {
+		return nil
+	}
+
+	channel, err := d.client.WatchProperties()
 	if err != nil {
 		return err
 	}
 
-	go (func() {
-		for {
-
-			if channel == nil {
-				fmt.Sprintf("watch-prop: nil channel, exit")
-				break
-			}
-
-			fmt.Sprintf("watch-prop: waiting updates")
-			sig := <-channel
+	watchLock.Lock()
+	if d.watch != nil {
+		// watched concurrently
+		watchLock.Unlock()
+		d.client.UnwatchProperties(channel)
+		return nil
+	}
+	d.watch = channel
+	watchLock.Unlock()
 
-			if sig == nil {
-				fmt.Sprintf("watch-prop: nil sig, exit")
-				return
+	go (func() {
+		// the channel is closed by unwatchProperties, or once the device is removed
+		// so that a later On("changed") watches it again
+		defer (func() {
+			watchLock.Lock()
+			if d.watch == channel {
+				d.watch = nil
 			}
+			watchLock.Unlock()
+		})()
 
-			fmt.Sprintf("watch-prop: signal name %s", sig.Name)
-			if sig.Name != bluez.PropertiesChanged {
-				fmt.Sprintf("Skipped %s vs %s\n", sig.Name, bluez.PropertiesInterface)
-				continue
-			}
+		for change := range channel {
 
 			fmt.Sprintf("Device property changed")
-			for i := 0; i < len(sig.Body); i++ {
-				fmt.Sprintf("%s -> %s", reflect.TypeOf(sig.Body[i]), sig.Body[i])
-			}
 
-			iface := sig.Body[0].(string)
-			changes := sig.Body[1].(map[string]dbus.Variant)
-			for field, val := range changes {
-
-				// updates [*]Properties struct
-				props := d.Properties
-
-				s := reflect.ValueOf(props).Elem()
-				// exported field
-				f := s.FieldByName(field)
-				if f.IsValid() {
-					// A Value can be changed only if it is
-					// addressable and was not obtained by
-					// the use of unexported struct fields.
-					if f.CanSet() {
-						x := reflect.ValueOf(val.Value())
-						f.Set(x)
-						fmt.Sprintf("Set props value: %s = %s\n", field, x.Interface())
-					}
-				}
+			// each change loads a new struct, a caller holding the previous
+			// d.Properties never sees it modified
+			props := new(profile.Device1Properties)
+			util.MapToStruct(props, change.Snapshot)
+			d.Properties = props
 
+			for field, val := range change.Changed {
 				fmt.Sprintf("Emit change for %s = %v\n", field, val.Value())
-				propChanged := PropertyChangedEvent{string(iface), field, val.Value(), props, d}
+				propChanged := PropertyChangedEvent{change.Interface, field, val.Value(), props, d}
+				d.Emit("changed", propChanged)
+			}
+			for _, field := range change.Invalidated {
+				propChanged := PropertyChangedEvent{change.Interface, field, nil, props, d}
 				d.Emit("changed", propChanged)
 			}
 		}
@@ -146,11 +149,22 @@ type Device struct {
 	Path       string
 	Properties *profile.Device1Properties
 	client     *profile.Device1
+	opts       []profile.Option
 	chars      map[dbus.ObjectPath]*profile.GattCharacteristic1
+	watch      chan *bluez.PropertyChange
+	battery    *batteryWatch
 }
 
 func (d *Device) unwatchProperties() error {
-	return d.client.Unregister()
+	watchLock.Lock()
+	watch := d.watch
+	d.watch = nil
+	watchLock.Unlock()
+
+	if watch != nil {
+		d.client.UnwatchProperties(watch)
+	}
+	return nil
 }
 
 //GetClient return a DBus Device1 interface client
@@ -163,6 +177,11 @@ func (d *Device) GetClient() (*profile.Device1, error) {
 
 //GetProperties return the properties for the device
 func (d *Device) GetProperties() (*profile.Device1Properties, error) {
+	return d.GetPropertiesContext(context.Background())
+}
+
+//GetPropertiesContext return the properties for the device, aborting when ctx is done
+func (d *Device) GetPropertiesContext(ctx context.Context) (*profile.Device1Properties, error) {
 
 	if d == nil {
 		return nil, errors.New("Empty device pointer")
@@ -173,7 +192,7 @@ func (d *Device) GetProperties() (*profile.Device1Properties, error) {
 		return nil, err
 	}
 
-	props, err := c.GetProperties()
+	props, err := c.GetPropertiesContext(ctx)
 
 	if err != nil {
 		return nil, err
@@ -185,23 +204,31 @@ func (d *Device) GetProperties() (*profile.Device1Properties, error) {
 
 //GetProperty return a property value
 func (d *Device) GetProperty(name string) (data interface{}, err error) {
+	return d.GetPropertyContext(context.Background(), name)
+}
+
+//GetPropertyContext return a property value, aborting when ctx is done
+func (d *Device) GetPropertyContext(ctx context.Context, name string) (data interface{}, err error) {
 	c, err := d.GetClient()
 	if err != nil {
 		return nil, err
 	}
-	val, err := c.GetProperty(name)
+	val, err := c.GetPropertyContext(ctx, name)
 	if err != nil {
 		return nil, err
 	}
 	return val.Value(), nil
 }
 
-//On register callback for event
+//On register callback for event, "battery" emits a BatteryEvent on each battery level change
 func (d *Device) On(name string, fn *emitter.Callback) {
 	switch name {
 	case "changed":
 		d.watchProperties()
 		break
+	case "battery":
+		d.watchBattery()
+		break
 	}
 	emitter.On(d.Path+"."+name, fn)
 }
@@ -212,6 +239,9 @@ func (d *Device) Off(name string, cb *emitter.Callback) {
 	case "changed":
 		d.unwatchProperties()
 		break
+	case "battery":
+		d.unwatchBattery()
+		break
 	}
 
 	pattern := d.Path + "." + name
@@ -229,12 +259,12 @@ func (d *Device) Emit(name string, data interface{}) {
 
 //GetService return a GattService
 func (d *Device) GetService(path string) *profile.GattService1 {
-	return profile.NewGattService1(path)
+	return profile.NewGattService1(path, d.opts...)
 }
 
 //GetChar return a GattService
 func (d *Device) GetChar(path string) *profile.GattCharacteristic1 {
-	return profile.NewGattCharacteristic1(path)
+	return profile.NewGattCharacteristic1(path, d.opts...)
 }
 
 //..........................................................................................
@@ -254,7 +284,7 @@ func (d *Device) GetAllServicesAndUUID() ([]string, error) {
 		// use cache
 		_, ok := d.chars[path]
 		if !ok {
-			d.chars[path] = profile.NewGattCharacteristic1(string(path))
+			d.chars[path] = profile.NewGattCharacteristic1(string(path), d.opts...)
 		}
 
 		props := d.chars[path].Properties
@@ -290,7 +320,7 @@ func (d *Device) GetCharByUUID(uuid string) (*profile.GattCharacteristic1, error
 		// use cache
 		_, ok := d.chars[path]
 		if !ok {
-			d.chars[path] = profile.NewGattCharacteristic1(string(path))
+			d.chars[path] = profile.NewGattCharacteristic1(string(path), d.opts...)
 		}
 
 		props := d.chars[path].Properties
@@ -346,7 +376,7 @@ func (d *Device) GetCharsList() []dbus.ObjectPath {
 	return chars
 }
 
-//IsConnected check if connected to the device
+//IsConnected check if connected to the device, the state is read from the property cache
 func (d *Device) IsConnected() bool {
 
 	props, _ := d.GetProperties()
@@ -360,13 +390,18 @@ func (d *Device) IsConnected() bool {
 
 //Connect to device
 func (d *Device) Connect() error {
+	return d.ConnectContext(context.Background())
+}
+
+//ConnectContext connect to device, aborting when ctx is done
+func (d *Device) ConnectContext(ctx context.Context) error {
 
 	c, err := d.GetClient()
 	if err != nil {
 		return err
 	}
 
-	err = c.Connect()
+	err = c.ConnectContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -375,20 +410,28 @@ func (d *Device) Connect() error {
 
 //Disconnect from a device
 func (d *Device) Disconnect() error {
+	return d.DisconnectContext(context.Background())
+}
+
+//DisconnectContext disconnect from a device, aborting when ctx is done
+func (d *Device) DisconnectContext(ctx context.Context) error {
 	c, err := d.GetClient()
 	if err != nil {
 		return err
 	}
-	c.Disconnect()
-	return nil
+	return c.DisconnectContext(ctx)
 }
 
 //Pair a device
 func (d *Device) Pair() error {
+	return d.PairContext(context.Background())
+}
+
+//PairContext pair a device, aborting when ctx is done
+func (d *Device) PairContext(ctx context.Context) error {
 	c, err := d.GetClient()
 	if err != nil {
 		return err
 	}
-	c.Pair()
-	return nil
+	return c.PairContext(ctx)
 }
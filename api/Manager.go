@@ -1,7 +1,9 @@
 package api
 
 import (
+	"context"
 	"strings"
+	"sync"
 
 	"github.com/godbus/dbus"
 	"github.com/saurabh-newera/BLE/bluez"
@@ -11,48 +13,169 @@ import (
 	"fmt"
 )
 
-var manager *Manager
+var (
+	manager     *Manager
+	managerLock sync.Mutex
+)
+
+const bluezServiceName = "org.bluez"
 
-//GetManager return the object manager reference
+//GetManager return the object manager reference, created on the system bus on first use.
+// It panics if the manager cannot be created, use NewManager and SetManager to handle the error
 func GetManager() *Manager {
+	managerLock.Lock()
+	defer managerLock.Unlock()
 	if manager == nil {
-		manager = NewManager()
+		m, err := NewManager()
+		if err != nil {
+			panic(err)
+		}
+		manager = m
 	}
 	return manager
 }
 
-// NewManager creates a new manager instance
-func NewManager() *Manager {
+//SetManager replace the manager returned by GetManager, eg. one created with
+// NewManager(profile.WithAddress(...)) to use the bluez of a test bus
+func SetManager(m *Manager) {
+	managerLock.Lock()
+	defer managerLock.Unlock()
+	manager = m
+}
+
+// defaultOptions return opts, or the options of the manager when none is given
+func defaultOptions(opts []profile.Option) []profile.Option {
+	if len(opts) > 0 {
+		return opts
+	}
+	managerLock.Lock()
+	defer managerLock.Unlock()
+	if manager == nil {
+		return nil
+	}
+	return manager.opts
+}
+
+// NewManager creates a new manager instance. The options select the bus of bluez, eg.
+// profile.WithConn, and are passed to the clients created by the api once set with SetManager
+func NewManager(opts ...profile.Option) (*Manager, error) {
 	m := new(Manager)
-	m.objectManager = profile.NewObjectManager()
+	m.opts = opts
+	m.objectManager = profile.NewObjectManager(opts...)
 	m.objects = make(map[dbus.ObjectPath]map[string]map[string]dbus.Variant)
 
 	// watch for signaling from ObjectManager
-	m.watchChanges()
-
-	// Load initial object cache and emit events
-	err := m.LoadObjects()
+	err := m.watchChanges()
+	if err == nil {
+		// watch for bluetoothd restarts
+		err = m.watchService()
+	}
+	if err == nil {
+		// Load initial object cache and emit events
+		err = m.LoadObjects()
+	}
 	if err != nil {
-		panic(err)
+		m.Close()
+		return nil, err
 	}
 
 	fmt.Sprintf("Manager initialized")
-	return m
+	return m, nil
 }
 
 // Manager track changes in the bluez dbus tree reflecting protocol updates
 type Manager struct {
+	opts                []profile.Option
 	objectManager       *profile.ObjectManager
 	watchChangesEnabled bool
-	objects             map[dbus.ObjectPath]map[string]map[string]dbus.Variant
 	channel             chan *dbus.Signal
+	busClient           *bluez.Client
+
+	// objectsLock guard objects, updated by the signal goroutines
+	objectsLock sync.RWMutex
+	objects     map[dbus.ObjectPath]map[string]map[string]dbus.Variant
+}
+
+// watchService track the org.bluez name owner to detect bluetoothd restarts
+func (m *Manager) watchService() error {
+
+	config := &bluez.Config{
+		Name:  "org.freedesktop.DBus",
+		Iface: "org.freedesktop.DBus",
+		Path:  "/org/freedesktop/DBus",
+		Bus:   bluez.SystemBus,
+	}
+	for _, opt := range m.opts {
+		opt(config)
+	}
+	m.busClient = bluez.NewClient(config)
+
+	channel, err := m.busClient.Subscribe(bluez.NameOwnerChangedMatch(bluezServiceName))
+	if err != nil {
+		return err
+	}
+
+	go (func() {
+		for v := range channel {
+
+			if len(v.Body) < 3 {
+				continue
+			}
+			oldOwner, _ := v.Body[1].(string)
+			newOwner, _ := v.Body[2].(string)
+
+			if newOwner == "" {
+				m.serviceDown(oldOwner)
+			} else {
+				m.serviceUp(newOwner)
+			}
+		}
+	})()
+
+	return nil
+}
+
+// serviceDown drop the state bound to the bluetoothd instance that went away
+func (m *Manager) serviceDown(owner string) {
+
+	m.objectsLock.Lock()
+	m.objects = make(map[dbus.ObjectPath]map[string]map[string]dbus.Variant)
+	m.objectsLock.Unlock()
+	profile.SuspendNotifications()
+
+	emitter.Emit("bluez-down", ServiceEvent{bluezServiceName, owner})
+}
+
+// serviceUp reload the state once bluetoothd is back on the bus
+func (m *Manager) serviceUp(owner string) {
+
+	err := m.busClient.RestoreSignals()
+	if err != nil {
+		logger.Warningf("Failed to restore signals: %v", err)
+	}
+
+	emitter.Emit("bluez-up", ServiceEvent{bluezServiceName, owner})
+
+	// emit the objects available again, eg. adapters
+	err = m.RefreshState()
+	if err != nil {
+		logger.Warningf("Failed to reload objects: %v", err)
+	}
+
+	err = bluez.RefreshPropertyCaches(context.Background())
+	if err != nil {
+		logger.Warningf("Failed to reload properties: %v", err)
+	}
+
+	// devices may need to connect again before their characteristics are available,
+	// those are restored when their interface is added
+	profile.RestoreNotifications()
 }
 
 // unwatchChanges register for signals from the ObjectManager
 func (m *Manager) unwatchChanges() error {
-	if m.channel != nil {
-		close(m.channel)
-	}
+	// the channel is closed by the client
+	m.channel = nil
 	m.watchChangesEnabled = false
 	return m.objectManager.Unregister()
 }
@@ -99,11 +222,31 @@ func (m *Manager) watchChanges() error {
 					path := v.Body[0].(dbus.ObjectPath)
 					props := v.Body[1].(map[string]map[string]dbus.Variant)
 
-					// keep cache up to date
-					m.objects[path] = props
+					// keep cache up to date, the interfaces are added to the ones of the
+					// object, eg. Battery1 once a device is connected
+					m.objectsLock.Lock()
+					ifaces := make(map[string]map[string]dbus.Variant, len(m.objects[path])+len(props))
+					for iface, ifaceProps := range m.objects[path] {
+						ifaces[iface] = ifaceProps
+					}
+					for iface, ifaceProps := range props {
+						ifaces[iface] = ifaceProps
+					}
+					m.objects[path] = ifaces
+					m.objectsLock.Unlock()
+
+					if _, ok := props[bluez.Battery1Interface]; ok {
+						go batteryAdded(path)
+					}
+
+					// notifications lost on a bluetoothd restart, restored aside so
+					// a slow StartNotify does not delay the other changes
+					if _, ok := props[bluez.GattCharacteristic1Interface]; ok {
+						go profile.RestoreNotifications(string(path))
+					}
 
 					fmt.Sprintf("Body %v", props)
-					emitChanges(path, props)
+					m.emitChanges(path, props)
 				}
 			case bluez.InterfacesRemoved:
 				{
@@ -114,10 +257,21 @@ func (m *Manager) watchChanges() error {
 					path := v.Body[0].(dbus.ObjectPath)
 					ifaces := v.Body[1].([]string)
 
-					// keep cache up to date
-					if _, ok := m.objects[path]; ok {
+					// keep cache up to date, the object is removed with its last interface
+					m.objectsLock.Lock()
+					left := make(map[string]map[string]dbus.Variant)
+					for iface, ifaceProps := range m.objects[path] {
+						left[iface] = ifaceProps
+					}
+					for _, iface := range ifaces {
+						delete(left, iface)
+					}
+					if len(left) == 0 {
 						delete(m.objects, path)
+					} else {
+						m.objects[path] = left
 					}
+					m.objectsLock.Unlock()
 
 					for _, iF := range ifaces {
 						// device removed
@@ -146,11 +300,11 @@ func (m *Manager) watchChanges() error {
 	return nil
 }
 
-func emitChanges(path dbus.ObjectPath, props map[string]map[string]dbus.Variant) {
+func (m *Manager) emitChanges(path dbus.ObjectPath, props map[string]map[string]dbus.Variant) {
 
 	//Device1
 	if props[bluez.Device1Interface] != nil {
-		dev, err := ParseDevice(path, props[bluez.Device1Interface])
+		dev, err := ParseDevice(path, props[bluez.Device1Interface], m.opts...)
 		if err != nil {
 			logger.Fatalf("Failed to parse device: %v\n", err)
 			return
@@ -231,14 +385,30 @@ func (m *Manager) LoadObjects() error {
 	if err != nil {
 		return err
 	}
+	m.objectsLock.Lock()
 	m.objects = objs
-	fmt.Sprintf("Loaded %d objects", len(m.objects))
+	m.objectsLock.Unlock()
+	fmt.Sprintf("Loaded %d objects", len(objs))
 	return nil
 }
 
-//GetObjects return the cached list of objects from the ObjectManager
+//GetObjects return a snapshot of the cached list of objects from the ObjectManager
 func (m *Manager) GetObjects() *map[dbus.ObjectPath]map[string]map[string]dbus.Variant {
-	return &m.objects
+	m.objectsLock.RLock()
+	defer m.objectsLock.RUnlock()
+	objects := make(map[dbus.ObjectPath]map[string]map[string]dbus.Variant, len(m.objects))
+	for path, ifaces := range m.objects {
+		objects[path] = ifaces
+	}
+	return &objects
+}
+
+// hasInterface check in the cached list of objects if the object at path implements iface
+func (m *Manager) hasInterface(path dbus.ObjectPath, iface string) bool {
+	m.objectsLock.RLock()
+	defer m.objectsLock.RUnlock()
+	_, ok := m.objects[path][iface]
+	return ok
 }
 
 //RefreshState emit local manager objects and interfaces
@@ -252,7 +422,7 @@ func (m *Manager) RefreshState() error {
 	fmt.Sprintf("Refreshing object state")
 	objs := m.GetObjects()
 	for path, ifaces := range *objs {
-		emitChanges(path, ifaces)
+		m.emitChanges(path, ifaces)
 	}
 
 	return nil
@@ -260,6 +430,10 @@ func (m *Manager) RefreshState() error {
 
 //Close Close the Manager and free underlying resources
 func (m *Manager) Close() {
+	if m.busClient != nil {
+		m.busClient.Disconnect()
+		m.busClient = nil
+	}
 	m.objectManager.Unregister()
 	m.objectManager.Close()
 	m.objectManager = nil
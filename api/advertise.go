@@ -0,0 +1,49 @@
+package api
+
+import (
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/bluez/profile"
+)
+
+// advertising publish an advertisement, see Advertise
+type advertising struct {
+	opts          []profile.Option
+	adv           *profile.Advertisement
+	advertisement *profile.LEAdvertisement1
+}
+
+func (a *advertising) export(path dbus.ObjectPath) error {
+	a.advertisement = profile.NewLEAdvertisement1(string(path), a.adv, a.opts...)
+	return a.advertisement.Export()
+}
+
+func (a *advertising) register(adapterID string) error {
+	manager := profile.NewLEAdvertisingManager1(adapterID, a.opts...)
+	defer manager.Close()
+	return manager.RegisterAdvertisement(a.advertisement.Path, map[string]interface{}{})
+}
+
+func (a *advertising) close() {
+	if a.advertisement != nil {
+		a.advertisement.Close()
+	}
+}
+
+//Advertise export adv and register it on an adapter, stop it with StopAdvertising
+func Advertise(adapterID string, adv *profile.Advertisement) (*profile.LEAdvertisement1, error) {
+	a := &advertising{opts: defaultOptions(nil), adv: adv}
+	err := publish(adapterID, "advertisement", a)
+	if err != nil {
+		return nil, err
+	}
+	return a.advertisement, nil
+}
+
+//StopAdvertising unregister an advertisement from an adapter and remove it from the bus
+func StopAdvertising(adapterID string, advertisement *profile.LEAdvertisement1) error {
+	manager := profile.NewLEAdvertisingManager1(adapterID, defaultOptions(nil)...)
+	defer manager.Close()
+	err := manager.UnregisterAdvertisement(advertisement.Path)
+	advertisement.Close()
+	return err
+}
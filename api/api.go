@@ -28,7 +28,7 @@ func GetDeviceByAddress(address string) (*Device, error) {
 	}
 	for _, path := range list {
 		// fmt.Sprintf("Check device %s", path)
-		dev := NewDevice(string(path))
+		dev := NewDevice(string(path), GetManager().opts...)
 		if dev.Properties.Address == address {
 			// fmt.Sprintf("Address found")
 			return dev, nil
@@ -45,12 +45,13 @@ func GetDevices() ([]Device, error) {
 		return nil, err
 	}
 
-	objects := GetManager().GetObjects()
+	m := GetManager()
+	objects := m.GetObjects()
 
 	var devices = make([]Device, 0)
 	for _, path := range list {
 		props := (*objects)[path][bluez.Device1Interface]
-		dev, err := ParseDevice(path, props)
+		dev, err := ParseDevice(path, props, m.opts...)
 		if err != nil {
 			return nil, err
 		}
@@ -91,8 +92,8 @@ func AdapterExists(adapterID string) (bool, error) {
 	return exists, nil
 }
 
-//GetAdapter return an adapter object instance
-func GetAdapter(adapterID string) (*profile.Adapter1, error) {
+//GetAdapter return an adapter object instance, the options default to those of the manager
+func GetAdapter(adapterID string, opts ...profile.Option) (*profile.Adapter1, error) {
 
 	if exists, err := AdapterExists(adapterID); !exists {
 		if err != nil {
@@ -101,7 +102,7 @@ func GetAdapter(adapterID string) (*profile.Adapter1, error) {
 		return nil, errors.New("Adapter " + adapterID + " not found")
 	}
 
-	return profile.NewAdapter1(adapterID), nil
+	return profile.NewAdapter1(adapterID, defaultOptions(opts)...), nil
 }
 
 //StartDiscovery on adapter hci0
@@ -114,8 +115,13 @@ func StopDiscovery() error {
 	return StopDiscoveryOn("hci0")
 }
 
-// StartDiscoveryOn start discovery on specified adapter
-func StartDiscoveryOn(adapterID string) error {
+// StartDiscoveryOn start discovery on specified adapter, an optional filter restricts the
+// reported devices, eg. to the service UUIDs of a SensorTag
+func StartDiscoveryOn(adapterID string, filter ...*profile.DiscoveryFilter) error {
+
+	if len(filter) > 1 {
+		return errors.New("Only one discovery filter can be set")
+	}
 
 	adapter, err := GetAdapter(adapterID)
 
@@ -123,6 +129,13 @@ func StartDiscoveryOn(adapterID string) error {
 		return err
 	}
 
+	if len(filter) == 1 {
+		err = adapter.SetDiscoveryFilter(filter[0])
+		if err != nil {
+			return err
+		}
+	}
+
 	err = adapter.StartDiscovery()
 
 	if err != nil {
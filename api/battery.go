@@ -0,0 +1,365 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/bluez"
+	"github.com/saurabh-newera/BLE/bluez/profile"
+	"github.com/saurabh-newera/BLE/util"
+)
+
+// ErrNoBattery is returned when a device has neither Battery1 nor a Battery Level characteristic
+var ErrNoBattery = errors.New("Battery level not available")
+
+// hasInterface check in the ObjectManager cache if the device object implements iface
+func (d *Device) hasInterface(iface string) bool {
+	return GetManager().hasInterface(dbus.ObjectPath(d.Path), iface)
+}
+
+// batteryChar return the Battery Level characteristic, nil if the device has none
+func (d *Device) batteryChar() (*profile.GattCharacteristic1, error) {
+	char, err := d.GetCharByUUID(profile.BatteryLevelUUID)
+	if err != nil {
+		return nil, err
+	}
+	if char == nil {
+		return nil, ErrNoBattery
+	}
+	return char, nil
+}
+
+//GetBatteryLevel return the battery percentage of the device
+func (d *Device) GetBatteryLevel() (byte, error) {
+	return d.GetBatteryLevelContext(context.Background())
+}
+
+//GetBatteryLevelContext return the battery percentage from Battery1, or read from the
+// GATT Battery Service when bluez does not expose it. The call is aborted when ctx is done
+func (d *Device) GetBatteryLevelContext(ctx context.Context) (byte, error) {
+
+	if d.hasInterface(bluez.Battery1Interface) {
+		battery := profile.NewBattery1(d.Path, d.opts...)
+		defer battery.Close()
+		props, err := battery.GetPropertiesContext(ctx)
+		if err != nil {
+			return 0, err
+		}
+		return props.Percentage, nil
+	}
+
+	char, err := d.batteryChar()
+	if err != nil {
+		return 0, err
+	}
+	value, err := char.ReadContext(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	return profile.ParseBatteryLevel(value)
+}
+
+// batteryWatches the devices following the GATT Battery Service, switched to Battery1
+// once bluez exposes it. It is guarded by watchLock
+var batteryWatches = make(map[dbus.ObjectPath]*Device)
+
+// batteryWatch follow the battery level of a device, see Device.On("battery")
+type batteryWatch struct {
+	battery *profile.Battery1
+	changes chan *bluez.PropertyChange
+	reader  *profile.GattNotifyReader
+}
+
+// close stop following the battery level
+func (w *batteryWatch) close() error {
+	if w.reader != nil {
+		return w.reader.Close()
+	}
+	w.battery.UnwatchProperties(w.changes)
+	w.battery.Close()
+	return nil
+}
+
+// watchBattery emit a BatteryEvent on each change of the battery level
+func (d *Device) watchBattery() error {
+
+	watchLock.Lock()
+	watching := d.battery != nil
+	watchLock.Unlock()
+	if watching {
+		return nil
+	}
+
+	w, err := d.newBatteryWatch()
+	if err != nil {
+		return err
+	}
+
+	watchLock.Lock()
+	if d.battery != nil {
+		// watched concurrently
+		watchLock.Unlock()
+		return w.close()
+	}
+	d.battery = w
+	if w.reader != nil {
+		batteryWatches[dbus.ObjectPath(d.Path)] = d
+	}
+	watchLock.Unlock()
+	return nil
+}
+
+// newBatteryWatch follow Battery1, or the GATT Battery Level notifications when bluez
+// does not expose it
+func (d *Device) newBatteryWatch() (*batteryWatch, error) {
+
+	if d.hasInterface(bluez.Battery1Interface) {
+		battery := profile.NewBattery1(d.Path, d.opts...)
+		changes, err := battery.WatchProperties()
+		if err != nil {
+			battery.Close()
+			return nil, err
+		}
+		w := &batteryWatch{battery: battery, changes: changes}
+
+		go (func() {
+			// the channel is closed by unwatchBattery, or once Battery1 is removed
+			defer d.endBatteryWatch(w)
+			for change := range changes {
+				if _, ok := change.Changed["Percentage"]; !ok {
+					continue
+				}
+				// the change carries its own copy, battery.Properties is not updated
+				props := new(profile.Battery1Properties)
+				err := util.MapToStruct(props, change.Snapshot)
+				if err != nil {
+					logger.Warningf("Skip battery change: %s", err)
+					continue
+				}
+				d.Emit("battery", BatteryEvent{
+					Device:     d,
+					Percentage: props.Percentage,
+					Source:     props.Source,
+				})
+			}
+		})()
+		return w, nil
+	}
+
+	char, err := d.batteryChar()
+	if err != nil {
+		return nil, err
+	}
+	reader, err := char.NewNotifyReader()
+	if err != nil {
+		return nil, err
+	}
+	w := &batteryWatch{reader: reader}
+
+	go (func() {
+		// ReadPacket fails once the reader is closed by unwatchBattery
+		defer d.endBatteryWatch(w)
+		for {
+			value, err := reader.ReadPacket()
+			if err != nil {
+				return
+			}
+			percentage, err := profile.ParseBatteryLevel(value)
+			if err != nil {
+				continue
+			}
+			d.Emit("battery", BatteryEvent{
+				Device:     d,
+				Percentage: percentage,
+				Source:     profile.BatterySourceGATT,
+			})
+		}
+	})()
+	return w, nil
+}
+
+// endBatteryWatch forget w once its changes end on their own, eg. the device is
+// disconnected, so that a later On("battery") watches the battery again
+func (d *Device) endBatteryWatch(w *batteryWatch) {
+	watchLock.Lock()
+	ended := d.battery == w
+	if ended {
+		d.battery = nil
+		if batteryWatches[dbus.ObjectPath(d.Path)] == d {
+			delete(batteryWatches, dbus.ObjectPath(d.Path))
+		}
+	}
+	watchLock.Unlock()
+
+	if ended {
+		w.close()
+	}
+}
+
+func (d *Device) unwatchBattery() error {
+	watchLock.Lock()
+	w := d.battery
+	d.battery = nil
+	if batteryWatches[dbus.ObjectPath(d.Path)] == d {
+		delete(batteryWatches, dbus.ObjectPath(d.Path))
+	}
+	watchLock.Unlock()
+
+	if w == nil {
+		return nil
+	}
+	return w.close()
+}
+
+// batteryAdded switch the battery watch of a device from the GATT Battery Service to
+// Battery1, once bluez exposes it, eg. after connecting
+func batteryAdded(path dbus.ObjectPath) {
+	watchLock.Lock()
+	d, ok := batteryWatches[path]
+	watchLock.Unlock()
+	if !ok {
+		return
+	}
+
+	d.unwatchBattery()
+	err := d.watchBattery()
+	if err != nil {
+		logger.Warningf("Failed to watch Battery1 of %s: %s", path, err)
+	}
+}
+
+// BatteryProvider export battery levels known by the host, eg. read through a vendor protocol,
+// bluez then exposes them as Battery1 on the devices
+type BatteryProvider struct {
+	adapterID string
+	opts      []profile.Option
+	root      dbus.ObjectPath
+	client    *bluez.Client
+
+	lock      sync.Mutex
+	batteries map[dbus.ObjectPath]*profile.BatteryProvider1
+	seq       int
+}
+
+//StartBatteryProvider export a battery provider and register it on an adapter, stop it
+// with StopBatteryProvider. The batteries are added with SetBattery
+func StartBatteryProvider(adapterID string) (*BatteryProvider, error) {
+	p := &BatteryProvider{
+		adapterID: adapterID,
+		opts:      defaultOptions(nil),
+		batteries: make(map[dbus.ObjectPath]*profile.BatteryProvider1),
+	}
+	err := publish(adapterID, "battery", p)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *BatteryProvider) export(root dbus.ObjectPath) error {
+	p.root = root
+	p.client = newObjectManagerClient(root, p.opts)
+	return p.client.Export(p, p.root, bluez.ObjectManagerInterface)
+}
+
+func (p *BatteryProvider) register(adapterID string) error {
+	manager := profile.NewBatteryProviderManager1(adapterID, p.opts...)
+	defer manager.Close()
+	return manager.RegisterBatteryProvider(p.root)
+}
+
+//StopBatteryProvider unregister a battery provider and remove its batteries from the bus
+func StopBatteryProvider(p *BatteryProvider) error {
+	manager := profile.NewBatteryProviderManager1(p.adapterID, p.opts...)
+	defer manager.Close()
+	err := manager.UnregisterBatteryProvider(p.root)
+	p.close()
+	return err
+}
+
+// Path return the object path of the provider
+func (p *BatteryProvider) Path() dbus.ObjectPath {
+	return p.root
+}
+
+//SetBattery add or update the battery level of a device, identified by its object path
+func (p *BatteryProvider) SetBattery(device string, percentage byte, source string) error {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if battery, ok := p.batteries[dbus.ObjectPath(device)]; ok {
+		return battery.Update(percentage, source)
+	}
+
+	path := fmt.Sprintf("%s/battery%d", p.root, p.seq)
+	battery := profile.NewBatteryProvider1(path, &profile.Battery{
+		Device:     dbus.ObjectPath(device),
+		Percentage: percentage,
+		Source:     source,
+	}, p.opts...)
+	err := battery.Export()
+	if err != nil {
+		battery.Close()
+		return err
+	}
+	props, err := battery.ManagedProperties()
+	if err != nil {
+		battery.Close()
+		return err
+	}
+	p.seq++
+	p.batteries[dbus.ObjectPath(device)] = battery
+
+	return p.client.Emit(p.root, bluez.InterfacesAdded, battery.Path,
+		map[string]map[string]dbus.Variant{bluez.BatteryProvider1Interface: props})
+}
+
+//RemoveBattery remove the battery of a device
+func (p *BatteryProvider) RemoveBattery(device string) error {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	battery, ok := p.batteries[dbus.ObjectPath(device)]
+	if !ok {
+		return nil
+	}
+	delete(p.batteries, dbus.ObjectPath(device))
+	battery.Close()
+
+	return p.client.Emit(p.root, bluez.InterfacesRemoved, battery.Path,
+		[]string{bluez.BatteryProvider1Interface})
+}
+
+//GetManagedObjects is called by bluez to list the batteries
+func (p *BatteryProvider) GetManagedObjects() (map[dbus.ObjectPath]map[string]map[string]dbus.Variant, *dbus.Error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	objects := make(map[dbus.ObjectPath]map[string]map[string]dbus.Variant)
+	for _, battery := range p.batteries {
+		props, err := battery.ManagedProperties()
+		if err != nil {
+			return nil, bluez.ToDBusError(err)
+		}
+		objects[battery.Path] = map[string]map[string]dbus.Variant{
+			bluez.BatteryProvider1Interface: props,
+		}
+	}
+	return objects, nil
+}
+
+func (p *BatteryProvider) close() {
+	p.lock.Lock()
+	for device, battery := range p.batteries {
+		battery.Close()
+		delete(p.batteries, device)
+	}
+	p.lock.Unlock()
+	if p.client != nil {
+		p.client.Disconnect()
+	}
+}
@@ -29,6 +29,22 @@ type DiscoveredDeviceEvent struct {
 	Device *Device
 }
 
+//MonitorEvent reports a device found or lost by an advertisement monitor, see StartMonitor
+type MonitorEvent struct {
+	Path    string
+	Monitor string
+	Status  DeviceStatus
+	Device  *Device
+}
+
+//BatteryEvent reports a change of the battery level of a device, see Device.On("battery")
+type BatteryEvent struct {
+	Device     *Device
+	Percentage byte
+	// Source of the level, eg. profile.BatterySourceGATT
+	Source string
+}
+
 // AdapterEvent reports the availability of a bluetooth adapter
 type AdapterEvent struct {
 	Name   string
@@ -36,6 +52,12 @@ type AdapterEvent struct {
 	Status DeviceStatus
 }
 
+// ServiceEvent reports the bluez service (bluetoothd) leaving or joining the bus
+type ServiceEvent struct {
+	Name  string
+	Owner string
+}
+
 // PropertyChangedEvent an object to describe a changed property
 type PropertyChangedEvent struct {
 	Iface      string
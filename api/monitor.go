@@ -0,0 +1,131 @@
+package api
+
+import (
+	"sync"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/bluez"
+	"github.com/saurabh-newera/BLE/bluez/profile"
+	"github.com/saurabh-newera/BLE/emitter"
+)
+
+// AdvertisementMonitor an advertisement monitor registered with StartMonitor. The matching
+// devices are reported as MonitorEvent on the "monitor" event
+type AdvertisementMonitor struct {
+	adapterID string
+	opts      []profile.Option
+	root      dbus.ObjectPath
+	client    *bluez.Client
+	pattern   *profile.Monitor
+	monitor   *profile.AdvertisementMonitor1
+
+	lock   sync.Mutex
+	active bool
+}
+
+//StartMonitor export monitor and register it on an adapter, stop it with StopMonitor.
+// Devices are reported passively, without a running discovery
+func StartMonitor(adapterID string, monitor *profile.Monitor) (*AdvertisementMonitor, error) {
+	m := &AdvertisementMonitor{adapterID: adapterID, opts: defaultOptions(nil), pattern: monitor}
+	err := publish(adapterID, "monitor", m)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *AdvertisementMonitor) export(root dbus.ObjectPath) error {
+	m.root = root
+	m.client = newObjectManagerClient(root, m.opts)
+	m.monitor = profile.NewAdvertisementMonitor1(string(root)+"/monitor0", m.pattern, &monitorHandler{m}, m.opts...)
+	err := m.monitor.Export()
+	if err != nil {
+		return err
+	}
+	return m.client.Export(m, m.root, bluez.ObjectManagerInterface)
+}
+
+func (m *AdvertisementMonitor) register(adapterID string) error {
+	manager := profile.NewAdvertisementMonitorManager1(adapterID, m.opts...)
+	defer manager.Close()
+	return manager.RegisterMonitor(m.root)
+}
+
+//StopMonitor unregister a monitor and remove it from the bus
+func StopMonitor(m *AdvertisementMonitor) error {
+	manager := profile.NewAdvertisementMonitorManager1(m.adapterID, m.opts...)
+	defer manager.Close()
+	err := manager.UnregisterMonitor(m.root)
+	m.close()
+	return err
+}
+
+// Path return the object path of the monitor
+func (m *AdvertisementMonitor) Path() dbus.ObjectPath {
+	return m.monitor.Path
+}
+
+//GetManagedObjects is called by bluez to list the monitors
+func (m *AdvertisementMonitor) GetManagedObjects() (map[dbus.ObjectPath]map[string]map[string]dbus.Variant, *dbus.Error) {
+	props, err := m.monitor.ManagedProperties()
+	if err != nil {
+		return nil, bluez.ToDBusError(err)
+	}
+	return map[dbus.ObjectPath]map[string]map[string]dbus.Variant{
+		m.monitor.Path: {bluez.AdvertisementMonitor1Interface: props},
+	}, nil
+}
+
+// Active check if bluez activated the monitor, it is inactive once released by bluez
+func (m *AdvertisementMonitor) Active() bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.active
+}
+
+func (m *AdvertisementMonitor) setActive(active bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.active = active
+}
+
+func (m *AdvertisementMonitor) close() {
+	m.setActive(false)
+	if m.monitor != nil {
+		m.monitor.Close()
+	}
+	if m.client != nil {
+		m.client.Disconnect()
+	}
+}
+
+// monitorHandler emit the devices reported to a monitor
+type monitorHandler struct {
+	monitor *AdvertisementMonitor
+}
+
+func (h *monitorHandler) Release() {
+	h.monitor.setActive(false)
+}
+
+func (h *monitorHandler) Activate() {
+	h.monitor.setActive(true)
+}
+
+func (h *monitorHandler) DeviceFound(device dbus.ObjectPath) {
+	h.emit(device, DeviceAdded)
+}
+
+func (h *monitorHandler) DeviceLost(device dbus.ObjectPath) {
+	h.emit(device, DeviceRemoved)
+}
+
+func (h *monitorHandler) emit(device dbus.ObjectPath, status DeviceStatus) {
+	ev := MonitorEvent{
+		Path:    string(device),
+		Monitor: string(h.monitor.Path()),
+		Status:  status,
+		Device:  NewDevice(string(device), h.monitor.opts...),
+	}
+	emitter.Emit("monitor", ev)
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/bluez"
+	"github.com/saurabh-newera/BLE/bluez/profile"
+)
+
+var (
+	publishLock sync.Mutex
+	publishSeq  = make(map[string]int)
+)
+
+// publisher an object exported by the application then registered on an adapter,
+// eg. an advertisement or a battery provider
+type publisher interface {
+	// export the object on the bus at path
+	export(path dbus.ObjectPath) error
+	// register the exported object on the adapter
+	register(adapterID string) error
+	// close remove from the bus what export added, even partially
+	close()
+}
+
+// publishPath return a new object path for an exported object, eg. /org/bluez/go/monitor0
+func publishPath(kind string) dbus.ObjectPath {
+	publishLock.Lock()
+	defer publishLock.Unlock()
+	path := fmt.Sprintf("/org/bluez/go/%s%d", kind, publishSeq[kind])
+	publishSeq[kind]++
+	return dbus.ObjectPath(path)
+}
+
+// publish check the adapter, then export obj at a new path and register it.
+// On failure obj is closed, so nothing is left on the bus
+func publish(adapterID string, kind string, obj publisher) error {
+
+	if exists, err := AdapterExists(adapterID); !exists {
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("Adapter %s not found", adapterID)
+	}
+
+	err := obj.export(publishPath(kind))
+	if err == nil {
+		err = obj.register(adapterID)
+	}
+	if err != nil {
+		obj.close()
+		return err
+	}
+	return nil
+}
+
+// newObjectManagerClient return a client to export the ObjectManager of an application at root
+func newObjectManagerClient(root dbus.ObjectPath, opts []profile.Option) *bluez.Client {
+	config := &bluez.Config{
+		Name:  "org.bluez",
+		Iface: bluez.ObjectManagerInterface,
+		Path:  string(root),
+		Bus:   bluez.SystemBus,
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+	return bluez.NewClient(config)
+}
@@ -1,34 +1,54 @@
 package bluez
 
 import (
+	"context"
+	"strings"
+	"sync"
+
 	"github.com/godbus/dbus"
-	"github.com/saurabh-newera/BLE/util"
 	"fmt"
+	logging "github.com/op/go-logging"
 )
 
+var logger = logging.MustGetLogger("bluez")
+
 // NewClient create a new client
 func NewClient(config *Config) *Client {
 	fmt.Sprintf("Create new client: %v", config)
 	c := new(Client)
 	c.Config = config
+	c.signals = make(map[chan *dbus.Signal]*clientSubscription)
+	c.exports = make(map[exportKey]bool)
 	return c
 }
 
 // Client implement a DBus client
 type Client struct {
+	lock       sync.Mutex
 	conn       *dbus.Conn
 	dbusObject dbus.BusObject
+	signals    map[chan *dbus.Signal]*clientSubscription
+	cache      *PropertyCache
+	exports    map[exportKey]bool
 	Config     *Config
 }
 
-func (c *Client) isConnected() bool {
-	return c.conn != nil
-}
-
-//Disconnect from DBus
+//Disconnect from DBus, the shared connection is closed once unused by every client
 func (c *Client) Disconnect() {
-	if c.isConnected() {
-		c.conn.Close()
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for channel := range c.signals {
+		c.unsubscribe(channel)
+	}
+	for key := range c.exports {
+		c.unexport(key)
+	}
+	if c.cache != nil {
+		c.cache.Release()
+		c.cache = nil
+	}
+	if c.conn != nil {
+		ReleaseConnection(c.conn)
 		c.conn = nil
 		c.dbusObject = nil
 		fmt.Sprintf("Client disconnected")
@@ -37,7 +57,16 @@ func (c *Client) Disconnect() {
 
 // Connect connects to DBus
 func (c *Client) Connect() error {
-	dbusConn, err := GetConnection(c.Config.Bus)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.connect()
+}
+
+func (c *Client) connect() error {
+	if c.conn != nil {
+		return nil
+	}
+	dbusConn, err := AcquireConnection(c.Config)
 	if err != nil {
 		return err
 	}
@@ -47,103 +76,353 @@ func (c *Client) Connect() error {
 	return nil
 }
 
+// getObject return the remote object, connecting if needed
+func (c *Client) getObject() (*dbus.Conn, dbus.BusObject, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if err := c.connect(); err != nil {
+		return nil, nil, err
+	}
+	return c.conn, c.dbusObject, nil
+}
+
 // Call a DBus method
 func (c *Client) Call(method string, flags dbus.Flags, args ...interface{}) *dbus.Call {
+	return c.CallContext(context.Background(), method, flags, args...)
+}
 
-	if !c.isConnected() {
-		err := c.Connect()
-		if err != nil {
-			return &dbus.Call{
-				Err: err,
-			}
-		}
+// CallContext call a DBus method, the call is aborted when ctx is done
+func (c *Client) CallContext(ctx context.Context, method string, flags dbus.Flags, args ...interface{}) *dbus.Call {
+	return c.callInterface(ctx, c.Config.Iface, method, flags, args...)
+}
+
+// callInterface call a method of iface on the client object through the interceptors
+func (c *Client) callInterface(ctx context.Context, iface string, method string, flags dbus.Flags, args ...interface{}) *dbus.Call {
+
+	op := c.newOperation(OperationCall, iface, method)
+	op.Flags = flags
+	op.Args = args
+
+	fmt.Sprintf("Call %s( %v )", op.Method(), args)
+
+	err := c.invoke(ctx, op)
+
+	return &dbus.Call{
+		Destination: op.Destination,
+		Path:        op.Path,
+		Method:      op.Method(),
+		Args:        op.Args,
+		Body:        op.Reply,
+		Err:         err,
 	}
+}
 
-	methodPath := c.Config.Iface + "." + method
+// newOperation create an operation on the client object
+func (c *Client) newOperation(kind OperationKind, iface string, member string) *Operation {
+	return &Operation{
+		Kind:        kind,
+		Destination: c.Config.Name,
+		Path:        dbus.ObjectPath(c.Config.Path),
+		Interface:   iface,
+		Member:      member,
+	}
+}
 
-	fmt.Sprintf("Call %s( %v )", methodPath, args)
+// invoke run an operation through the interceptors
+func (c *Client) invoke(ctx context.Context, op *Operation) error {
+	return chain(getInterceptors(c.Config), c.execute)(ctx, op)
+}
+
+// execute run an operation on the backend or the D-Bus connection, it ends the interceptors chain
+func (c *Client) execute(ctx context.Context, op *Operation) error {
 
-	return c.dbusObject.Call(methodPath, flags, args...)
+	if backend := getBackend(c.Config); backend != nil {
+		return backend.Invoke(ctx, op)
+	}
+
+	_, obj, err := c.getObject()
+	if err != nil {
+		return err
+	}
+
+	switch op.Kind {
+	case OperationGetProperty:
+		var v dbus.Variant
+		err = obj.CallWithContext(ctx, PropertiesInterface+".Get", 0, op.Interface, op.Member).Store(&v)
+		if err != nil {
+			return wrapError(err, op.Path, PropertiesInterface+".Get")
+		}
+		op.Reply = []interface{}{v}
+		return nil
+	case OperationSetProperty:
+		err = obj.CallWithContext(ctx, PropertiesInterface+".Set", 0, op.Interface, op.Member, op.Args[0]).Store()
+		return wrapError(err, op.Path, PropertiesInterface+".Set")
+	}
+
+	call := obj.CallWithContext(ctx, op.Method(), op.Flags, op.Args...)
+	if call.Err != nil {
+		return wrapError(call.Err, op.Path, op.Method())
+	}
+	op.Reply = call.Body
+	return nil
 }
 
 //GetProperty return a property value
 func (c *Client) GetProperty(p string) (dbus.Variant, error) {
-	if !c.isConnected() {
-		err := c.Connect()
-		if err != nil {
-			return dbus.Variant{}, err
-		}
+	return c.GetPropertyContext(context.Background(), p)
+}
+
+//GetPropertyContext return a property value, the call is aborted when ctx is done
+func (c *Client) GetPropertyContext(ctx context.Context, p string) (dbus.Variant, error) {
+
+	op := c.newOperation(OperationGetProperty, c.Config.Iface, p)
+
+	err := c.invoke(ctx, op)
+	if err != nil {
+		return dbus.Variant{}, err
+	}
+
+	if len(op.Reply) == 0 {
+		return dbus.Variant{}, nil
+	}
+	v, ok := op.Reply[0].(dbus.Variant)
+	if !ok {
+		v = dbus.MakeVariant(op.Reply[0])
 	}
-	return c.dbusObject.GetProperty(c.Config.Iface + "." + p)
+	return v, nil
 }
 
 //SetProperty set a property value
 func (c *Client) SetProperty(p string, v interface{}) error {
-	if !c.isConnected() {
-		err := c.Connect()
-		if err != nil {
-			return err
-		}
+	return c.SetPropertyContext(context.Background(), p, v)
+}
+
+//SetPropertyContext set a property value, the call is aborted when ctx is done
+func (c *Client) SetPropertyContext(ctx context.Context, p string, v interface{}) error {
+
+	variant, ok := v.(dbus.Variant)
+	if !ok {
+		variant = dbus.MakeVariant(v)
 	}
-	return c.dbusObject.Call("org.freedesktop.DBus.Properties.Set", 0, c.Config.Iface, p, v).Store()
+
+	op := c.newOperation(OperationSetProperty, c.Config.Iface, p)
+	op.Args = []interface{}{variant}
+
+	return c.invoke(ctx, op)
 }
 
 //GetProperties load all the properties for an interface
 func (c *Client) GetProperties(props interface{}) error {
+	return c.GetPropertiesContext(context.Background(), props)
+}
 
-	if !c.isConnected() {
-		err := c.Connect()
-		if err != nil {
-			return err
-		}
-	}
+//GetPropertiesContext load all the properties for an interface from the property cache,
+// props is filled with a snapshot, updated by the next call. The call is aborted when ctx is done
+func (c *Client) GetPropertiesContext(ctx context.Context, props interface{}) error {
 
 	fmt.Sprintf("Loading properties for %s", c.Config.Iface)
 
-	result := make(map[string]dbus.Variant)
-	err := c.dbusObject.Call("org.freedesktop.DBus.Properties.GetAll", 0, c.Config.Iface).Store(&result)
+	cache, err := c.PropertyCacheContext(ctx)
 	if err != nil {
 		return err
 	}
 
-	return util.MapToStruct(props, result)
+	return cache.Load(props)
 }
 
-func getMatchString(path string, iface string) string {
-	return "type='signal',interface='" + iface + "',path='" + path + "'"
+//PropertyCache return the shared cache of the client object properties
+func (c *Client) PropertyCache() (*PropertyCache, error) {
+	return c.PropertyCacheContext(context.Background())
 }
 
-//Register for signals
-func (c *Client) Register(path string, iface string) (chan *dbus.Signal, error) {
+//PropertyCacheContext return the shared cache of the client object properties,
+// loading it if needed. The loading is aborted when ctx is done
+func (c *Client) PropertyCacheContext(ctx context.Context) (*PropertyCache, error) {
+
+	c.lock.Lock()
+	cache := c.cache
+	c.lock.Unlock()
+	if cache != nil {
+		return cache, nil
+	}
+
+	cache, err := acquirePropertyCache(ctx, c.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.cache != nil {
+		// loaded concurrently
+		cache.Release()
+		return c.cache, nil
+	}
+	c.cache = cache
+	return cache, nil
+}
+
+//WatchProperties return a channel receiving the changes of the client object properties
+func (c *Client) WatchProperties() (chan *PropertyChange, error) {
+	cache, err := c.PropertyCache()
+	if err != nil {
+		return nil, err
+	}
+	return cache.Watch(), nil
+}
+
+//UnwatchProperties stop the delivery of changes to a channel returned by WatchProperties and close it
+func (c *Client) UnwatchProperties(channel chan *PropertyChange) {
+	c.lock.Lock()
+	cache := c.cache
+	c.lock.Unlock()
+	if cache != nil {
+		cache.Unwatch(channel)
+	}
+}
+
+//Subscribe return a channel receiving the signals selected by match. Signals are
+// routed by a dispatcher shared by all the clients of the connection
+func (c *Client) Subscribe(match SignalMatch) (chan *dbus.Signal, error) {
 
-	if !c.isConnected() {
-		err := c.Connect()
+	var source signalSource
+	if backend := getBackend(c.Config); backend != nil {
+		source = backend
+	} else {
+		conn, _, err := c.getObject()
 		if err != nil {
 			return nil, err
 		}
+		source = getRouter(conn)
 	}
 
-	matchstr := getMatchString(path, iface)
-	fmt.Sprintf("Match on %s", matchstr)
-	c.conn.BusObject().Call("org.freedesktop.DBus.AddMatch", 0, matchstr)
+	fmt.Sprintf("Subscribe to %s", match.Rule())
+	routed, err := source.Subscribe(match)
+	if err != nil {
+		return nil, err
+	}
 
-	channel := make(chan *dbus.Signal, 1)
-	c.conn.Signal(channel)
+	sub := &clientSubscription{
+		match:   match,
+		source:  source,
+		routed:  routed,
+		channel: routed,
+	}
 
-	return channel, nil
+	list := getInterceptors(c.Config)
+	if len(list) > 0 {
+		sub.channel = make(chan *dbus.Signal, 1)
+		sub.done = make(chan struct{})
+		go sub.intercept(list)
+	}
+
+	c.lock.Lock()
+	c.signals[sub.channel] = sub
+	c.lock.Unlock()
+
+	return sub.channel, nil
 }
 
-//Unregister for signals
-func (c *Client) Unregister(path string, iface string) error {
-	if !c.isConnected() {
-		err := c.Connect()
-		if err != nil {
-			return err
+// clientSubscription a signal subscription of a client
+type clientSubscription struct {
+	match  SignalMatch
+	source signalSource
+	// routed receives the signals from the router or the backend
+	routed chan *dbus.Signal
+	// channel is returned to the subscriber, it differs from routed when signals are intercepted
+	channel chan *dbus.Signal
+	done    chan struct{}
+}
+
+// intercept run the routed signals through the interceptors before delivering them
+func (sub *clientSubscription) intercept(list []Interceptor) {
+	defer close(sub.channel)
+
+	deliver := chain(list, func(ctx context.Context, op *Operation) error {
+		sig := &dbus.Signal{
+			Sender: op.Destination,
+			Path:   op.Path,
+			Name:   op.Method(),
+			Body:   op.Reply,
+		}
+		select {
+		case sub.channel <- sig:
+		case <-sub.done:
+		}
+		return nil
+	})
+
+	for sig := range sub.routed {
+		op := &Operation{
+			Kind:        OperationSignal,
+			Destination: sig.Sender,
+			Path:        sig.Path,
+			Reply:       sig.Body,
+			Match:       &sub.match,
 		}
+		pos := strings.LastIndex(sig.Name, ".")
+		if pos > -1 {
+			op.Interface = sig.Name[:pos]
+			op.Member = sig.Name[pos+1:]
+		}
+		deliver(context.Background(), op)
+	}
+}
+
+//Unsubscribe stop the delivery of signals to a channel returned by Subscribe or Register
+// and close it. Other subscribers are not affected
+func (c *Client) Unsubscribe(channel chan *dbus.Signal) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.unsubscribe(channel)
+}
+
+func (c *Client) unsubscribe(channel chan *dbus.Signal) error {
+	sub, ok := c.signals[channel]
+	if !ok {
+		return nil
+	}
+	delete(c.signals, channel)
+	if sub.done != nil {
+		close(sub.done)
 	}
-	matchstr := getMatchString(path, iface)
-	fmt.Sprintf("Match on %s", matchstr)
-	c.conn.BusObject().Call("org.freedesktop.DBus.RemoveMatch", 0, matchstr)
+	return sub.source.Unsubscribe(sub.routed)
+}
+
+//RestoreSignals apply again the match rules registered on the client connection,
+// to be used once a restarted service is back on the bus
+func (c *Client) RestoreSignals() error {
+	if getBackend(c.Config) != nil {
+		return nil
+	}
+	conn, _, err := c.getObject()
+	if err != nil {
+		return err
+	}
+	return getRouter(conn).restore()
+}
 
+//Register for the signals emitted by the client service
+func (c *Client) Register(path string, iface string) (chan *dbus.Signal, error) {
+	return c.Subscribe(SignalMatch{
+		Sender:    c.Config.Name,
+		Path:      dbus.ObjectPath(path),
+		Interface: iface,
+	})
+}
+
+//Unregister for signals, the channels returned by Register for path and iface are closed
+func (c *Client) Unregister(path string, iface string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for channel, sub := range c.signals {
+		match := sub.match
+		if match.Path == dbus.ObjectPath(path) && match.Interface == iface && match.Member == "" {
+			err := c.unsubscribe(channel)
+			if err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
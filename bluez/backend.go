@@ -0,0 +1,45 @@
+package bluez
+
+import (
+	"context"
+	"sync"
+
+	"github.com/godbus/dbus"
+)
+
+// Backend execute the operations and deliver the signals of the clients in place
+// of the D-Bus connection, eg. a Replayer serving a recorded session
+type Backend interface {
+	// Invoke execute an operation and set its Reply
+	Invoke(ctx context.Context, op *Operation) error
+	// Subscribe return a channel receiving the signals selected by match
+	Subscribe(match SignalMatch) (chan *dbus.Signal, error)
+	// Unsubscribe stop the delivery of signals to channel and close it
+	Unsubscribe(channel chan *dbus.Signal) error
+}
+
+// signalSource deliver the signals of a Client, a router or a Backend
+type signalSource interface {
+	Subscribe(match SignalMatch) (chan *dbus.Signal, error)
+	Unsubscribe(channel chan *dbus.Signal) error
+}
+
+var backendLock sync.RWMutex
+var backend Backend
+
+// UseBackend set the backend used by every Client without a Config.Backend, nil restores the D-Bus connection
+func UseBackend(b Backend) {
+	backendLock.Lock()
+	defer backendLock.Unlock()
+	backend = b
+}
+
+// getBackend return the backend to use for a config, nil for the D-Bus connection
+func getBackend(config *Config) Backend {
+	if config.Backend != nil {
+		return config.Backend
+	}
+	backendLock.RLock()
+	defer backendLock.RUnlock()
+	return backend
+}
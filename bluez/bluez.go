@@ -12,6 +12,35 @@ const (
 	GattCharacteristic1Interface = "org.bluez.GattCharacteristic1"
 	//GattDescriptor1Interface the bluez interface for GattDescriptor1
 	GattDescriptor1Interface = "org.bluez.GattDescriptor1"
+	//AgentManager1Interface the bluez interface for AgentManager1
+	AgentManager1Interface = "org.bluez.AgentManager1"
+	//GattManager1Interface the bluez interface for GattManager1
+	GattManager1Interface = "org.bluez.GattManager1"
+	//LEAdvertisingManager1Interface the bluez interface for LEAdvertisingManager1
+	LEAdvertisingManager1Interface = "org.bluez.LEAdvertisingManager1"
+	//LEAdvertisement1Interface the bluez interface implemented by advertisements
+	LEAdvertisement1Interface = "org.bluez.LEAdvertisement1"
+	//Agent1Interface the bluez interface implemented by pairing agents
+	Agent1Interface = "org.bluez.Agent1"
+	//ProfileManager1Interface the bluez interface for ProfileManager1
+	ProfileManager1Interface = "org.bluez.ProfileManager1"
+	//Profile1Interface the bluez interface implemented by profiles
+	Profile1Interface = "org.bluez.Profile1"
+	//AdvertisementMonitorManager1Interface the bluez interface for AdvertisementMonitorManager1
+	AdvertisementMonitorManager1Interface = "org.bluez.AdvertisementMonitorManager1"
+	//AdvertisementMonitor1Interface the bluez interface implemented by advertisement monitors
+	AdvertisementMonitor1Interface = "org.bluez.AdvertisementMonitor1"
+	//Battery1Interface the bluez interface for Battery1
+	Battery1Interface = "org.bluez.Battery1"
+	//BatteryProviderManager1Interface the bluez interface for BatteryProviderManager1
+	BatteryProviderManager1Interface = "org.bluez.BatteryProviderManager1"
+	//BatteryProvider1Interface the bluez interface implemented by battery providers
+	BatteryProvider1Interface = "org.bluez.BatteryProvider1"
+
+	//ObjectManagerInterface the DBus object manager interface
+	ObjectManagerInterface = "org.freedesktop.DBus.ObjectManager"
+	//IntrospectableInterface the DBus introspection interface
+	IntrospectableInterface = "org.freedesktop.DBus.Introspectable"
 
 	//InterfacesRemoved the DBus signal member for InterfacesRemoved
 	InterfacesRemoved = "org.freedesktop.DBus.ObjectManager.InterfacesRemoved"
@@ -0,0 +1,553 @@
+package bluez
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/util"
+)
+
+// PropertyChange describe an update applied to a PropertyCache
+type PropertyChange struct {
+	Path        dbus.ObjectPath
+	Interface   string
+	Changed     map[string]dbus.Variant
+	Invalidated []string
+	// Snapshot is the content of the cache once the change is applied
+	Snapshot map[string]dbus.Variant
+}
+
+// propertyWatcher receives the changes of a cache
+type propertyWatcher struct {
+	channel chan *PropertyChange
+	// wake is signaled when a change is queued or the watcher is ended
+	wake chan struct{}
+	done chan struct{}
+
+	lock    sync.Mutex
+	pending []*PropertyChange
+	ended   bool
+	warned  bool
+}
+
+func newPropertyWatcher() *propertyWatcher {
+	return &propertyWatcher{
+		channel: make(chan *PropertyChange, 1),
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+}
+
+// push queue a change without blocking. The queue of a slow watcher grows, so it
+// still sees every intermediate value, eg. each notified Value
+func (w *propertyWatcher) push(change *PropertyChange) {
+	w.lock.Lock()
+	w.pending = append(w.pending, change)
+	backlog := len(w.pending)
+	warn := backlog >= subscriberQueueSize && !w.warned
+	if warn {
+		w.warned = true
+	} else if backlog < subscriberQueueSize {
+		w.warned = false
+	}
+	w.lock.Unlock()
+
+	if warn {
+		logger.Warningf("Slow watcher of %s %s, %d changes queued", change.Path, change.Interface, backlog)
+	}
+	w.signal()
+}
+
+// end close the channel once the queued changes are delivered
+func (w *propertyWatcher) end() {
+	w.lock.Lock()
+	w.ended = true
+	w.lock.Unlock()
+	w.signal()
+}
+
+func (w *propertyWatcher) signal() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// next return the oldest queued change, nil if there is none
+func (w *propertyWatcher) next() (*PropertyChange, bool) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if len(w.pending) == 0 {
+		return nil, w.ended
+	}
+	change := w.pending[0]
+	w.pending[0] = nil
+	w.pending = w.pending[1:]
+	return change, false
+}
+
+// forward deliver queued changes in order, the channel is closed once unwatched or ended
+func (w *propertyWatcher) forward() {
+	defer close(w.channel)
+	for {
+		change, ended := w.next()
+		if ended {
+			return
+		}
+		if change == nil {
+			select {
+			case <-w.wake:
+			case <-w.done:
+				return
+			}
+			continue
+		}
+		select {
+		case w.channel <- change:
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// PropertyCache hold the properties of an object interface, kept up to date by the
+// PropertiesChanged signals. A cache is shared by all the clients of the same object
+type PropertyCache struct {
+	Path      dbus.ObjectPath
+	Interface string
+
+	key    string
+	client *Client
+	refs   int
+
+	// ready is closed once the cache is loaded, err is then set if the loading failed
+	ready chan struct{}
+	err   error
+
+	lock     sync.RWMutex
+	values   map[string]dbus.Variant
+	watchers map[chan *PropertyChange]*propertyWatcher
+	// version count the changes applied, versions hold the last change of each property
+	version  uint64
+	versions map[string]uint64
+	// removed is set once the interface is removed from the bus, until it is added again
+	removed bool
+}
+
+var cachesLock sync.Mutex
+var caches = make(map[string]*PropertyCache)
+
+// cacheKey identify the cache of an object interface on a bus or backend
+func cacheKey(config *Config) string {
+	key := connKey(config)
+	if backend := getBackend(config); backend != nil {
+		key = fmt.Sprintf("backend:%p", backend)
+	}
+	return key + " " + config.Path + " " + config.Iface
+}
+
+// acquirePropertyCache return the cache of the object interface described by config,
+// loading it on first use. Each call must be paired with Release
+func acquirePropertyCache(ctx context.Context, config *Config) (*PropertyCache, error) {
+
+	key := cacheKey(config)
+
+	for {
+		cachesLock.Lock()
+		cache, ok := caches[key]
+		if !ok {
+			// the cache has its own client, so it outlives the one requesting it
+			cacheConfig := *config
+			cache = &PropertyCache{
+				Path:      dbus.ObjectPath(config.Path),
+				Interface: config.Iface,
+				key:       key,
+				client:    NewClient(&cacheConfig),
+				refs:      1,
+				ready:     make(chan struct{}),
+				values:    make(map[string]dbus.Variant),
+				watchers:  make(map[chan *PropertyChange]*propertyWatcher),
+				versions:  make(map[string]uint64),
+			}
+			caches[key] = cache
+			cachesLock.Unlock()
+
+			// other callers wait for ready, the bus is not queried under cachesLock
+			err := cache.start(ctx)
+			cache.loaded(err)
+			if err != nil {
+				return nil, err
+			}
+			return cache, nil
+		}
+		cache.refs++
+		cachesLock.Unlock()
+
+		select {
+		case <-cache.ready:
+		case <-ctx.Done():
+			cache.Release()
+			return nil, ctx.Err()
+		}
+		if cache.err == nil {
+			return cache, nil
+		}
+		// try again when the caller loading the cache gave up
+		if !errors.Is(cache.err, context.Canceled) && !errors.Is(cache.err, context.DeadlineExceeded) {
+			return nil, cache.err
+		}
+	}
+}
+
+// loaded record the result of start and wake up the callers waiting for the cache.
+// A failed cache is dropped, so the next callers load it again
+func (p *PropertyCache) loaded(err error) {
+	if err != nil {
+		cachesLock.Lock()
+		if caches[p.key] == p {
+			delete(caches, p.key)
+		}
+		cachesLock.Unlock()
+		p.client.Disconnect()
+	}
+	p.err = err
+	close(p.ready)
+}
+
+// start subscribe to the changes then load the properties, so no update is lost
+func (p *PropertyCache) start(ctx context.Context) error {
+
+	signals, err := p.client.Subscribe(SignalMatch{
+		Sender:    p.client.Config.Name,
+		Path:      p.Path,
+		Interface: PropertiesInterface,
+		Member:    "PropertiesChanged",
+		Arg0:      p.Interface,
+	})
+	if err != nil {
+		return err
+	}
+
+	// the ObjectManager path differs between services, any path is accepted
+	objects, err := p.client.Subscribe(SignalMatch{
+		Sender:    p.client.Config.Name,
+		Interface: ObjectManagerInterface,
+	})
+	if err != nil {
+		return err
+	}
+
+	values := make(map[string]dbus.Variant)
+	err = p.client.callInterface(ctx, PropertiesInterface, "GetAll", 0, p.Interface).Store(&values)
+	if err != nil {
+		return err
+	}
+
+	p.lock.Lock()
+	p.values = values
+	p.lock.Unlock()
+
+	go p.loop(signals, objects)
+
+	return nil
+}
+
+func (p *PropertyCache) loop(signals chan *dbus.Signal, objects chan *dbus.Signal) {
+	for {
+		select {
+		case sig, ok := <-signals:
+			if !ok {
+				// the cache has been released
+				p.closeWatchers(false)
+				return
+			}
+			if len(sig.Body) < 2 {
+				continue
+			}
+			changed, ok := sig.Body[1].(map[string]dbus.Variant)
+			if !ok {
+				continue
+			}
+			var invalidated []string
+			if len(sig.Body) > 2 {
+				invalidated, _ = sig.Body[2].([]string)
+			}
+			p.apply(changed, invalidated)
+		case sig, ok := <-objects:
+			if !ok {
+				objects = nil
+				continue
+			}
+			switch sig.Name {
+			case InterfacesAdded:
+				// the object is back, eg. a device discovered again
+				if values, ok := p.addedValues(sig); ok {
+					p.lock.Lock()
+					p.removed = false
+					p.lock.Unlock()
+					// the signal follows every change already applied
+					p.reset(values, ^uint64(0))
+				}
+			case InterfacesRemoved:
+				if p.isRemoved(sig) {
+					p.lock.Lock()
+					p.removed = true
+					p.lock.Unlock()
+					p.closeWatchers(true)
+				}
+			}
+		}
+	}
+}
+
+// addedValues return the properties of the cached interface from an InterfacesAdded signal
+func (p *PropertyCache) addedValues(sig *dbus.Signal) (map[string]dbus.Variant, bool) {
+	if len(sig.Body) < 2 {
+		return nil, false
+	}
+	path, ok := sig.Body[0].(dbus.ObjectPath)
+	if !ok || path != p.Path {
+		return nil, false
+	}
+	ifaces, _ := sig.Body[1].(map[string]map[string]dbus.Variant)
+	values, ok := ifaces[p.Interface]
+	return values, ok
+}
+
+// isRemoved check if an InterfacesRemoved signal removes the cached interface
+func (p *PropertyCache) isRemoved(sig *dbus.Signal) bool {
+	if len(sig.Body) < 2 {
+		return false
+	}
+	path, ok := sig.Body[0].(dbus.ObjectPath)
+	if !ok || path != p.Path {
+		return false
+	}
+	ifaces, _ := sig.Body[1].([]string)
+	for _, iface := range ifaces {
+		if iface == p.Interface {
+			return true
+		}
+	}
+	return false
+}
+
+// closeWatchers close the channels of the watchers, once the queued changes are
+// delivered when flush is set
+func (p *PropertyCache) closeWatchers(flush bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for channel, watcher := range p.watchers {
+		if flush {
+			watcher.end()
+		} else {
+			close(watcher.done)
+		}
+		delete(p.watchers, channel)
+	}
+}
+
+// apply update the cache at once and notify the watchers, it never blocks on a slow watcher
+func (p *PropertyCache) apply(changed map[string]dbus.Variant, invalidated []string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.applyLocked(changed, invalidated)
+}
+
+// applyLocked update the cache and notify the watchers, the lock must be held
+func (p *PropertyCache) applyLocked(changed map[string]dbus.Variant, invalidated []string) {
+
+	if len(changed) == 0 && len(invalidated) == 0 {
+		return
+	}
+
+	p.version++
+	for name, value := range changed {
+		p.values[name] = value
+		p.versions[name] = p.version
+	}
+	for _, name := range invalidated {
+		delete(p.values, name)
+		p.versions[name] = p.version
+	}
+
+	change := &PropertyChange{
+		Path:        p.Path,
+		Interface:   p.Interface,
+		Changed:     changed,
+		Invalidated: invalidated,
+		Snapshot:    p.snapshot(),
+	}
+	for _, watcher := range p.watchers {
+		watcher.push(change)
+	}
+}
+
+// Refresh reload all the properties from the bus, eg. once the service is restarted.
+// Watchers are notified of the differences with the cached values, the properties
+// changed by a signal while loading are newer and kept
+func (p *PropertyCache) Refresh(ctx context.Context) error {
+
+	p.lock.RLock()
+	since := p.version
+	p.lock.RUnlock()
+
+	values := make(map[string]dbus.Variant)
+	err := p.client.callInterface(ctx, PropertiesInterface, "GetAll", 0, p.Interface).Store(&values)
+	if err != nil {
+		return err
+	}
+
+	p.reset(values, since)
+	return nil
+}
+
+// reset replace the cached values loaded after the change numbered since, the properties
+// changed later are kept. Watchers are notified of the differences
+func (p *PropertyCache) reset(values map[string]dbus.Variant, since uint64) {
+
+	changed := make(map[string]dbus.Variant)
+	var invalidated []string
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for name, value := range values {
+		if p.versions[name] > since {
+			continue
+		}
+		if old, ok := p.values[name]; !ok || !reflect.DeepEqual(old.Value(), value.Value()) {
+			changed[name] = value
+		}
+	}
+	for name := range p.values {
+		if _, ok := values[name]; !ok && p.versions[name] <= since {
+			invalidated = append(invalidated, name)
+		}
+	}
+
+	p.applyLocked(changed, invalidated)
+}
+
+// snapshot copy the values, the lock must be held
+func (p *PropertyCache) snapshot() map[string]dbus.Variant {
+	values := make(map[string]dbus.Variant, len(p.values))
+	for name, value := range p.values {
+		values[name] = value
+	}
+	return values
+}
+
+// Snapshot return a copy of the cached properties
+func (p *PropertyCache) Snapshot() map[string]dbus.Variant {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.snapshot()
+}
+
+// Get return a cached property, false if it is not available
+func (p *PropertyCache) Get(name string) (dbus.Variant, bool) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	value, ok := p.values[name]
+	return value, ok
+}
+
+// Load fill a properties struct with a consistent snapshot of the cache, the struct
+// is replaced at once. It is written only by the caller, call Load again to update it
+func (p *PropertyCache) Load(props interface{}) error {
+	values := p.Snapshot()
+	value := reflect.ValueOf(props)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return util.MapToStruct(props, values)
+	}
+	fresh := reflect.New(value.Elem().Type())
+	err := util.MapToStruct(fresh.Interface(), values)
+	value.Elem().Set(fresh.Elem())
+	return err
+}
+
+// Watch return a channel receiving the changes applied to the cache. The channel is
+// closed once the object interface is removed from the bus, or the cache released.
+// It is closed at once if the interface is already removed
+func (p *PropertyCache) Watch() chan *PropertyChange {
+	channel, _ := p.WatchSnapshot()
+	return channel
+}
+
+// WatchSnapshot return a channel like Watch and the cached properties, the channel
+// receives the changes applied after the snapshot
+func (p *PropertyCache) WatchSnapshot() (chan *PropertyChange, map[string]dbus.Variant) {
+	watcher := newPropertyWatcher()
+	go watcher.forward()
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.removed {
+		watcher.end()
+	} else {
+		p.watchers[watcher.channel] = watcher
+	}
+	return watcher.channel, p.snapshot()
+}
+
+// Unwatch stop the delivery of changes to a channel returned by Watch and close it
+func (p *PropertyCache) Unwatch(channel chan *PropertyChange) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if watcher, ok := p.watchers[channel]; ok {
+		close(watcher.done)
+		delete(p.watchers, channel)
+	}
+}
+
+// Release drop a reference to the cache, it stops listening for changes once unused
+func (p *PropertyCache) Release() {
+
+	cachesLock.Lock()
+	p.refs--
+	if p.refs > 0 {
+		cachesLock.Unlock()
+		return
+	}
+	if caches[p.key] == p {
+		delete(caches, p.key)
+	}
+	cachesLock.Unlock()
+
+	p.client.Disconnect()
+}
+
+// RefreshPropertyCaches reload the properties of every cache in use, eg. once
+// bluetoothd is restarted. The first error is returned after refreshing all the caches
+func RefreshPropertyCaches(ctx context.Context) error {
+
+	cachesLock.Lock()
+	list := make([]*PropertyCache, 0, len(caches))
+	for _, cache := range caches {
+		select {
+		case <-cache.ready:
+		default:
+			// still loading, the values are fresh
+			continue
+		}
+		// hold the cache while refreshing
+		cache.refs++
+		list = append(list, cache)
+	}
+	cachesLock.Unlock()
+
+	var first error
+	for _, cache := range list {
+		err := cache.Refresh(ctx)
+		if err != nil && first == nil {
+			first = err
+		}
+		cache.Release()
+	}
+	return first
+}
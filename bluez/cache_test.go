@@ -0,0 +1,225 @@
+package bluez
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus"
+)
+
+type testDeviceProperties struct {
+	Connected bool
+	RSSI      int16
+}
+
+func TestPropertyCache(t *testing.T) {
+
+	path := dbus.ObjectPath("/org/bluez/hci0/dev_B0_B4_48_C9_4B_01")
+	iface := "org.bluez.Device1"
+
+	buf := new(bytes.Buffer)
+	recorder, err := NewRecorder(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder.Record(&Operation{
+		Kind: OperationCall, Path: path, Interface: PropertiesInterface, Member: "GetAll",
+		Args: []interface{}{iface},
+		Reply: []interface{}{map[string]dbus.Variant{
+			"Connected": dbus.MakeVariant(false),
+			"RSSI":      dbus.MakeVariant(int16(-60)),
+		}},
+	}, nil)
+	recorder.Record(&Operation{
+		Kind: OperationSignal, Path: path, Interface: PropertiesInterface, Member: "PropertiesChanged",
+		Reply: []interface{}{
+			iface,
+			map[string]dbus.Variant{"Connected": dbus.MakeVariant(true)},
+			[]string{"RSSI"},
+		},
+	}, nil)
+
+	replayer, err := NewReplayer(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer replayer.Close()
+
+	config := &Config{Name: "org.bluez", Iface: iface, Path: string(path), Backend: replayer}
+	client1 := NewClient(config)
+	client2 := NewClient(config)
+
+	cache, err := client1.PropertyCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes := cache.Watch()
+
+	props := new(testDeviceProperties)
+	if err = client2.GetProperties(props); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case change := <-changes:
+		if len(change.Invalidated) != 1 || change.Invalidated[0] != "RSSI" {
+			t.Fatalf("Expected RSSI to be invalidated, got %v", change.Invalidated)
+		}
+		if _, ok := change.Snapshot["RSSI"]; ok {
+			t.Fatal("Invalidated property still in the snapshot")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Change not delivered")
+	}
+
+	// props is a snapshot, the cache never writes into it
+	if props.Connected || props.RSSI != -60 {
+		t.Fatalf("Loaded properties changed: %+v", props)
+	}
+	if err = client2.GetProperties(props); err != nil {
+		t.Fatal(err)
+	}
+	if !props.Connected || props.RSSI != 0 {
+		t.Fatalf("Properties not updated: %+v", props)
+	}
+
+	// the cache is shared, releasing a client keeps it alive for the other one
+	client1.Disconnect()
+	if value, ok := cache.Get("Connected"); !ok || value.Value() != true {
+		t.Fatalf("Unexpected cached value %v", value)
+	}
+
+	// the watchers are closed once the cache is released
+	client2.Disconnect()
+	select {
+	case _, ok := <-changes:
+		if ok {
+			t.Fatal("Unexpected change")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Watcher not closed")
+	}
+}
+
+func TestPropertyWatcherQueue(t *testing.T) {
+
+	watcher := newPropertyWatcher()
+	count := subscriberQueueSize * 2
+	for i := 0; i < count; i++ {
+		watcher.push(&PropertyChange{
+			Changed: map[string]dbus.Variant{"Value": dbus.MakeVariant([]byte{byte(i)})},
+		})
+	}
+	watcher.end()
+	go watcher.forward()
+
+	// a slow watcher still receives every value, in order
+	i := 0
+	for change := range watcher.channel {
+		if value := change.Changed["Value"].Value().([]byte); value[0] != byte(i) {
+			t.Fatalf("Expected value %d, got %v", i, value)
+		}
+		i++
+	}
+	if i != count {
+		t.Fatalf("Expected %d changes, got %d", count, i)
+	}
+}
+
+func TestPropertyCacheRefreshStale(t *testing.T) {
+
+	cache := &PropertyCache{
+		Path:      "/org/bluez/hci0/dev_B0_B4_48_C9_4B_01",
+		Interface: "org.bluez.Device1",
+		values: map[string]dbus.Variant{
+			"Connected": dbus.MakeVariant(false),
+			"RSSI":      dbus.MakeVariant(int16(-60)),
+			"Name":      dbus.MakeVariant("old"),
+		},
+		watchers: make(map[chan *PropertyChange]*propertyWatcher),
+		versions: make(map[string]uint64),
+	}
+
+	// a signal is applied while the GetAll of a refresh is pending
+	since := cache.version
+	cache.apply(map[string]dbus.Variant{"Connected": dbus.MakeVariant(true)}, []string{"Name"})
+	cache.reset(map[string]dbus.Variant{
+		"Connected": dbus.MakeVariant(false),
+		"RSSI":      dbus.MakeVariant(int16(-70)),
+		"Name":      dbus.MakeVariant("old"),
+	}, since)
+
+	if value, _ := cache.Get("Connected"); value.Value() != true {
+		t.Fatalf("Newer value overwritten by the refresh, got %v", value)
+	}
+	if _, ok := cache.Get("Name"); ok {
+		t.Fatal("Invalidated property restored by the refresh")
+	}
+	if value, _ := cache.Get("RSSI"); value.Value() != int16(-70) {
+		t.Fatalf("Property not refreshed, got %v", value)
+	}
+}
+
+func TestPropertyCacheRemoved(t *testing.T) {
+
+	path := dbus.ObjectPath("/org/bluez/hci0/dev_B0_B4_48_C9_4B_01")
+	iface := "org.bluez.Device1"
+
+	buf := new(bytes.Buffer)
+	recorder, err := NewRecorder(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder.Record(&Operation{
+		Kind: OperationCall, Path: path, Interface: PropertiesInterface, Member: "GetAll",
+		Args:  []interface{}{iface},
+		Reply: []interface{}{map[string]dbus.Variant{"Connected": dbus.MakeVariant(false)}},
+	}, nil)
+	recorder.Record(&Operation{Kind: OperationCall, Path: path, Interface: iface, Member: "Connect"}, nil)
+	recorder.Record(&Operation{
+		Kind: OperationSignal, Path: "/", Interface: ObjectManagerInterface, Member: "InterfacesRemoved",
+		Reply: []interface{}{path, []string{iface}},
+	}, nil)
+
+	replayer, err := NewReplayer(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer replayer.Close()
+
+	client := NewClient(&Config{Name: "org.bluez", Iface: iface, Path: string(path), Backend: replayer})
+	defer client.Disconnect()
+
+	changes, err := client.WatchProperties()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the removal is replayed once the call is done, the watcher is then installed
+	if err = client.Call("Connect", 0).Store(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case _, ok := <-changes:
+		if ok {
+			t.Fatal("Unexpected change")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Watcher not closed on removal")
+	}
+
+	// a watch installed after the removal does not wait forever
+	changes, err = client.WatchProperties()
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case _, ok := <-changes:
+		if ok {
+			t.Fatal("Unexpected change")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Watcher of a removed object not closed")
+	}
+}
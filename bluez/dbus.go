@@ -3,6 +3,7 @@ package bluez
 import (
 	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/godbus/dbus"
 )
@@ -17,47 +18,171 @@ const (
 	SystemBus
 )
 
-var conns = make([]*dbus.Conn, 2)
-
 // Config pass configuration to a DBUS client
 type Config struct {
 	Name  string
 	Iface string
 	Path  string
 	Bus   BusType
+	// Conn is an existing connection to use, Bus and Address are ignored when set
+	Conn *dbus.Conn
+	// Address of a bus to dial instead of Bus, eg. unix:path=/tmp/test.sock or tcp:host=10.0.0.2,port=5000
+	Address string
+	// Interceptors wrap the calls, property access and signals of the client
+	Interceptors []Interceptor
+	// Backend replace the D-Bus connection, eg. to replay a recording
+	Backend Backend
 }
 
-//GetConnection get a DBus connection
-func GetConnection(connType BusType) (*dbus.Conn, error) {
-	fmt.Sprintf("Get connection %d", connType)
-	switch connType {
+// sharedConn a connection shared by many clients
+type sharedConn struct {
+	key  string
+	conn *dbus.Conn
+	refs int
+	// owned connections are opened by the manager and closed when the last reference is released
+	owned bool
+}
+
+// connManager track the shared connections and their references
+type connManager struct {
+	lock   sync.Mutex
+	byKey  map[string]*sharedConn
+	byConn map[*dbus.Conn]*sharedConn
+}
+
+var conns = &connManager{
+	byKey:  make(map[string]*sharedConn),
+	byConn: make(map[*dbus.Conn]*sharedConn),
+}
+
+func connKey(config *Config) string {
+	if config.Conn != nil {
+		return fmt.Sprintf("conn:%p", config.Conn)
+	}
+	if config.Address != "" {
+		return "address:" + config.Address
+	}
+	switch config.Bus {
 	case SystemBus:
-		{
-			if conns[SystemBus] == nil {
-				// c.logger.Debug("Connecting to SystemBus")
-				conn, err := dbus.SystemBus()
-				if err != nil {
-					return nil, err
-				}
-				conns[SystemBus] = conn
-			}
-			return conns[SystemBus], nil
-		}
+		return "system"
 	case SessionBus:
-		{
-			if conns[SessionBus] == nil {
-				// c.logger.Debug("Connecting to SessionBus")
-				conn, err := dbus.SessionBus()
-				if err != nil {
-					return nil, err
-				}
-				conns[SessionBus] = conn
-			}
-			return conns[SessionBus], nil
-		}
+		return "session"
 	default:
-		{
-			panic(errors.New("Unmanged DBus type code"))
+		panic(errors.New("Unmanged DBus type code"))
+	}
+}
+
+// dial open a private connection, the manager owns it and can close it safely
+func dial(config *Config) (*dbus.Conn, error) {
+	var conn *dbus.Conn
+	var err error
+	if config.Address != "" {
+		conn, err = dbus.Dial(config.Address)
+	} else if config.Bus == SystemBus {
+		conn, err = dbus.SystemBusPrivate()
+	} else {
+		conn, err = dbus.SessionBusPrivate()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = conn.Auth(nil); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err = conn.Hello(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (m *connManager) acquire(config *Config) (*dbus.Conn, error) {
+
+	key := connKey(config)
+
+	m.lock.Lock()
+	if shared, ok := m.byKey[key]; ok {
+		shared.refs++
+		m.lock.Unlock()
+		return shared.conn, nil
+	}
+	m.lock.Unlock()
+
+	shared := &sharedConn{key: key, refs: 1}
+	if config.Conn != nil {
+		shared.conn = config.Conn
+	} else {
+		// a slow bus must not block the other connections
+		conn, err := dial(config)
+		if err != nil {
+			return nil, err
+		}
+		shared.conn = conn
+		shared.owned = true
+	}
+
+	m.lock.Lock()
+	if existing, ok := m.byKey[key]; ok {
+		// dialed concurrently
+		existing.refs++
+		m.lock.Unlock()
+		if shared.owned {
+			shared.conn.Close()
 		}
+		return existing.conn, nil
+	}
+	m.byKey[key] = shared
+	m.byConn[shared.conn] = shared
+	m.lock.Unlock()
+	return shared.conn, nil
+}
+
+func (m *connManager) release(conn *dbus.Conn) error {
+
+	m.lock.Lock()
+	shared, ok := m.byConn[conn]
+	if !ok {
+		m.lock.Unlock()
+		return errors.New("Connection is not managed")
 	}
+
+	shared.refs--
+	if shared.refs > 0 {
+		m.lock.Unlock()
+		return nil
+	}
+
+	delete(m.byKey, shared.key)
+	delete(m.byConn, shared.conn)
+	m.lock.Unlock()
+
+	if !shared.owned {
+		return nil
+	}
+	return shared.conn.Close()
+}
+
+//AcquireConnection return the shared DBus connection described by a Config
+// and add a reference to it. Each call must be paired with ReleaseConnection
+func AcquireConnection(config *Config) (*dbus.Conn, error) {
+	return conns.acquire(config)
+}
+
+//ReleaseConnection drop a reference to a shared connection, the connection is
+// closed once the last reference is released. Connections injected with Config.Conn
+// are never closed as they are owned by the caller
+func ReleaseConnection(conn *dbus.Conn) error {
+	return conns.release(conn)
+}
+
+//GetConnection get a shared DBus connection, release it with ReleaseConnection
+func GetConnection(connType BusType) (*dbus.Conn, error) {
+	return AcquireConnection(&Config{Bus: connType})
+}
+
+//GetConnectionByAddress get a shared DBus connection to the bus at the given address,
+// release it with ReleaseConnection
+func GetConnectionByAddress(address string) (*dbus.Conn, error) {
+	return AcquireConnection(&Config{Address: address})
 }
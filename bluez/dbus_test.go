@@ -0,0 +1,371 @@
+package bluez
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus"
+)
+
+// fakeBus accept connections like a bus daemon, answering the authentication and Hello
+type fakeBus struct {
+	address  string
+	listener net.Listener
+	// closed receives a value each time a client connection is closed
+	closed chan struct{}
+	// hold delays the authentication until it is closed, when not nil
+	hold chan struct{}
+
+	lock sync.Mutex
+	// replies delays the replies to the calls but Hello until it is closed, when not nil
+	replies chan struct{}
+}
+
+// holdReplies delay the replies to the next calls until the returned channel is closed
+func (b *fakeBus) holdReplies() chan struct{} {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.replies = make(chan struct{})
+	return b.replies
+}
+
+func newFakeBus(t *testing.T, hold chan struct{}) *fakeBus {
+	dir, err := ioutil.TempDir("", "bus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "bus.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	b := &fakeBus{
+		address:  "unix:path=" + path,
+		listener: l,
+		closed:   make(chan struct{}, 64),
+		hold:     hold,
+	}
+	go b.accept()
+	return b
+}
+
+func (b *fakeBus) Close() {
+	b.listener.Close()
+	os.RemoveAll(filepath.Dir(strings.TrimPrefix(b.address, "unix:path=")))
+}
+
+func (b *fakeBus) accept() {
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+		go b.serve(conn)
+	}
+}
+
+func (b *fakeBus) serve(conn net.Conn) {
+	defer func() {
+		conn.Close()
+		b.closed <- struct{}{}
+	}()
+	if b.hold != nil {
+		<-b.hold
+	}
+
+	in := bufio.NewReader(conn)
+	for {
+		line, err := in.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "\x00"))
+		switch {
+		case line == "AUTH":
+			io.WriteString(conn, "REJECTED EXTERNAL\r\n")
+		case strings.HasPrefix(line, "AUTH EXTERNAL"):
+			io.WriteString(conn, "OK 0123456789abcdef0123456789abcdef\r\n")
+		case line == "NEGOTIATE_UNIX_FD":
+			io.WriteString(conn, "ERROR\r\n")
+		case line == "BEGIN":
+			b.reply(in, conn)
+			return
+		}
+	}
+}
+
+// reply answer the Hello call then any other call with an empty reply, until the client is gone
+func (b *fakeBus) reply(in io.Reader, conn net.Conn) {
+	for {
+		msg, err := dbus.DecodeMessage(in)
+		if err != nil {
+			return
+		}
+		if msg.Type != dbus.TypeMethodCall || msg.Flags&dbus.FlagNoReplyExpected != 0 {
+			continue
+		}
+		reply := &dbus.Message{
+			Type: dbus.TypeMethodReply,
+			Headers: map[dbus.HeaderField]dbus.Variant{
+				dbus.FieldReplySerial: dbus.MakeVariant(msg.Serial()),
+			},
+		}
+		if member, _ := msg.Headers[dbus.FieldMember].Value().(string); member == "Hello" {
+			reply.Headers[dbus.FieldSignature] = dbus.MakeVariant(dbus.SignatureOf(""))
+			reply.Body = []interface{}{":1.1"}
+		} else {
+			b.lock.Lock()
+			replies := b.replies
+			b.lock.Unlock()
+			if replies != nil {
+				<-replies
+			}
+		}
+		if err := reply.EncodeTo(conn, binary.LittleEndian); err != nil {
+			return
+		}
+	}
+}
+
+// waitClosed check that a client connection of the bus is closed
+func (b *fakeBus) waitClosed(t *testing.T) {
+	select {
+	case <-b.closed:
+	case <-time.After(time.Second):
+		t.Fatal("Connection not closed")
+	}
+}
+
+// pipeConn return a connection injected with Config.Conn and the other end of its transport
+func pipeConn(t *testing.T) (*dbus.Conn, net.Conn) {
+	local, remote := net.Pipe()
+	conn, err := dbus.NewConn(local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn, remote
+}
+
+// isOpen check that the transport of a pipe connection is still open
+func isOpen(remote net.Conn) bool {
+	remote.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+	_, err := remote.Read(make([]byte, 1))
+	netErr, ok := err.(net.Error)
+	return ok && netErr.Timeout()
+}
+
+func TestAcquireInjectedConn(t *testing.T) {
+
+	conn, remote := pipeConn(t)
+	defer remote.Close()
+
+	client := NewClient(&Config{Name: "org.bluez", Path: "/org/bluez/hci0", Conn: conn})
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	if client.conn != conn {
+		t.Fatal("Expected the injected connection")
+	}
+	other, err := AcquireConnection(&Config{Bus: SystemBus, Conn: conn})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other != conn {
+		t.Fatal("Expected the injected connection to be shared")
+	}
+
+	ReleaseConnection(other)
+	client.Disconnect()
+	if !isOpen(remote) {
+		t.Fatal("Injected connection closed by the last release")
+	}
+	if err := ReleaseConnection(conn); err == nil {
+		t.Fatal("Expected an error releasing an unmanaged connection")
+	}
+}
+
+func TestAcquireAddress(t *testing.T) {
+
+	bus := newFakeBus(t, nil)
+	defer bus.Close()
+
+	client := NewClient(&Config{Name: "org.bluez", Path: "/org/bluez/hci0", Address: bus.address})
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	conn, err := GetConnectionByAddress(bus.address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conn != client.conn {
+		t.Fatal("Expected the connection to the address to be shared")
+	}
+
+	ReleaseConnection(conn)
+	select {
+	case <-bus.closed:
+		t.Fatal("Connection closed while still used")
+	case <-time.After(10 * time.Millisecond):
+	}
+	client.Disconnect()
+	bus.waitClosed(t)
+
+	if _, err := GetConnectionByAddress(bus.address + ".missing"); err == nil {
+		t.Fatal("Expected an error dialing a missing bus")
+	}
+}
+
+// refs return the references of a managed connection, 0 once released
+func refs(conn *dbus.Conn) int {
+	conns.lock.Lock()
+	defer conns.lock.Unlock()
+	if shared, ok := conns.byConn[conn]; ok {
+		return shared.refs
+	}
+	return 0
+}
+
+func TestConnRefs(t *testing.T) {
+
+	conn, remote := pipeConn(t)
+	defer remote.Close()
+	bus := newFakeBus(t, nil)
+	defer bus.Close()
+
+	for i := 0; i < 3; i++ {
+		if _, err := AcquireConnection(&Config{Conn: conn}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dialed, err := GetConnectionByAddress(bus.address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetConnectionByAddress(bus.address); err != nil {
+		t.Fatal(err)
+	}
+	if refs(conn) != 3 || refs(dialed) != 2 {
+		t.Fatalf("Expected 3 and 2 references, got %d and %d", refs(conn), refs(dialed))
+	}
+
+	// the injected connection is owned by the caller, the dialed one by the manager
+	for i := 0; i < 3; i++ {
+		ReleaseConnection(conn)
+	}
+	ReleaseConnection(dialed)
+	if refs(conn) != 0 || refs(dialed) != 1 {
+		t.Fatalf("Expected 0 and 1 references, got %d and %d", refs(conn), refs(dialed))
+	}
+	if !isOpen(remote) {
+		t.Fatal("Injected connection closed")
+	}
+	ReleaseConnection(dialed)
+	bus.waitClosed(t)
+}
+
+func TestConnConcurrent(t *testing.T) {
+
+	conn, remote := pipeConn(t)
+	defer remote.Close()
+	bus := newFakeBus(t, nil)
+	defer bus.Close()
+
+	var wg sync.WaitGroup
+	dialed := make(chan *dbus.Conn, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c, err := AcquireConnection(&Config{Conn: conn})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				ReleaseConnection(c)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			c, err := GetConnectionByAddress(bus.address)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			dialed <- c
+		}()
+	}
+	wg.Wait()
+	close(dialed)
+
+	var shared *dbus.Conn
+	for c := range dialed {
+		if shared == nil {
+			shared = c
+		}
+		if c != shared {
+			t.Fatal("Expected a single connection to the address")
+		}
+	}
+	if refs(conn) != 0 || refs(shared) != 10 {
+		t.Fatalf("Expected 0 and 10 references, got %d and %d", refs(conn), refs(shared))
+	}
+	// the connections dialed concurrently are closed but the shared one
+	for i := 0; i < 10; i++ {
+		ReleaseConnection(shared)
+	}
+	if refs(shared) != 0 {
+		t.Fatal("Expected the connection to be released")
+	}
+	bus.waitClosed(t)
+}
+
+func TestConnSlowDial(t *testing.T) {
+
+	hold := make(chan struct{})
+	bus := newFakeBus(t, hold)
+	defer bus.Close()
+
+	dialed := make(chan error, 1)
+	go func() {
+		conn, err := GetConnectionByAddress(bus.address)
+		if err == nil {
+			ReleaseConnection(conn)
+		}
+		dialed <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	// the other connections do not wait for the slow bus
+	conn, remote := pipeConn(t)
+	defer remote.Close()
+	acquired := make(chan error, 1)
+	go func() {
+		_, err := AcquireConnection(&Config{Conn: conn})
+		acquired <- err
+	}()
+	select {
+	case err := <-acquired:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Acquire blocked by a slow dial")
+	}
+	ReleaseConnection(conn)
+
+	close(hold)
+	if err := <-dialed; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,187 @@
+package bluez
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/godbus/dbus"
+)
+
+var (
+	// ErrFailed a generic failure reported by bluez
+	ErrFailed = errors.New("bluez: operation failed")
+	// ErrInProgress the operation is already in progress
+	ErrInProgress = errors.New("bluez: operation in progress")
+	// ErrNotReady the adapter is not ready, eg. it is powered off
+	ErrNotReady = errors.New("bluez: resource not ready")
+	// ErrNotPermitted the operation is not permitted
+	ErrNotPermitted = errors.New("bluez: operation not permitted")
+	// ErrNotAuthorized the caller is not authorized
+	ErrNotAuthorized = errors.New("bluez: operation not authorized")
+	// ErrNotSupported the operation is not supported by the adapter, device or daemon
+	ErrNotSupported = errors.New("bluez: operation not supported")
+	// ErrNotAvailable the resource is not available
+	ErrNotAvailable = errors.New("bluez: resource not available")
+	// ErrNotConnected the device is not connected
+	ErrNotConnected = errors.New("bluez: not connected")
+	// ErrAlreadyConnected the device is already connected
+	ErrAlreadyConnected = errors.New("bluez: already connected")
+	// ErrAlreadyExists the resource already exists
+	ErrAlreadyExists = errors.New("bluez: already exists")
+	// ErrDoesNotExist the resource does not exist
+	ErrDoesNotExist = errors.New("bluez: does not exist")
+	// ErrInvalidArguments the arguments are not valid
+	ErrInvalidArguments = errors.New("bluez: invalid arguments")
+	// ErrInvalidValueLength the value length is not valid for the attribute
+	ErrInvalidValueLength = errors.New("bluez: invalid value length")
+	// ErrInvalidOffset the offset is not valid for the attribute
+	ErrInvalidOffset = errors.New("bluez: invalid offset")
+	// ErrAuthenticationFailed the pairing authentication failed
+	ErrAuthenticationFailed = errors.New("bluez: authentication failed")
+	// ErrAuthenticationCanceled the pairing authentication has been canceled
+	ErrAuthenticationCanceled = errors.New("bluez: authentication canceled")
+	// ErrAuthenticationRejected the pairing authentication has been rejected
+	ErrAuthenticationRejected = errors.New("bluez: authentication rejected")
+	// ErrAuthenticationTimeout the pairing authentication timed out
+	ErrAuthenticationTimeout = errors.New("bluez: authentication timeout")
+	// ErrConnectionAttemptFailed the connection to the device failed
+	ErrConnectionAttemptFailed = errors.New("bluez: connection attempt failed")
+	// ErrRejected the request has been rejected
+	ErrRejected = errors.New("bluez: rejected")
+	// ErrCanceled the request has been canceled
+	ErrCanceled = errors.New("bluez: canceled")
+
+	// ErrUnknownMethod the method is not implemented by the remote object, eg. on older daemons
+	ErrUnknownMethod = errors.New("dbus: unknown method")
+	// ErrUnknownObject the object path does not exist
+	ErrUnknownObject = errors.New("dbus: unknown object")
+	// ErrServiceUnknown the service is not available on the bus, eg. bluetoothd is not running
+	ErrServiceUnknown = errors.New("dbus: service unknown")
+	// ErrNoReply no reply has been received in time
+	ErrNoReply = errors.New("dbus: no reply")
+)
+
+// errorNames map D-Bus error names to their sentinel error
+var errorNames = map[string]error{
+	"org.bluez.Error.Failed":                  ErrFailed,
+	"org.bluez.Error.InProgress":              ErrInProgress,
+	"org.bluez.Error.NotReady":                ErrNotReady,
+	"org.bluez.Error.NotPermitted":            ErrNotPermitted,
+	"org.bluez.Error.NotAuthorized":           ErrNotAuthorized,
+	"org.bluez.Error.NotSupported":            ErrNotSupported,
+	"org.bluez.Error.NotAvailable":            ErrNotAvailable,
+	"org.bluez.Error.NotConnected":            ErrNotConnected,
+	"org.bluez.Error.AlreadyConnected":        ErrAlreadyConnected,
+	"org.bluez.Error.AlreadyExists":           ErrAlreadyExists,
+	"org.bluez.Error.DoesNotExist":            ErrDoesNotExist,
+	"org.bluez.Error.InvalidArguments":        ErrInvalidArguments,
+	"org.bluez.Error.InvalidValueLength":      ErrInvalidValueLength,
+	"org.bluez.Error.InvalidOffset":           ErrInvalidOffset,
+	"org.bluez.Error.AuthenticationFailed":    ErrAuthenticationFailed,
+	"org.bluez.Error.AuthenticationCanceled":  ErrAuthenticationCanceled,
+	"org.bluez.Error.AuthenticationRejected":  ErrAuthenticationRejected,
+	"org.bluez.Error.AuthenticationTimeout":   ErrAuthenticationTimeout,
+	"org.bluez.Error.ConnectionAttemptFailed": ErrConnectionAttemptFailed,
+	"org.bluez.Error.Rejected":                ErrRejected,
+	"org.bluez.Error.Canceled":                ErrCanceled,
+
+	"org.freedesktop.DBus.Error.UnknownMethod":  ErrUnknownMethod,
+	"org.freedesktop.DBus.Error.UnknownObject":  ErrUnknownObject,
+	"org.freedesktop.DBus.Error.ServiceUnknown": ErrServiceUnknown,
+	"org.freedesktop.DBus.Error.NoReply":        ErrNoReply,
+}
+
+// Error is a failure returned by a D-Bus call, carrying the object and method that failed.
+// Use errors.Is with the Err* sentinels to check the kind of failure
+type Error struct {
+	// Name of the D-Bus error, eg. org.bluez.Error.InProgress
+	Name string
+	// Message is the description sent with the error, if any
+	Message string
+	// Path of the object the call was made on
+	Path dbus.ObjectPath
+	// Method is the fully qualified method name, eg. org.bluez.Device1.Connect
+	Method string
+	// Err is the matching sentinel error or the original dbus.Error for unknown names
+	Err error
+}
+
+func (e *Error) Error() string {
+	msg := e.Name
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return fmt.Sprintf("%s on %s: %s", e.Method, e.Path, msg)
+}
+
+// Unwrap return the sentinel error, if any
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// wrapError translate a D-Bus error reply to an *Error, other errors are returned unchanged
+func wrapError(err error, path dbus.ObjectPath, method string) error {
+
+	var dbusErr dbus.Error
+	switch e := err.(type) {
+	case dbus.Error:
+		dbusErr = e
+	case *dbus.Error:
+		if e == nil {
+			return err
+		}
+		dbusErr = *e
+	default:
+		return err
+	}
+
+	bluezErr := &Error{
+		Name:   dbusErr.Name,
+		Path:   path,
+		Method: method,
+		Err:    dbusErr,
+	}
+	if len(dbusErr.Body) > 0 {
+		if msg, ok := dbusErr.Body[0].(string); ok {
+			bluezErr.Message = msg
+		}
+	}
+	if sentinel, ok := errorNames[dbusErr.Name]; ok {
+		bluezErr.Err = sentinel
+	}
+	return bluezErr
+}
+
+// ToDBusError convert err to the D-Bus error returned by an exported method, eg. an agent
+// callback. Errors matching a sentinel keep its name, eg. ErrRejected is sent as
+// org.bluez.Error.Rejected, other errors are sent as org.bluez.Error.Failed
+func ToDBusError(err error) *dbus.Error {
+
+	if err == nil {
+		return nil
+	}
+
+	switch e := err.(type) {
+	case *dbus.Error:
+		return e
+	case dbus.Error:
+		return &e
+	}
+
+	var bluezErr *Error
+	if errors.As(err, &bluezErr) {
+		msg := bluezErr.Message
+		if msg == "" {
+			msg = bluezErr.Name
+		}
+		return dbus.NewError(bluezErr.Name, []interface{}{msg})
+	}
+
+	for name, sentinel := range errorNames {
+		if errors.Is(err, sentinel) {
+			return dbus.NewError(name, []interface{}{err.Error()})
+		}
+	}
+
+	return dbus.NewError("org.bluez.Error.Failed", []interface{}{err.Error()})
+}
@@ -0,0 +1,83 @@
+package bluez
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/godbus/dbus"
+)
+
+func TestWrapError(t *testing.T) {
+
+	dbusErr := dbus.Error{
+		Name: "org.bluez.Error.InProgress",
+		Body: []interface{}{"In Progress"},
+	}
+
+	err := wrapError(dbusErr, "/org/bluez/hci0/dev_B0_B4_48_C9_4B_01", "org.bluez.Device1.Connect")
+
+	if !errors.Is(err, ErrInProgress) {
+		t.Fatalf("Expected ErrInProgress, got %v", err)
+	}
+
+	var bluezErr *Error
+	if !errors.As(err, &bluezErr) {
+		t.Fatalf("Expected *Error, got %T", err)
+	}
+	if bluezErr.Method != "org.bluez.Device1.Connect" {
+		t.Fatalf("Unexpected method %s", bluezErr.Method)
+	}
+	if bluezErr.Path != "/org/bluez/hci0/dev_B0_B4_48_C9_4B_01" {
+		t.Fatalf("Unexpected path %s", bluezErr.Path)
+	}
+	if bluezErr.Message != "In Progress" {
+		t.Fatalf("Unexpected message %s", bluezErr.Message)
+	}
+}
+
+func TestWrapErrorUnknownName(t *testing.T) {
+
+	dbusErr := &dbus.Error{Name: "org.bluez.Error.SomethingNew"}
+	err := wrapError(dbusErr, "/org/bluez/hci0", "org.bluez.Adapter1.StartDiscovery")
+
+	var orig dbus.Error
+	if !errors.As(err, &orig) {
+		t.Fatalf("Expected the original dbus.Error to be wrapped, got %v", err)
+	}
+	if errors.Is(err, ErrFailed) {
+		t.Fatal("Unknown error should not match ErrFailed")
+	}
+}
+
+func TestWrapErrorPassthrough(t *testing.T) {
+	plain := errors.New("not a dbus error")
+	if wrapError(plain, "/", "m") != plain {
+		t.Fatal("Non D-Bus errors should be returned unchanged")
+	}
+	if wrapError(nil, "/", "m") != nil {
+		t.Fatal("nil should stay nil")
+	}
+}
+
+func TestToDBusError(t *testing.T) {
+
+	if ToDBusError(nil) != nil {
+		t.Fatal("nil should stay nil")
+	}
+
+	err := ToDBusError(fmt.Errorf("user declined: %w", ErrRejected))
+	if err.Name != "org.bluez.Error.Rejected" || err.Body[0] != "user declined: bluez: rejected" {
+		t.Fatalf("Unexpected error %+v", err)
+	}
+
+	if err := ToDBusError(errors.New("boom")); err.Name != "org.bluez.Error.Failed" {
+		t.Fatalf("Expected org.bluez.Error.Failed, got %s", err.Name)
+	}
+
+	// a call error is forwarded with its original name
+	wrapped := wrapError(dbus.Error{Name: "org.bluez.Error.SomethingNew"}, "/org/bluez/hci0", "org.bluez.Adapter1.StartDiscovery")
+	if err := ToDBusError(wrapped); err.Name != "org.bluez.Error.SomethingNew" {
+		t.Fatalf("Expected org.bluez.Error.SomethingNew, got %s", err.Name)
+	}
+}
@@ -0,0 +1,108 @@
+package bluez
+
+import (
+	"errors"
+
+	"github.com/godbus/dbus"
+)
+
+// ErrExportNotSupported is returned when objects are exported through a Backend
+var ErrExportNotSupported = errors.New("Objects cannot be exported through a backend")
+
+// exportKey identify an object exported by a client
+type exportKey struct {
+	path  dbus.ObjectPath
+	iface string
+}
+
+//Export expose obj at path for iface on the connection of the client. The methods of
+//obj returning a *dbus.Error as last value can be called by bluez, eg. an agent or a
+//GATT application. The object is removed on Unexport or Disconnect
+func (c *Client) Export(obj interface{}, path dbus.ObjectPath, iface string) error {
+	if getBackend(c.Config) != nil {
+		return ErrExportNotSupported
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if err := c.connect(); err != nil {
+		return err
+	}
+	err := c.conn.Export(obj, path, iface)
+	if err != nil {
+		return err
+	}
+	c.exports[exportKey{path, iface}] = true
+	return nil
+}
+
+//Unexport remove an object exported at path for iface
+func (c *Client) Unexport(path dbus.ObjectPath, iface string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.unexport(exportKey{path, iface})
+}
+
+// unexport remove an exported object, the lock must be held
+func (c *Client) unexport(key exportKey) error {
+	if !c.exports[key] {
+		return nil
+	}
+	delete(c.exports, key)
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.Export(nil, key.path, key.iface)
+}
+
+//Connection return the D-Bus connection of the client, eg. to export objects
+//with the godbus helpers
+func (c *Client) Connection() (*dbus.Conn, error) {
+	if getBackend(c.Config) != nil {
+		return nil, ErrExportNotSupported
+	}
+	conn, _, err := c.getObject()
+	return conn, err
+}
+
+//Emit send a signal from an exported object, eg. PropertiesChanged
+func (c *Client) Emit(path dbus.ObjectPath, name string, values ...interface{}) error {
+	conn, err := c.Connection()
+	if err != nil {
+		return err
+	}
+	return conn.Emit(path, name, values...)
+}
+
+// ExportedProperties implement org.freedesktop.DBus.Properties for an exported object,
+// the values are read-only and computed on each request
+type ExportedProperties struct {
+	// Interface of the object
+	Interface string
+	// Values return the current properties
+	Values func() map[string]dbus.Variant
+}
+
+//Get return a property
+func (p *ExportedProperties) Get(iface, name string) (dbus.Variant, *dbus.Error) {
+	if iface != p.Interface {
+		return dbus.Variant{}, dbus.NewError("org.freedesktop.DBus.Error.UnknownInterface", []interface{}{iface})
+	}
+	value, ok := p.Values()[name]
+	if !ok {
+		return dbus.Variant{}, dbus.NewError("org.freedesktop.DBus.Error.UnknownProperty", []interface{}{name})
+	}
+	return value, nil
+}
+
+//GetAll return all the properties
+func (p *ExportedProperties) GetAll(iface string) (map[string]dbus.Variant, *dbus.Error) {
+	if iface != p.Interface {
+		return nil, dbus.NewError("org.freedesktop.DBus.Error.UnknownInterface", []interface{}{iface})
+	}
+	return p.Values(), nil
+}
+
+//Set refuse the changes, the values are changed by the application
+func (p *ExportedProperties) Set(iface, name string, value dbus.Variant) *dbus.Error {
+	return dbus.NewError("org.freedesktop.DBus.Error.PropertyReadOnly", []interface{}{name})
+}
@@ -0,0 +1,185 @@
+package gen
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func parseFile(t *testing.T, path string) []Interface {
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	list, err := ParseAPI(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("Expected 1 interface, got %d", len(list))
+	}
+	return list
+}
+
+func TestParseAPI(t *testing.T) {
+
+	iface := parseFile(t, "testdata/adapter-api.txt")[0]
+
+	if iface.Name != "org.bluez.Adapter1" || iface.Service != "org.bluez" {
+		t.Fatalf("Unexpected interface %s on %s", iface.Name, iface.Service)
+	}
+
+	// the filter keys documented in SetDiscoveryFilter are not properties
+	if len(iface.Methods) != 5 || len(iface.Properties) != 6 {
+		t.Fatalf("Expected 5 methods and 6 properties, got %d and %d", len(iface.Methods), len(iface.Properties))
+	}
+
+	method := iface.Methods[1]
+	if method.Name != "RemoveDevice" || len(method.In) != 1 || method.In[0].Type != "o" || method.In[0].Name != "device" {
+		t.Fatalf("Unexpected method %+v", method)
+	}
+	if !strings.HasPrefix(iface.Methods[0].Doc, "This method starts the device discovery session.") {
+		t.Fatalf("Unexpected doc %q", iface.Methods[0].Doc)
+	}
+	if out := iface.Methods[3].Out; len(out) != 1 || out[0].Type != "as" {
+		t.Fatalf("Unexpected GetDiscoveryFilters result %+v", out)
+	}
+
+	timeout := iface.Properties[3]
+	if timeout.Name != "DiscoverableTimeout" || timeout.Type != "u" || !timeout.Writable() {
+		t.Fatalf("Unexpected property %+v", timeout)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+
+	iface := parseFile(t, "testdata/gatt-api.txt")[0]
+
+	src, err := Generate(iface, Options{Package: "generated"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := string(src)
+
+	for _, expected := range []string{
+		"func NewGattCharacteristic1(path string, opts ...func(*bluez.Config)) *GattCharacteristic1",
+		"func (g *GattCharacteristic1) ReadValueContext(ctx context.Context, options map[string]dbus.Variant) ([]byte, error)",
+		"func (g *GattCharacteristic1) WriteValue(value []byte, options map[string]dbus.Variant) error",
+		"func (g *GattCharacteristic1) AcquireWrite(options map[string]dbus.Variant) (dbus.UnixFD, uint16, error)",
+		"func (g *GattCharacteristic1) GetMTU() (uint16, error)",
+		"\tService dbus.ObjectPath\n",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Fatalf("Missing %q in\n%s", expected, code)
+		}
+	}
+	if strings.Contains(code, "SetUUID") {
+		t.Fatal("Setter generated for a read-only property")
+	}
+}
+
+func TestParseIntrospection(t *testing.T) {
+
+	xml := `<node name="/org/bluez/hci0/dev_AA">
+	<interface name="org.freedesktop.DBus.Properties"/>
+	<interface name="org.bluez.Device1">
+		<method name="Connect"/>
+		<method name="ConnectProfile"><arg name="UUID" type="s" direction="in"/></method>
+		<property name="Connected" type="b" access="read"/>
+		<property name="Trusted" type="b" access="readwrite"/>
+	</interface>
+	<interface name="org.bluez.Test1">
+		<method name="GetTrusted"><arg type="b" direction="out"/></method>
+		<property name="Trusted" type="b" access="readwrite"/>
+		<signal name="Changed"><arg name="value" type="a{sv}"/></signal>
+	</interface>
+</node>`
+
+	list, err := ParseIntrospection(strings.NewReader(xml), "org.bluez")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("Expected 2 interfaces, got %d", len(list))
+	}
+	if list[0].Methods[1].In[0].Name != "UUID" || !list[0].Properties[1].Writable() {
+		t.Fatalf("Unexpected interface %+v", list[0])
+	}
+
+	src, err := Generate(list[1], Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := string(src)
+	// the getter does not clash with the GetTrusted method
+	for _, expected := range []string{
+		"func (t *Test1) GetTrusted() (bool, error)",
+		"func (t *Test1) GetTrustedProperty() (bool, error)",
+		"func (t *Test1) WatchChanged() (chan *dbus.Signal, error)",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Fatalf("Missing %q in\n%s", expected, code)
+		}
+	}
+}
+
+func TestPropertyOverride(t *testing.T) {
+
+	iface := parseFile(t, "testdata/device-api.txt")[0]
+
+	types := make(map[string]string)
+	for _, prop := range iface.Properties {
+		types[prop.Name] = prop.Type
+	}
+	// ManufacturerData and AdvertisingData are not keyed by strings
+	if types["ManufacturerData"] != "a{qv}" || types["AdvertisingData"] != "a{yv}" || types["ServiceData"] != "a{sv}" {
+		t.Fatalf("Unexpected property types %v", types)
+	}
+
+	src, err := Generate(iface, Options{Package: "generated"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(src), "\tManufacturerData map[uint16]dbus.Variant\n") {
+		t.Fatalf("Unexpected ManufacturerData type in\n%s", src)
+	}
+}
+
+func TestGenerateBuild(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip("building the generated package")
+	}
+	gotool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not available")
+	}
+
+	// the package is built in the tree, so it imports the bluez package under test
+	dir, err := ioutil.TempDir(".", "generated")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"adapter-api.txt", "device-api.txt", "gatt-api.txt"} {
+		iface := parseFile(t, filepath.Join("testdata", name))[0]
+		src, err := Generate(iface, Options{Package: "generated"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ioutil.WriteFile(filepath.Join(dir, strings.ToLower(iface.TypeName())+".go"), src, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out, err := exec.Command(gotool, "build", "./"+filepath.Base(dir)).CombinedOutput()
+	if err != nil {
+		t.Fatalf("Generated package does not build: %s\n%s", err, out)
+	}
+}
@@ -0,0 +1,375 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+	"text/template"
+)
+
+// Options customize the generated code
+type Options struct {
+	// Package is the name of the generated package
+	Package string
+}
+
+// paramView a Go parameter or result
+type paramView struct {
+	Name string
+	Type string
+}
+
+// methodView a generated method wrapper
+type methodView struct {
+	Name   string
+	Member string
+	In     []paramView
+	Out    []paramView
+}
+
+// Params return the parameter list, eg. "device dbus.ObjectPath, options map[string]dbus.Variant"
+func (m methodView) Params() string {
+	list := make([]string, len(m.In))
+	for i, p := range m.In {
+		list[i] = p.Name + " " + p.Type
+	}
+	return strings.Join(list, ", ")
+}
+
+// Args return the argument names, prefixed with a comma when not empty
+func (m methodView) Args() string {
+	if len(m.In) == 0 {
+		return ""
+	}
+	list := make([]string, len(m.In))
+	for i, p := range m.In {
+		list[i] = p.Name
+	}
+	return ", " + strings.Join(list, ", ")
+}
+
+// Results return the result list, eg. "(dbus.UnixFD, uint16, error)"
+func (m methodView) Results() string {
+	if len(m.Out) == 0 {
+		return "error"
+	}
+	list := make([]string, 0, len(m.Out)+1)
+	for _, p := range m.Out {
+		list = append(list, p.Type)
+	}
+	return "(" + strings.Join(append(list, "error"), ", ") + ")"
+}
+
+// Refs return the pointers to the results, eg. "&out0, &out1"
+func (m methodView) Refs() string {
+	list := make([]string, len(m.Out))
+	for i, p := range m.Out {
+		list[i] = "&" + p.Name
+	}
+	return strings.Join(list, ", ")
+}
+
+// Returns return the results names followed by err
+func (m methodView) Returns() string {
+	list := make([]string, 0, len(m.Out)+1)
+	for _, p := range m.Out {
+		list = append(list, p.Name)
+	}
+	return strings.Join(append(list, "err"), ", ")
+}
+
+// propertyView a generated property accessor
+type propertyView struct {
+	Name   string
+	Type   string
+	Getter string
+	Setter string
+}
+
+// signalView a generated signal watcher
+type signalView struct {
+	Name    string
+	Watcher string
+}
+
+// interfaceView the data of the template
+type interfaceView struct {
+	Package    string
+	Interface  string
+	Type       string
+	Receiver   string
+	Service    string
+	ObjectPath string
+	Bus        string
+	Methods    []methodView
+	Properties []propertyView
+	Signals    []signalView
+}
+
+// newInterfaceView prepare the template data, naming the generated methods without conflicts
+func newInterfaceView(iface Interface, opts Options) interfaceView {
+
+	view := interfaceView{
+		Package:    opts.Package,
+		Interface:  iface.Name,
+		Type:       iface.TypeName(),
+		Service:    iface.Service,
+		ObjectPath: iface.ObjectPath,
+		Bus:        "SystemBus",
+	}
+	view.Receiver = strings.ToLower(view.Type[:1])
+
+	// services are declared as eg. "unique name" for the objects exported by applications
+	if view.Service == "" || strings.ContainsAny(view.Service, " \t") {
+		view.Service = "org.bluez"
+	}
+	if strings.HasPrefix(view.Service, "org.bluez.obex") {
+		view.Bus = "SessionBus"
+	}
+
+	used := map[string]bool{
+		"Close": true, "GetProperties": true, "GetPropertiesContext": true,
+		"WatchProperties": true, "UnwatchProperties": true, "UnwatchSignal": true,
+	}
+	name := func(base string) string {
+		for _, candidate := range []string{base, base + "Property", base + "Signal"} {
+			if !used[candidate] && !used[candidate+"Context"] {
+				used[candidate] = true
+				used[candidate+"Context"] = true
+				return candidate
+			}
+		}
+		for i := 2; ; i++ {
+			candidate := fmt.Sprintf("%s%d", base, i)
+			if !used[candidate] {
+				used[candidate] = true
+				used[candidate+"Context"] = true
+				return candidate
+			}
+		}
+	}
+
+	for _, m := range iface.Methods {
+		method := methodView{
+			Name:   name(exported(m.Name)),
+			Member: m.Name,
+		}
+		params := map[string]bool{view.Receiver: true}
+		for i, arg := range m.In {
+			param := argName(arg.Name, i)
+			if params[param] {
+				param = fmt.Sprintf("%s%d", param, i)
+			}
+			params[param] = true
+			method.In = append(method.In, paramView{Name: param, Type: goType(arg.Type)})
+		}
+		for i, arg := range m.Out {
+			method.Out = append(method.Out, paramView{Name: fmt.Sprintf("out%d", i), Type: goType(arg.Type)})
+		}
+		view.Methods = append(view.Methods, method)
+	}
+
+	for _, p := range iface.Properties {
+		property := propertyView{
+			Name: exported(p.Name),
+			Type: goType(p.Type),
+		}
+		if p.Readable() {
+			property.Getter = name("Get" + property.Name)
+		}
+		if p.Writable() {
+			property.Setter = name("Set" + property.Name)
+		}
+		view.Properties = append(view.Properties, property)
+	}
+
+	for _, s := range iface.Signals {
+		view.Signals = append(view.Signals, signalView{
+			Name:    s.Name,
+			Watcher: name("Watch" + exported(s.Name)),
+		})
+	}
+
+	return view
+}
+
+var clientTemplate = template.Must(template.New("client").Parse(`// Code generated by bluez-gen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"context"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/bluez"
+)
+
+// {{.Type}}Interface the {{.Interface}} interface name
+const {{.Type}}Interface = "{{.Interface}}"
+
+// New{{.Type}} create a new {{.Type}} client{{if .ObjectPath}}, the object path is {{.ObjectPath}}{{end}}
+func New{{.Type}}(path string, opts ...func(*bluez.Config)) *{{.Type}} {
+	{{.Receiver}} := new({{.Type}})
+	config := &bluez.Config{
+		Name:  "{{.Service}}",
+		Iface: {{.Type}}Interface,
+		Path:  path,
+		Bus:   bluez.{{.Bus}},
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+	{{.Receiver}}.client = bluez.NewClient(config)
+	{{.Receiver}}.Properties = new({{.Type}}Properties)
+{{- if .Properties}}
+	{{.Receiver}}.GetProperties()
+{{- end}}
+	return {{.Receiver}}
+}
+
+// {{.Type}} client for {{.Interface}}
+type {{.Type}} struct {
+	client     *bluez.Client
+	Properties *{{.Type}}Properties
+}
+
+// {{.Type}}Properties exposed properties for {{.Type}}
+type {{.Type}}Properties struct {
+{{- range .Properties}}
+	{{.Name}} {{.Type}}
+{{- end}}
+}
+
+// Close the connection
+func ({{.Receiver}} *{{.Type}}) Close() {
+	{{.Receiver}}.client.Disconnect()
+}
+
+//GetProperties load all available properties
+func ({{.Receiver}} *{{.Type}}) GetProperties() (*{{.Type}}Properties, error) {
+	return {{.Receiver}}.GetPropertiesContext(context.Background())
+}
+
+//GetPropertiesContext load all available properties, aborting when ctx is done
+func ({{.Receiver}} *{{.Type}}) GetPropertiesContext(ctx context.Context) (*{{.Type}}Properties, error) {
+	err := {{.Receiver}}.client.GetPropertiesContext(ctx, {{.Receiver}}.Properties)
+	return {{.Receiver}}.Properties, err
+}
+
+//WatchProperties return a channel receiving the property changes, GetProperties updates Properties
+func ({{.Receiver}} *{{.Type}}) WatchProperties() (chan *bluez.PropertyChange, error) {
+	return {{.Receiver}}.client.WatchProperties()
+}
+
+//UnwatchProperties stop the delivery of property changes to channel and close it
+func ({{.Receiver}} *{{.Type}}) UnwatchProperties(channel chan *bluez.PropertyChange) {
+	{{.Receiver}}.client.UnwatchProperties(channel)
+}
+{{- $r := .Receiver}}{{$t := .Type}}
+{{- range .Methods}}
+
+// {{.Name}} call {{$.Interface}}.{{.Member}}
+func ({{$r}} *{{$t}}) {{.Name}}({{.Params}}) {{.Results}} {
+	return {{$r}}.{{.Name}}Context(context.Background(){{.Args}})
+}
+
+// {{.Name}}Context call {{$.Interface}}.{{.Member}}, aborting when ctx is done
+func ({{$r}} *{{$t}}) {{.Name}}Context(ctx context.Context{{if .In}}, {{.Params}}{{end}}) {{.Results}} {
+{{- if .Out}}
+{{- range .Out}}
+	var {{.Name}} {{.Type}}
+{{- end}}
+	err := {{$r}}.client.CallContext(ctx, "{{.Member}}", 0{{.Args}}).Store({{.Refs}})
+	return {{.Returns}}
+{{- else}}
+	return {{$r}}.client.CallContext(ctx, "{{.Member}}", 0{{.Args}}).Store()
+{{- end}}
+}
+{{- end}}
+{{- range .Properties}}
+{{- if .Getter}}
+
+// {{.Getter}} return the {{.Name}} property
+func ({{$r}} *{{$t}}) {{.Getter}}() ({{.Type}}, error) {
+	return {{$r}}.{{.Getter}}Context(context.Background())
+}
+
+// {{.Getter}}Context return the {{.Name}} property, aborting when ctx is done
+func ({{$r}} *{{$t}}) {{.Getter}}Context(ctx context.Context) ({{.Type}}, error) {
+{{- if eq .Type "dbus.Variant"}}
+	return {{$r}}.client.GetPropertyContext(ctx, "{{.Name}}")
+{{- else}}
+	var value {{.Type}}
+	v, err := {{$r}}.client.GetPropertyContext(ctx, "{{.Name}}")
+	if err != nil {
+		return value, err
+	}
+	err = dbus.Store([]interface{}{v.Value()}, &value)
+	return value, err
+{{- end}}
+}
+{{- end}}
+{{- if .Setter}}
+
+// {{.Setter}} set the {{.Name}} property
+func ({{$r}} *{{$t}}) {{.Setter}}(value {{.Type}}) error {
+	return {{$r}}.{{.Setter}}Context(context.Background(), value)
+}
+
+// {{.Setter}}Context set the {{.Name}} property, aborting when ctx is done
+func ({{$r}} *{{$t}}) {{.Setter}}Context(ctx context.Context, value {{.Type}}) error {
+	return {{$r}}.client.SetPropertyContext(ctx, "{{.Name}}", value)
+}
+{{- end}}
+{{- end}}
+{{- range .Signals}}
+
+// {{.Watcher}} return a channel receiving the {{.Name}} signals, stop it with UnwatchSignal
+func ({{$r}} *{{$t}}) {{.Watcher}}() (chan *dbus.Signal, error) {
+	return {{$r}}.client.Subscribe(bluez.SignalMatch{
+		Path:      dbus.ObjectPath({{$r}}.client.Config.Path),
+		Interface: {{$t}}Interface,
+		Member:    "{{.Name}}",
+	})
+}
+{{- end}}
+{{- if .Signals}}
+
+//UnwatchSignal stop the delivery of signals to a channel returned by a Watch method and close it
+func ({{$r}} *{{$t}}) UnwatchSignal(channel chan *dbus.Signal) error {
+	return {{$r}}.client.Unsubscribe(channel)
+}
+{{- end}}
+`))
+
+// Generate return the source of a typed client for iface
+func Generate(iface Interface, opts Options) ([]byte, error) {
+
+	if opts.Package == "" {
+		opts.Package = "generated"
+	}
+
+	buf := new(bytes.Buffer)
+	err := clientTemplate.Execute(buf, newInterfaceView(iface, opts))
+	if err != nil {
+		return nil, err
+	}
+
+	src := buf.Bytes()
+	if !bytes.Contains(src[bytes.Index(src, []byte(")\n")):], []byte("dbus.")) {
+		// no D-Bus type is used
+		src = bytes.Replace(src, []byte("\t\"github.com/godbus/dbus\"\n"), nil, 1)
+	}
+
+	formatted, err := format.Source(src)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid code generated for %s: %s", iface.Name, err.Error())
+	}
+	return formatted, nil
+}
+
+// FileName return the name of the file generated for iface, eg. Adapter1.go
+func FileName(iface Interface) string {
+	return iface.TypeName() + ".go"
+}
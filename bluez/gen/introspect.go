@@ -0,0 +1,67 @@
+package gen
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"github.com/godbus/dbus/introspect"
+)
+
+// ParseIntrospection parse the D-Bus introspection XML of an object, eg. the output of
+// `busctl introspect --xml-interface org.bluez /org/bluez/hci0`. The standard
+// org.freedesktop.DBus interfaces are skipped
+func ParseIntrospection(r io.Reader, service string) ([]Interface, error) {
+
+	var node introspect.Node
+	err := xml.NewDecoder(r).Decode(&node)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []Interface
+	for _, src := range node.Interfaces {
+
+		if strings.HasPrefix(src.Name, "org.freedesktop.DBus.") {
+			continue
+		}
+
+		iface := Interface{
+			Name:       src.Name,
+			Service:    service,
+			ObjectPath: node.Name,
+		}
+
+		for _, m := range src.Methods {
+			method := Method{Name: m.Name}
+			for _, arg := range m.Args {
+				if arg.Direction == "out" {
+					method.Out = append(method.Out, Arg{Name: arg.Name, Type: arg.Type})
+				} else {
+					method.In = append(method.In, Arg{Name: arg.Name, Type: arg.Type})
+				}
+			}
+			iface.Methods = append(iface.Methods, method)
+		}
+
+		for _, p := range src.Properties {
+			iface.Properties = append(iface.Properties, Property{
+				Name:   p.Name,
+				Type:   p.Type,
+				Access: p.Access,
+			})
+		}
+
+		for _, s := range src.Signals {
+			signal := Signal{Name: s.Name}
+			for _, arg := range s.Args {
+				signal.Args = append(signal.Args, Arg{Name: arg.Name, Type: arg.Type})
+			}
+			iface.Signals = append(iface.Signals, signal)
+		}
+
+		list = append(list, iface)
+	}
+
+	return list, nil
+}
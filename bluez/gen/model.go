@@ -0,0 +1,233 @@
+// Package gen generate typed clients of the BlueZ D-Bus interfaces, from the BlueZ
+// API descriptions (doc/*-api.txt) or from D-Bus introspection XML
+package gen
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Arg a method or signal argument
+type Arg struct {
+	Name string
+	// Type is the D-Bus signature of the argument
+	Type string
+}
+
+// Method a method of an interface
+type Method struct {
+	Name  string
+	In    []Arg
+	Out   []Arg
+	Flags []string
+	Doc   string
+}
+
+// Property a property of an interface
+type Property struct {
+	Name string
+	Type string
+	// Access is read, write or readwrite
+	Access string
+	Flags  []string
+	Doc    string
+}
+
+// Readable check if the property can be read
+func (p Property) Readable() bool {
+	return p.Access != "write"
+}
+
+// Writable check if the property can be written
+func (p Property) Writable() bool {
+	return p.Access == "write" || p.Access == "readwrite"
+}
+
+// Signal a signal of an interface
+type Signal struct {
+	Name string
+	Args []Arg
+	Doc  string
+}
+
+// Interface describe a D-Bus interface
+type Interface struct {
+	Name       string
+	Service    string
+	ObjectPath string
+	Methods    []Method
+	Properties []Property
+	Signals    []Signal
+}
+
+// TypeName return the Go type name of the interface, eg. Adapter1 for org.bluez.Adapter1
+func (i Interface) TypeName() string {
+	return exported(i.Name[strings.LastIndex(i.Name, ".")+1:])
+}
+
+// apiTypes map the type names of the BlueZ API descriptions to D-Bus signatures
+var apiTypes = map[string]string{
+	"boolean": "b",
+	"bool":    "b",
+	"byte":    "y",
+	"uint8":   "y",
+	"int16":   "n",
+	"uint16":  "q",
+	"int32":   "i",
+	"uint32":  "u",
+	"int64":   "x",
+	"uint64":  "t",
+	"double":  "d",
+	"string":  "s",
+	"object":  "o",
+	"fd":      "h",
+	"dict":    "a{sv}",
+	"variant": "v",
+}
+
+// apiSignature convert a type of the BlueZ API descriptions, eg. array{string}, to a D-Bus signature
+func apiSignature(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if sig, ok := apiTypes[name]; ok {
+		return sig, nil
+	}
+	if strings.HasPrefix(name, "array{") && strings.HasSuffix(name, "}") {
+		elem, err := apiSignature(name[len("array{") : len(name)-1])
+		if err != nil {
+			return "", err
+		}
+		return "a" + elem, nil
+	}
+	return "", fmt.Errorf("Unknown type %s", name)
+}
+
+// propertyTypes the signatures of the dict properties not keyed by strings, by interface
+// and property. The API descriptions document them as dict, ie. a{sv}
+var propertyTypes = map[string]map[string]string{
+	"org.bluez.Device1": {
+		"ManufacturerData": "a{qv}",
+		"AdvertisingData":  "a{yv}",
+	},
+	"org.bluez.LEAdvertisement1": {
+		"ManufacturerData": "a{qv}",
+		"Data":             "a{yv}",
+	},
+}
+
+// propertySignature return the signature of a property, sig unless overridden by propertyTypes
+func propertySignature(iface string, name string, sig string) string {
+	if override, ok := propertyTypes[iface][name]; ok {
+		return override
+	}
+	return sig
+}
+
+// goType return the Go type of a D-Bus signature, as decoded by godbus
+func goType(sig string) string {
+	t, rest := splitType(sig)
+	if rest != "" {
+		// multiple complete types
+		return "[]interface{}"
+	}
+	return t
+}
+
+// splitType return the Go type of the first complete type in sig and the remaining signature
+func splitType(sig string) (string, string) {
+	if sig == "" {
+		return "interface{}", ""
+	}
+	switch sig[0] {
+	case 'b':
+		return "bool", sig[1:]
+	case 'y':
+		return "byte", sig[1:]
+	case 'n':
+		return "int16", sig[1:]
+	case 'q':
+		return "uint16", sig[1:]
+	case 'i':
+		return "int32", sig[1:]
+	case 'u':
+		return "uint32", sig[1:]
+	case 'x':
+		return "int64", sig[1:]
+	case 't':
+		return "uint64", sig[1:]
+	case 'd':
+		return "float64", sig[1:]
+	case 's':
+		return "string", sig[1:]
+	case 'o':
+		return "dbus.ObjectPath", sig[1:]
+	case 'g':
+		return "dbus.Signature", sig[1:]
+	case 'h':
+		return "dbus.UnixFD", sig[1:]
+	case 'v':
+		return "dbus.Variant", sig[1:]
+	case 'a':
+		if len(sig) > 1 && sig[1] == '{' {
+			key, rest := splitType(sig[2:])
+			value, rest := splitType(rest)
+			return "map[" + key + "]" + value, strings.TrimPrefix(rest, "}")
+		}
+		elem, rest := splitType(sig[1:])
+		return "[]" + elem, rest
+	case '(':
+		depth := 0
+		for i, c := range sig {
+			if c == '(' {
+				depth++
+			} else if c == ')' {
+				depth--
+				if depth == 0 {
+					return "[]interface{}", sig[i+1:]
+				}
+			}
+		}
+	}
+	return "interface{}", ""
+}
+
+// goKeywords the identifiers which cannot be used as argument names
+var goKeywords = map[string]bool{
+	"break": true, "case": true, "chan": true, "const": true, "continue": true,
+	"default": true, "defer": true, "else": true, "fallthrough": true, "for": true,
+	"func": true, "go": true, "goto": true, "if": true, "import": true,
+	"interface": true, "map": true, "package": true, "range": true, "return": true,
+	"select": true, "struct": true, "switch": true, "type": true, "var": true,
+	// names used by the generated code
+	"ctx": true, "err": true, "context": true, "dbus": true, "bluez": true,
+}
+
+// exported return name with the first letter in upper case
+func exported(name string) string {
+	if name == "" {
+		return name
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// argName return a valid Go identifier for an argument
+func argName(name string, index int) string {
+	if name == "" {
+		return fmt.Sprintf("arg%d", index)
+	}
+	name = strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' || r == '.' {
+			return '_'
+		}
+		return r
+	}, name)
+	if strings.ToUpper(name) == name {
+		// eg. UUID
+		name = strings.ToLower(name)
+	} else {
+		name = strings.ToLower(name[:1]) + name[1:]
+	}
+	if goKeywords[name] {
+		return name + "Arg"
+	}
+	return name
+}
@@ -0,0 +1,292 @@
+package gen
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var (
+	// eg. "fd, uint16 AcquireWrite(dict options) [optional]"
+	methodLine = regexp.MustCompile(`^([\w{}, ]+?)\s+(\w+)\s*\((.*)\)\s*(\[([^\]]*)\])?`)
+	// eg. "array{string} UUIDs [readonly, optional]"
+	propertyLine = regexp.MustCompile(`^([\w{}]+)\s+(\w+)\s*(\[([^\]]*)\])?`)
+)
+
+// section the part of an interface description being parsed
+type section int
+
+const (
+	sectionNone section = iota
+	sectionMethods
+	sectionProperties
+	sectionSignals
+)
+
+// apiParser parse a BlueZ API description
+type apiParser struct {
+	list    []*Interface
+	current *Interface
+	// service and path declared for the next interfaces
+	service string
+	path    string
+	section section
+	// column where the entries of the section start, deeper lines are documentation
+	column int
+	doc    *string
+}
+
+// ParseAPI parse a BlueZ API description, eg. doc/adapter-api.txt. Entries with
+// types that cannot be represented are skipped
+func ParseAPI(r io.Reader) ([]Interface, error) {
+
+	p := new(apiParser)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		p.line(scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	list := make([]Interface, 0, len(p.list))
+	for _, iface := range p.list {
+		list = append(list, *iface)
+	}
+	return list, nil
+}
+
+// expandTabs skip the leading blanks of line, starting at column, and return the
+// text and the column where it starts
+func expandTabs(line string, column int) (string, int) {
+	for i, c := range line {
+		switch c {
+		case '\t':
+			column += 8 - column%8
+		case ' ':
+			column++
+		default:
+			return line[i:], column
+		}
+	}
+	return "", column
+}
+
+func (p *apiParser) line(raw string) {
+
+	text, column := expandTabs(raw, 0)
+	text = strings.TrimRight(text, " \t")
+	if text == "" {
+		if p.doc != nil && *p.doc != "" && !strings.HasSuffix(*p.doc, "\n") {
+			*p.doc += "\n"
+		}
+		return
+	}
+
+	if column == 0 {
+		p.header(text)
+		return
+	}
+
+	if p.section == sectionNone || p.current == nil {
+		return
+	}
+
+	if p.column > 0 && column > p.column {
+		if p.doc != nil {
+			if *p.doc != "" && !strings.HasSuffix(*p.doc, "\n") {
+				*p.doc += " "
+			}
+			*p.doc += text
+		}
+		return
+	}
+
+	if p.column == 0 {
+		// the first entry of the section is on its own line
+		p.column = column
+	}
+	p.entry(text)
+}
+
+// headerLabels the labels of the lines without indentation
+var headerLabels = []string{"Service", "Interface", "Object path", "Methods", "Properties", "Signals"}
+
+// header handle a line without indentation
+func (p *apiParser) header(text string) {
+
+	label := ""
+	for _, name := range headerLabels {
+		if text == name || strings.HasPrefix(text, name+"\t") || strings.HasPrefix(text, name+" ") {
+			label = name
+			break
+		}
+	}
+
+	p.doc = nil
+	value := strings.TrimSpace(text[len(label):])
+
+	switch label {
+	case "Service":
+		// a new hierarchy
+		p.service = value
+		p.path = ""
+		p.section = sectionNone
+	case "Interface":
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			return
+		}
+		p.current = &Interface{
+			Name:       fields[0],
+			Service:    p.service,
+			ObjectPath: p.path,
+		}
+		p.list = append(p.list, p.current)
+		p.section = sectionNone
+	case "Object path":
+		p.path = value
+		if p.current != nil && p.current.ObjectPath == "" {
+			p.current.ObjectPath = value
+		}
+	case "Methods", "Properties", "Signals":
+		switch label {
+		case "Methods":
+			p.section = sectionMethods
+		case "Properties":
+			p.section = sectionProperties
+		default:
+			p.section = sectionSignals
+		}
+		if p.current == nil {
+			return
+		}
+		entry, column := expandTabs(text[len(label):], len(label))
+		p.column = column
+		if entry == "" {
+			p.column = 0
+			return
+		}
+		p.entry(strings.TrimRight(entry, " \t"))
+	default:
+		p.section = sectionNone
+	}
+}
+
+// entry parse a method, property or signal declaration
+func (p *apiParser) entry(text string) {
+
+	p.doc = nil
+
+	switch p.section {
+	case sectionMethods, sectionSignals:
+		match := methodLine.FindStringSubmatch(text)
+		if match == nil {
+			return
+		}
+		out, err := parseTypes(match[1])
+		if err != nil {
+			return
+		}
+		in, err := parseArgs(match[3])
+		if err != nil {
+			return
+		}
+		flags := parseFlags(match[5])
+		if p.section == sectionSignals {
+			p.current.Signals = append(p.current.Signals, Signal{Name: match[2], Args: in})
+			p.doc = &p.current.Signals[len(p.current.Signals)-1].Doc
+			return
+		}
+		p.current.Methods = append(p.current.Methods, Method{
+			Name:  match[2],
+			In:    in,
+			Out:   out,
+			Flags: flags,
+		})
+		p.doc = &p.current.Methods[len(p.current.Methods)-1].Doc
+
+	case sectionProperties:
+		match := propertyLine.FindStringSubmatch(text)
+		if match == nil {
+			return
+		}
+		sig, err := apiSignature(match[1])
+		if err != nil {
+			return
+		}
+		flags := parseFlags(match[4])
+		access := "read"
+		for _, flag := range flags {
+			switch flag {
+			case "readwrite", "read-write":
+				access = "readwrite"
+			case "writeonly", "write-only":
+				access = "write"
+			}
+		}
+		p.current.Properties = append(p.current.Properties, Property{
+			Name:   match[2],
+			Type:   propertySignature(p.current.Name, match[2], sig),
+			Access: access,
+			Flags:  flags,
+		})
+		p.doc = &p.current.Properties[len(p.current.Properties)-1].Doc
+	}
+}
+
+// parseTypes parse the return types of a method, eg. "fd, uint16"
+func parseTypes(text string) ([]Arg, error) {
+	var list []Arg
+	for i, name := range strings.Split(text, ",") {
+		name = strings.TrimSpace(name)
+		if name == "void" {
+			continue
+		}
+		sig, err := apiSignature(name)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, Arg{Name: fmt.Sprintf("r%d", i), Type: sig})
+	}
+	return list, nil
+}
+
+// parseArgs parse the arguments of a method, eg. "object device, dict options"
+func parseArgs(text string) ([]Arg, error) {
+	var list []Arg
+	text = strings.TrimSpace(text)
+	if text == "" || text == "void" {
+		return list, nil
+	}
+	for _, arg := range strings.Split(text, ",") {
+		fields := strings.Fields(arg)
+		if len(fields) == 0 {
+			continue
+		}
+		sig, err := apiSignature(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		name := ""
+		if len(fields) > 1 {
+			name = fields[1]
+		}
+		list = append(list, Arg{Name: name, Type: sig})
+	}
+	return list, nil
+}
+
+// parseFlags split the flags of an entry, eg. "readonly, optional"
+func parseFlags(text string) []string {
+	var flags []string
+	for _, flag := range strings.Split(text, ",") {
+		flag = strings.TrimSpace(flag)
+		if flag != "" {
+			flags = append(flags, flag)
+		}
+	}
+	return flags
+}
@@ -0,0 +1,108 @@
+package bluez
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/godbus/dbus"
+)
+
+// OperationKind the kind of D-Bus operation flowing through a Client
+type OperationKind int
+
+const (
+	// OperationCall a method call
+	OperationCall OperationKind = iota
+	// OperationGetProperty a property read
+	OperationGetProperty
+	// OperationSetProperty a property write
+	OperationSetProperty
+	// OperationSignal a signal delivered to a subscriber
+	OperationSignal
+)
+
+func (k OperationKind) String() string {
+	switch k {
+	case OperationCall:
+		return "call"
+	case OperationGetProperty:
+		return "get"
+	case OperationSetProperty:
+		return "set"
+	case OperationSignal:
+		return "signal"
+	}
+	return "unknown"
+}
+
+// parseOperationKind return the kind named by s, as returned by OperationKind.String
+func parseOperationKind(s string) (OperationKind, error) {
+	for _, kind := range []OperationKind{OperationCall, OperationGetProperty, OperationSetProperty, OperationSignal} {
+		if kind.String() == s {
+			return kind, nil
+		}
+	}
+	return 0, fmt.Errorf("Unknown operation kind %s", s)
+}
+
+// Operation describe a D-Bus operation
+type Operation struct {
+	Kind        OperationKind
+	Destination string
+	Path        dbus.ObjectPath
+	Interface   string
+	// Member is the method, property or signal name
+	Member string
+	Flags  dbus.Flags
+	// Args are the call arguments or, for SetProperty, the new value
+	Args []interface{}
+	// Reply is the call reply body, the property value or the signal body
+	Reply []interface{}
+	// Match is the subscription receiving a signal
+	Match *SignalMatch
+}
+
+// Method return the fully qualified member name, eg. org.bluez.Device1.Connect
+func (op *Operation) Method() string {
+	return op.Interface + "." + op.Member
+}
+
+// Handler execute an operation
+type Handler func(ctx context.Context, op *Operation) error
+
+// Interceptor wrap the execution of an operation. It must call next to continue the
+// chain, an interceptor can inspect or change the operation, its reply and error
+type Interceptor func(ctx context.Context, op *Operation, next Handler) error
+
+var interceptorsLock sync.RWMutex
+var interceptors []Interceptor
+
+// Use add interceptors applied to the operations of every Client, before the ones in Config
+func Use(list ...Interceptor) {
+	interceptorsLock.Lock()
+	defer interceptorsLock.Unlock()
+	interceptors = append(interceptors, list...)
+}
+
+// getInterceptors return the interceptors to apply for a config
+func getInterceptors(config *Config) []Interceptor {
+	interceptorsLock.RLock()
+	defer interceptorsLock.RUnlock()
+	list := make([]Interceptor, 0, len(interceptors)+len(config.Interceptors))
+	list = append(list, interceptors...)
+	return append(list, config.Interceptors...)
+}
+
+// chain build a Handler running the interceptors in order before last
+func chain(list []Interceptor, last Handler) Handler {
+	handler := last
+	for i := len(list) - 1; i >= 0; i-- {
+		interceptor := list[i]
+		next := handler
+		handler = func(ctx context.Context, op *Operation) error {
+			return interceptor(ctx, op, next)
+		}
+	}
+	return handler
+}
@@ -0,0 +1,186 @@
+package bluez
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultLatencyBuckets the upper bounds of the latency histogram used by NewMetrics
+var DefaultLatencyBuckets = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+}
+
+// MethodMetrics the statistics collected for a method
+type MethodMetrics struct {
+	Kind   OperationKind
+	Method string
+	Count  uint64
+	Errors uint64
+	Sum    time.Duration
+	// Buckets holds the count of operations lasting at most the matching Metrics bucket bound
+	Buckets []uint64
+}
+
+// Metrics collect per method latency histograms and error counters. Add it to a
+// client with Interceptor and read it with Snapshot or as Prometheus text with WriteTo
+type Metrics struct {
+	lock    sync.Mutex
+	buckets []time.Duration
+	methods map[string]*MethodMetrics
+}
+
+// NewMetrics create a Metrics collector, DefaultLatencyBuckets are used if no bucket is given
+func NewMetrics(buckets ...time.Duration) *Metrics {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	sorted := append([]time.Duration{}, buckets...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return &Metrics{
+		buckets: sorted,
+		methods: make(map[string]*MethodMetrics),
+	}
+}
+
+// Interceptor return the interceptor recording the operations
+func (m *Metrics) Interceptor() Interceptor {
+	return func(ctx context.Context, op *Operation, next Handler) error {
+		start := time.Now()
+		err := next(ctx, op)
+		m.Observe(op.Kind, op.Method(), time.Since(start), err)
+		return err
+	}
+}
+
+// Observe record an operation
+func (m *Metrics) Observe(kind OperationKind, method string, duration time.Duration, err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	key := kind.String() + " " + method
+	stats, ok := m.methods[key]
+	if !ok {
+		stats = &MethodMetrics{
+			Kind:    kind,
+			Method:  method,
+			Buckets: make([]uint64, len(m.buckets)),
+		}
+		m.methods[key] = stats
+	}
+
+	stats.Count++
+	stats.Sum += duration
+	if err != nil {
+		stats.Errors++
+	}
+	for i, bound := range m.buckets {
+		if duration <= bound {
+			stats.Buckets[i]++
+		}
+	}
+}
+
+// Buckets return the upper bounds of the histogram buckets
+func (m *Metrics) Buckets() []time.Duration {
+	return append([]time.Duration{}, m.buckets...)
+}
+
+// Snapshot return a copy of the collected metrics, sorted by kind and method
+func (m *Metrics) Snapshot() []MethodMetrics {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	list := make([]MethodMetrics, 0, len(m.methods))
+	for _, stats := range m.methods {
+		copied := *stats
+		copied.Buckets = append([]uint64{}, stats.Buckets...)
+		list = append(list, copied)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Kind != list[j].Kind {
+			return list[i].Kind < list[j].Kind
+		}
+		return list[i].Method < list[j].Method
+	})
+	return list
+}
+
+// Reset drop the collected metrics
+func (m *Metrics) Reset() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.methods = make(map[string]*MethodMetrics)
+}
+
+// WriteTo write the metrics in the Prometheus text exposition format
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+
+	var written int64
+	write := func(format string, args ...interface{}) error {
+		n, err := fmt.Fprintf(w, format, args...)
+		written += int64(n)
+		return err
+	}
+
+	list := m.Snapshot()
+
+	if err := write("# HELP bluez_dbus_duration_seconds Latency of the D-Bus operations\n"); err != nil {
+		return written, err
+	}
+	if err := write("# TYPE bluez_dbus_duration_seconds histogram\n"); err != nil {
+		return written, err
+	}
+	for _, stats := range list {
+		labels := fmt.Sprintf("kind=%q,method=%q", stats.Kind.String(), stats.Method)
+		for i, bound := range m.buckets {
+			le := strconv.FormatFloat(bound.Seconds(), 'f', -1, 64)
+			if err := write("bluez_dbus_duration_seconds_bucket{%s,le=%q} %d\n", labels, le, stats.Buckets[i]); err != nil {
+				return written, err
+			}
+		}
+		if err := write("bluez_dbus_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, stats.Count); err != nil {
+			return written, err
+		}
+		if err := write("bluez_dbus_duration_seconds_sum{%s} %s\n", labels, strconv.FormatFloat(stats.Sum.Seconds(), 'f', -1, 64)); err != nil {
+			return written, err
+		}
+		if err := write("bluez_dbus_duration_seconds_count{%s} %d\n", labels, stats.Count); err != nil {
+			return written, err
+		}
+	}
+
+	if err := write("# HELP bluez_dbus_errors_total Failed D-Bus operations\n"); err != nil {
+		return written, err
+	}
+	if err := write("# TYPE bluez_dbus_errors_total counter\n"); err != nil {
+		return written, err
+	}
+	for _, stats := range list {
+		if err := write("bluez_dbus_errors_total{kind=%q,method=%q} %d\n", stats.Kind.String(), stats.Method, stats.Errors); err != nil {
+			return written, err
+		}
+	}
+
+	return written, nil
+}
+
+// ServeHTTP expose the metrics to a Prometheus scraper, eg. http.Handle("/metrics", metrics)
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	m.WriteTo(w)
+}
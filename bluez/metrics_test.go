@@ -0,0 +1,58 @@
+package bluez
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsInterceptor(t *testing.T) {
+
+	metrics := NewMetrics(10*time.Millisecond, time.Second)
+
+	op := &Operation{Kind: OperationCall, Interface: "org.bluez.Device1", Member: "Connect"}
+	fail := errors.New("failed")
+
+	handler := chain([]Interceptor{metrics.Interceptor()}, func(ctx context.Context, op *Operation) error {
+		return nil
+	})
+	handler(context.Background(), op)
+
+	handler = chain([]Interceptor{metrics.Interceptor()}, func(ctx context.Context, op *Operation) error {
+		return fail
+	})
+	if err := handler(context.Background(), op); err != fail {
+		t.Fatalf("Expected the handler error, got %v", err)
+	}
+
+	snapshot := metrics.Snapshot()
+	if len(snapshot) != 1 {
+		t.Fatalf("Expected 1 method, got %d", len(snapshot))
+	}
+	if snapshot[0].Count != 2 || snapshot[0].Errors != 1 {
+		t.Fatalf("Unexpected counters %+v", snapshot[0])
+	}
+	if snapshot[0].Buckets[1] != 2 {
+		t.Fatalf("Expected 2 operations under 1s, got %d", snapshot[0].Buckets[1])
+	}
+
+	buf := new(bytes.Buffer)
+	metrics.WriteTo(buf)
+	expected := `bluez_dbus_errors_total{kind="call",method="org.bluez.Device1.Connect"} 1`
+	if !strings.Contains(buf.String(), expected) {
+		t.Fatalf("Missing %s in\n%s", expected, buf.String())
+	}
+}
+
+func TestEncodedSize(t *testing.T) {
+	if size := encodedSize(nil); size != 0 {
+		t.Fatalf("Expected 0, got %d", size)
+	}
+	// uint32 length + 4 bytes + nul terminator
+	if size := encodedSize([]interface{}{"abcd"}); size != 9 {
+		t.Fatalf("Expected 9, got %d", size)
+	}
+}
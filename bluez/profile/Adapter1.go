@@ -1,20 +1,23 @@
 package profile
 
 import (
+	"context"
+
 	"github.com/godbus/dbus"
 	"github.com/saurabh-newera/BLE/bluez"
 )
 
 // NewAdapter1 create a new Adapter1 client
-func NewAdapter1(hostID string) *Adapter1 {
+func NewAdapter1(hostID string, opts ...Option) *Adapter1 {
 	a := new(Adapter1)
-	a.client = bluez.NewClient(
+	a.client = newClient(
 		&bluez.Config{
 			Name:  "org.bluez",
 			Iface: bluez.Adapter1Interface,
 			Path:  "/org/bluez/" + hostID,
 			Bus:   bluez.SystemBus,
 		},
+		opts,
 	)
 	a.Properties = new(Adapter1Properties)
 	a.GetProperties()
@@ -27,20 +30,32 @@ type Adapter1 struct {
 	Properties *Adapter1Properties
 }
 
-//Adapter1Properties contains the exposed properties of an interface
+//Adapter1Properties contains the exposed properties of an interface, the properties
+//missing on older bluez versions keep their zero value
 type Adapter1Properties struct {
 	UUIDs               []string
 	Discoverable        bool
 	Discovering         bool
 	Pairable            bool
 	Powered             bool
+	Connectable         bool
 	Address             string
+	AddressType         string
 	Alias               string
 	Modalias            string
 	Name                string
+	PowerState          string
 	Class               uint32
 	DiscoverableTimeout uint32
 	PairableTimeout     uint32
+	// Roles the supported roles, eg. central, peripheral, central-peripheral
+	Roles []string
+	// ExperimentalFeatures the UUIDs of the enabled experimental features
+	ExperimentalFeatures []string
+	// Manufacturer the company identifier of the controller
+	Manufacturer uint16
+	// Version the Bluetooth core version of the controller
+	Version byte
 }
 
 // Close the connection
@@ -50,26 +65,180 @@ func (a *Adapter1) Close() {
 
 //GetProperties load all available properties
 func (a *Adapter1) GetProperties() (*Adapter1Properties, error) {
-	err := a.client.GetProperties(a.Properties)
+	return a.GetPropertiesContext(context.Background())
+}
+
+//GetPropertiesContext load all available properties, aborting when ctx is done
+func (a *Adapter1) GetPropertiesContext(ctx context.Context) (*Adapter1Properties, error) {
+	err := a.client.GetPropertiesContext(ctx, a.Properties)
 	return a.Properties, err
 }
 
+//WatchProperties return a channel receiving the property changes, GetProperties updates Properties
+func (a *Adapter1) WatchProperties() (chan *bluez.PropertyChange, error) {
+	return a.client.WatchProperties()
+}
+
+//UnwatchProperties stop the delivery of property changes to channel and close it
+func (a *Adapter1) UnwatchProperties(channel chan *bluez.PropertyChange) {
+	a.client.UnwatchProperties(channel)
+}
+
 //SetProperty set a property
 func (a *Adapter1) SetProperty(name string, value interface{}) error {
-	return a.client.SetProperty(name, value)
+	return a.SetPropertyContext(context.Background(), name, value)
+}
+
+//SetPropertyContext set a property, aborting when ctx is done
+func (a *Adapter1) SetPropertyContext(ctx context.Context, name string, value interface{}) error {
+	return a.client.SetPropertyContext(ctx, name, value)
+}
+
+//SetAlias set the name of the adapter, an empty alias restores the system name
+func (a *Adapter1) SetAlias(alias string) error {
+	return a.SetAliasContext(context.Background(), alias)
+}
+
+//SetAliasContext set Alias, aborting when ctx is done
+func (a *Adapter1) SetAliasContext(ctx context.Context, alias string) error {
+	return a.SetPropertyContext(ctx, "Alias", alias)
+}
+
+//SetPowered switch the adapter on or off
+func (a *Adapter1) SetPowered(powered bool) error {
+	return a.SetPoweredContext(context.Background(), powered)
+}
+
+//SetPoweredContext set Powered, aborting when ctx is done
+func (a *Adapter1) SetPoweredContext(ctx context.Context, powered bool) error {
+	return a.SetPropertyContext(ctx, "Powered", powered)
+}
+
+//SetDiscoverable make the adapter visible to the other devices
+func (a *Adapter1) SetDiscoverable(discoverable bool) error {
+	return a.SetDiscoverableContext(context.Background(), discoverable)
+}
+
+//SetDiscoverableContext set Discoverable, aborting when ctx is done
+func (a *Adapter1) SetDiscoverableContext(ctx context.Context, discoverable bool) error {
+	return a.SetPropertyContext(ctx, "Discoverable", discoverable)
+}
+
+//SetDiscoverableTimeout set the seconds the adapter stays discoverable, 0 disables the timeout
+func (a *Adapter1) SetDiscoverableTimeout(timeout uint32) error {
+	return a.SetDiscoverableTimeoutContext(context.Background(), timeout)
+}
+
+//SetDiscoverableTimeoutContext set DiscoverableTimeout, aborting when ctx is done
+func (a *Adapter1) SetDiscoverableTimeoutContext(ctx context.Context, timeout uint32) error {
+	return a.SetPropertyContext(ctx, "DiscoverableTimeout", timeout)
+}
+
+//SetPairable allow the pairing with the adapter
+func (a *Adapter1) SetPairable(pairable bool) error {
+	return a.SetPairableContext(context.Background(), pairable)
+}
+
+//SetPairableContext set Pairable, aborting when ctx is done
+func (a *Adapter1) SetPairableContext(ctx context.Context, pairable bool) error {
+	return a.SetPropertyContext(ctx, "Pairable", pairable)
+}
+
+//SetPairableTimeout set the seconds the adapter stays pairable, 0 disables the timeout
+func (a *Adapter1) SetPairableTimeout(timeout uint32) error {
+	return a.SetPairableTimeoutContext(context.Background(), timeout)
+}
+
+//SetPairableTimeoutContext set PairableTimeout, aborting when ctx is done
+func (a *Adapter1) SetPairableTimeoutContext(ctx context.Context, timeout uint32) error {
+	return a.SetPropertyContext(ctx, "PairableTimeout", timeout)
+}
+
+//SetConnectable allow the incoming connections
+func (a *Adapter1) SetConnectable(connectable bool) error {
+	return a.SetConnectableContext(context.Background(), connectable)
+}
+
+//SetConnectableContext set Connectable, aborting when ctx is done
+func (a *Adapter1) SetConnectableContext(ctx context.Context, connectable bool) error {
+	return a.SetPropertyContext(ctx, "Connectable", connectable)
 }
 
 //StartDiscovery on the adapter
 func (a *Adapter1) StartDiscovery() error {
-	return a.client.Call("StartDiscovery", 0).Store()
+	return a.StartDiscoveryContext(context.Background())
+}
+
+//StartDiscoveryContext start discovery on the adapter, aborting when ctx is done
+func (a *Adapter1) StartDiscoveryContext(ctx context.Context) error {
+	return a.client.CallContext(ctx, "StartDiscovery", 0).Store()
 }
 
 //StopDiscovery on the adapter
 func (a *Adapter1) StopDiscovery() error {
-	return a.client.Call("StopDiscovery", 0).Store()
+	return a.StopDiscoveryContext(context.Background())
+}
+
+//StopDiscoveryContext stop discovery on the adapter, aborting when ctx is done
+func (a *Adapter1) StopDiscoveryContext(ctx context.Context) error {
+	return a.client.CallContext(ctx, "StopDiscovery", 0).Store()
+}
+
+//SetDiscoveryFilter restrict the devices reported by StartDiscovery, an empty filter
+//clears it. The filter applies to the connection until the discovery stops
+func (a *Adapter1) SetDiscoveryFilter(filter *DiscoveryFilter) error {
+	return a.SetDiscoveryFilterContext(context.Background(), filter)
+}
+
+//SetDiscoveryFilterContext set the discovery filter, aborting when ctx is done
+func (a *Adapter1) SetDiscoveryFilterContext(ctx context.Context, filter *DiscoveryFilter) error {
+	if filter == nil {
+		filter = new(DiscoveryFilter)
+	}
+	options, err := filter.ToMap()
+	if err != nil {
+		return err
+	}
+	return a.client.CallContext(ctx, "SetDiscoveryFilter", 0, options).Store()
+}
+
+//GetDiscoveryFilters return the filter keys supported by the adapter
+func (a *Adapter1) GetDiscoveryFilters() ([]string, error) {
+	return a.GetDiscoveryFiltersContext(context.Background())
+}
+
+//GetDiscoveryFiltersContext return the supported filter keys, aborting when ctx is done
+func (a *Adapter1) GetDiscoveryFiltersContext(ctx context.Context) ([]string, error) {
+	var filters []string
+	err := a.client.CallContext(ctx, "GetDiscoveryFilters", 0).Store(&filters)
+	return filters, err
 }
 
 //RemoveDevice from the list
 func (a *Adapter1) RemoveDevice(device string) error {
-	return a.client.Call("RemoveDevice", 0, dbus.ObjectPath(device)).Store()
+	return a.RemoveDeviceContext(context.Background(), device)
+}
+
+//RemoveDeviceContext remove a device from the list, aborting when ctx is done
+func (a *Adapter1) RemoveDeviceContext(ctx context.Context, device string) error {
+	return a.client.CallContext(ctx, "RemoveDevice", 0, dbus.ObjectPath(device)).Store()
+}
+
+//ConnectDevice connect to a device without discovery, addressType is AddressTypePublic,
+//AddressTypeRandom or empty for a BR/EDR device. The path of the device is returned (experimental)
+func (a *Adapter1) ConnectDevice(address string, addressType string) (dbus.ObjectPath, error) {
+	return a.ConnectDeviceContext(context.Background(), address, addressType)
+}
+
+//ConnectDeviceContext connect to a device without discovery, aborting when ctx is done
+func (a *Adapter1) ConnectDeviceContext(ctx context.Context, address string, addressType string) (dbus.ObjectPath, error) {
+	properties := map[string]interface{}{
+		"Address": address,
+	}
+	if addressType != "" {
+		properties["AddressType"] = addressType
+	}
+	var device dbus.ObjectPath
+	err := a.client.CallContext(ctx, "ConnectDevice", 0, properties).Store(&device)
+	return device, err
 }
@@ -26,3 +26,34 @@ func TestNewAdapter1(t *testing.T) {
 	t.Skipped()
 
 }
+
+func TestDiscoveryFilter(t *testing.T) {
+
+	duplicates := false
+	filter := &DiscoveryFilter{
+		UUIDs:         []string{"f000aa00-0451-4000-b000-000000000000"},
+		RSSI:          -70,
+		Transport:     DiscoveryTransportLE,
+		DuplicateData: &duplicates,
+	}
+	options, err := filter.ToMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(options) != 4 {
+		t.Fatalf("Expected 4 options, got %v", options)
+	}
+	if options["RSSI"].Value() != int16(-70) || options["DuplicateData"].Value() != false {
+		t.Fatalf("Unexpected options %v", options)
+	}
+
+	empty, err := new(DiscoveryFilter).ToMap()
+	if err != nil || len(empty) != 0 {
+		t.Fatalf("Expected an empty filter, got %v %v", empty, err)
+	}
+
+	filter.Pathloss = 10
+	if _, err := filter.ToMap(); err == nil {
+		t.Fatal("Expected an error with RSSI and Pathloss")
+	}
+}
@@ -0,0 +1,181 @@
+package profile
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/bluez"
+	"github.com/saurabh-newera/BLE/util"
+)
+
+// MonitorTypeOrPatterns a monitor matching an advertisement containing any of its patterns
+const MonitorTypeOrPatterns = "or_patterns"
+
+// Common AD types matched by a MonitorPattern
+const (
+	ADTypeFlags            byte = 0x01
+	ADTypeShortName        byte = 0x08
+	ADTypeCompleteName     byte = 0x09
+	ADTypeServiceData16    byte = 0x16
+	ADTypeManufacturerData byte = 0xff
+)
+
+// The RSSI limits of a monitor, in dBm
+const (
+	MonitorRSSIMin = -127
+	MonitorRSSIMax = 20
+)
+
+// MonitorPattern match Value at Offset in the AD structures of type ADType
+type MonitorPattern struct {
+	Offset byte
+	ADType byte
+	Value  []byte
+}
+
+// Monitor the properties of an AdvertisementMonitor1, the empty fields are not sent
+type Monitor struct {
+	// Type is MonitorTypeOrPatterns
+	Type string `dbus:"Type"`
+	// RSSILowThreshold and RSSILowTimeout, in seconds, declare a device lost
+	RSSILowThreshold int16  `dbus:"RSSILowThreshold,omitempty"`
+	RSSILowTimeout   uint16 `dbus:"RSSILowTimeout,omitempty"`
+	// RSSIHighThreshold and RSSIHighTimeout, in seconds, declare a device found
+	RSSIHighThreshold int16  `dbus:"RSSIHighThreshold,omitempty"`
+	RSSIHighTimeout   uint16 `dbus:"RSSIHighTimeout,omitempty"`
+	// RSSISamplingPeriod in units of 100ms, 0 reports all the advertisements
+	RSSISamplingPeriod uint16           `dbus:"RSSISamplingPeriod,omitempty"`
+	Patterns           []MonitorPattern `dbus:"Patterns,omitempty"`
+}
+
+// Validate check the type, the patterns and the RSSI thresholds
+func (m *Monitor) Validate() error {
+
+	if m.Type != MonitorTypeOrPatterns {
+		return fmt.Errorf("Invalid monitor type %q", m.Type)
+	}
+	if len(m.Patterns) == 0 {
+		return errors.New("A monitor needs at least one pattern")
+	}
+	for _, p := range m.Patterns {
+		if len(p.Value) == 0 || int(p.Offset)+len(p.Value) > MaxAdvertisementSize {
+			return fmt.Errorf("Invalid pattern %x at offset %d", p.Value, p.Offset)
+		}
+	}
+	for _, rssi := range []int16{m.RSSILowThreshold, m.RSSIHighThreshold} {
+		if rssi != 0 && (rssi < MonitorRSSIMin || rssi > MonitorRSSIMax) {
+			return fmt.Errorf("Invalid RSSI threshold %d", rssi)
+		}
+	}
+	if m.RSSILowThreshold != 0 && m.RSSIHighThreshold != 0 && m.RSSILowThreshold > m.RSSIHighThreshold {
+		return errors.New("RSSILowThreshold is above RSSIHighThreshold")
+	}
+	return nil
+}
+
+// MonitorHandler receive the callbacks of an advertisement monitor
+type MonitorHandler interface {
+	// Release is called when bluez drops the monitor, eg. it is invalid
+	Release()
+	// Activate is called once the monitor is in use
+	Activate()
+	// DeviceFound is called when a device matches the monitor
+	DeviceFound(device dbus.ObjectPath)
+	// DeviceLost is called when a device found earlier stops matching, eg. on RSSILowTimeout
+	DeviceLost(device dbus.ObjectPath)
+}
+
+// NewAdvertisementMonitor1 create a monitor exported at path, a child of the application
+// registered with AdvertisementMonitorManager1.RegisterMonitor
+func NewAdvertisementMonitor1(path string, monitor *Monitor, handler MonitorHandler, opts ...Option) *AdvertisementMonitor1 {
+	m := new(AdvertisementMonitor1)
+	m.client = newClient(
+		&bluez.Config{
+			Name:  "org.bluez",
+			Iface: bluez.AdvertisementMonitor1Interface,
+			Path:  path,
+			Bus:   bluez.SystemBus,
+		},
+		opts,
+	)
+	m.Path = dbus.ObjectPath(path)
+	m.Monitor = monitor
+	m.handler = handler
+	return m
+}
+
+// AdvertisementMonitor1 an org.bluez.AdvertisementMonitor1 object forwarding the callbacks to a MonitorHandler
+type AdvertisementMonitor1 struct {
+	client  *bluez.Client
+	handler MonitorHandler
+	Path    dbus.ObjectPath
+	Monitor *Monitor
+}
+
+// ManagedProperties return the properties listed by the ObjectManager of the application
+func (m *AdvertisementMonitor1) ManagedProperties() (map[string]dbus.Variant, error) {
+	return util.StructToMap(m.Monitor)
+}
+
+// Export validate the monitor and export it on the bus, the properties cannot change afterwards
+func (m *AdvertisementMonitor1) Export() error {
+
+	err := m.Monitor.Validate()
+	if err != nil {
+		return err
+	}
+	props, err := m.ManagedProperties()
+	if err != nil {
+		return err
+	}
+
+	err = m.client.Export(m, m.Path, bluez.AdvertisementMonitor1Interface)
+	if err != nil {
+		return err
+	}
+	return m.client.Export(&bluez.ExportedProperties{
+		Interface: bluez.AdvertisementMonitor1Interface,
+		Values: func() map[string]dbus.Variant {
+			return props
+		},
+	}, m.Path, bluez.PropertiesInterface)
+}
+
+// Unexport remove the monitor from the bus
+func (m *AdvertisementMonitor1) Unexport() error {
+	err := m.client.Unexport(m.Path, bluez.AdvertisementMonitor1Interface)
+	if err != nil {
+		return err
+	}
+	return m.client.Unexport(m.Path, bluez.PropertiesInterface)
+}
+
+// Close remove the monitor and the connection
+func (m *AdvertisementMonitor1) Close() {
+	m.client.Disconnect()
+}
+
+//Release is called by bluez when the monitor is removed
+func (m *AdvertisementMonitor1) Release() *dbus.Error {
+	m.handler.Release()
+	return nil
+}
+
+//Activate is called by bluez when the monitor is in use
+func (m *AdvertisementMonitor1) Activate() *dbus.Error {
+	m.handler.Activate()
+	return nil
+}
+
+//DeviceFound is called by bluez when a device matches the monitor
+func (m *AdvertisementMonitor1) DeviceFound(device dbus.ObjectPath) *dbus.Error {
+	m.handler.DeviceFound(device)
+	return nil
+}
+
+//DeviceLost is called by bluez when a device stops matching the monitor
+func (m *AdvertisementMonitor1) DeviceLost(device dbus.ObjectPath) *dbus.Error {
+	m.handler.DeviceLost(device)
+	return nil
+}
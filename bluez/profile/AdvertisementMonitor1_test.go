@@ -0,0 +1,42 @@
+package profile
+
+import (
+	"testing"
+)
+
+func TestMonitorValidate(t *testing.T) {
+
+	monitor := &Monitor{
+		Type:              MonitorTypeOrPatterns,
+		RSSILowThreshold:  -90,
+		RSSIHighThreshold: -70,
+		Patterns: []MonitorPattern{
+			{Offset: 0, ADType: ADTypeManufacturerData, Value: []byte{0x4c, 0x00, 0x02, 0x15}},
+		},
+	}
+	if err := monitor.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewAdvertisementMonitor1("/org/bluez/go/monitor/test", monitor, nil)
+	props, err := m.ManagedProperties()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig := props["Patterns"].Signature().String(); sig != "a(yyay)" {
+		t.Fatalf("Expected a(yyay) patterns, got %s", sig)
+	}
+	if _, ok := props["RSSILowTimeout"]; ok {
+		t.Fatal("Expected RSSILowTimeout to be omitted")
+	}
+
+	monitor.RSSILowThreshold = -60
+	if err := monitor.Validate(); err == nil {
+		t.Fatal("Expected an error for a low threshold above the high one")
+	}
+	monitor.RSSILowThreshold = -90
+	monitor.Patterns[0].Offset = 30
+	if err := monitor.Validate(); err == nil {
+		t.Fatal("Expected an error for a pattern out of the advertising data")
+	}
+}
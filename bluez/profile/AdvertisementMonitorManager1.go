@@ -0,0 +1,75 @@
+package profile
+
+import (
+	"context"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/bluez"
+)
+
+// NewAdvertisementMonitorManager1 create a new AdvertisementMonitorManager1 client
+func NewAdvertisementMonitorManager1(hostID string, opts ...Option) *AdvertisementMonitorManager1 {
+	a := new(AdvertisementMonitorManager1)
+	a.client = newClient(
+		&bluez.Config{
+			Name:  "org.bluez",
+			Iface: bluez.AdvertisementMonitorManager1Interface,
+			Path:  "/org/bluez/" + hostID,
+			Bus:   bluez.SystemBus,
+		},
+		opts,
+	)
+	a.Properties = new(AdvertisementMonitorManager1Properties)
+	return a
+}
+
+// AdvertisementMonitorManager1 client
+type AdvertisementMonitorManager1 struct {
+	client     *bluez.Client
+	Properties *AdvertisementMonitorManager1Properties
+}
+
+//AdvertisementMonitorManager1Properties contains the exposed properties of an interface
+type AdvertisementMonitorManager1Properties struct {
+	// SupportedMonitorTypes eg. MonitorTypeOrPatterns
+	SupportedMonitorTypes []string
+	// SupportedFeatures the offloading features of the controller, eg. controller-patterns
+	SupportedFeatures []string
+}
+
+// Close the connection
+func (a *AdvertisementMonitorManager1) Close() {
+	a.client.Disconnect()
+}
+
+//GetProperties load all available properties
+func (a *AdvertisementMonitorManager1) GetProperties() (*AdvertisementMonitorManager1Properties, error) {
+	return a.GetPropertiesContext(context.Background())
+}
+
+//GetPropertiesContext load all available properties, aborting when ctx is done
+func (a *AdvertisementMonitorManager1) GetPropertiesContext(ctx context.Context) (*AdvertisementMonitorManager1Properties, error) {
+	err := a.client.GetPropertiesContext(ctx, a.Properties)
+	return a.Properties, err
+}
+
+//RegisterMonitor register the application exported at root, its monitors are listed
+// by the ObjectManager of root
+func (a *AdvertisementMonitorManager1) RegisterMonitor(root dbus.ObjectPath) error {
+	return a.RegisterMonitorContext(context.Background(), root)
+}
+
+//RegisterMonitorContext register a monitor application, aborting when ctx is done
+func (a *AdvertisementMonitorManager1) RegisterMonitorContext(ctx context.Context, root dbus.ObjectPath) error {
+	return a.client.CallContext(ctx, "RegisterMonitor", 0, root).Store()
+}
+
+//UnregisterMonitor unregister a monitor application
+func (a *AdvertisementMonitorManager1) UnregisterMonitor(root dbus.ObjectPath) error {
+	return a.UnregisterMonitorContext(context.Background(), root)
+}
+
+//UnregisterMonitorContext unregister a monitor application, aborting when ctx is done
+func (a *AdvertisementMonitorManager1) UnregisterMonitorContext(ctx context.Context, root dbus.ObjectPath) error {
+	return a.client.CallContext(ctx, "UnregisterMonitor", 0, root).Store()
+}
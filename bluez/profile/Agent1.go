@@ -0,0 +1,115 @@
+package profile
+
+import (
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/bluez"
+)
+
+// AgentHandler implement the pairing callbacks of an agent. Return bluez.ErrRejected or
+// bluez.ErrCanceled to refuse a request, other errors are reported as org.bluez.Error.Failed
+type AgentHandler interface {
+	// Release is called when bluez unregisters the agent
+	Release() error
+	// RequestPinCode return the PIN code of a legacy device
+	RequestPinCode(device dbus.ObjectPath) (string, error)
+	// DisplayPinCode show the PIN code to type on the remote device
+	DisplayPinCode(device dbus.ObjectPath, pincode string) error
+	// RequestPasskey return the passkey, between 0 and 999999
+	RequestPasskey(device dbus.ObjectPath) (uint32, error)
+	// DisplayPasskey show the passkey to type on the remote device, entered is the number of typed digits
+	DisplayPasskey(device dbus.ObjectPath, passkey uint32, entered uint16) error
+	// RequestConfirmation confirm that the passkey matches the one shown by the remote device
+	RequestConfirmation(device dbus.ObjectPath, passkey uint32) error
+	// RequestAuthorization authorize an incoming pairing, eg. "just works"
+	RequestAuthorization(device dbus.ObjectPath) error
+	// AuthorizeService authorize a connection to a service
+	AuthorizeService(device dbus.ObjectPath, uuid string) error
+	// Cancel is called when a request is canceled by bluez, eg. on timeout
+	Cancel() error
+}
+
+// NewAgent1 create an agent exporting handler at path, eg. /org/bluez/agent/go
+func NewAgent1(path string, handler AgentHandler, opts ...Option) *Agent1 {
+	a := new(Agent1)
+	a.client = newClient(
+		&bluez.Config{
+			Name:  "org.bluez",
+			Iface: bluez.Agent1Interface,
+			Path:  path,
+			Bus:   bluez.SystemBus,
+		},
+		opts,
+	)
+	a.Path = dbus.ObjectPath(path)
+	a.handler = handler
+	return a
+}
+
+// Agent1 an org.bluez.Agent1 object forwarding the requests of bluez to an AgentHandler
+type Agent1 struct {
+	client  *bluez.Client
+	handler AgentHandler
+	Path    dbus.ObjectPath
+}
+
+// Export the agent on the bus, it must be registered with AgentManager1.RegisterAgent
+func (a *Agent1) Export() error {
+	return a.client.Export(a, a.Path, bluez.Agent1Interface)
+}
+
+// Unexport remove the agent from the bus
+func (a *Agent1) Unexport() error {
+	return a.client.Unexport(a.Path, bluez.Agent1Interface)
+}
+
+// Close remove the agent and the connection
+func (a *Agent1) Close() {
+	a.client.Disconnect()
+}
+
+//Release is called by bluez when the agent is unregistered
+func (a *Agent1) Release() *dbus.Error {
+	return bluez.ToDBusError(a.handler.Release())
+}
+
+//RequestPinCode is called by bluez to get the PIN code of a device
+func (a *Agent1) RequestPinCode(device dbus.ObjectPath) (string, *dbus.Error) {
+	pincode, err := a.handler.RequestPinCode(device)
+	return pincode, bluez.ToDBusError(err)
+}
+
+//DisplayPinCode is called by bluez to show a PIN code
+func (a *Agent1) DisplayPinCode(device dbus.ObjectPath, pincode string) *dbus.Error {
+	return bluez.ToDBusError(a.handler.DisplayPinCode(device, pincode))
+}
+
+//RequestPasskey is called by bluez to get the passkey of a device
+func (a *Agent1) RequestPasskey(device dbus.ObjectPath) (uint32, *dbus.Error) {
+	passkey, err := a.handler.RequestPasskey(device)
+	return passkey, bluez.ToDBusError(err)
+}
+
+//DisplayPasskey is called by bluez to show a passkey
+func (a *Agent1) DisplayPasskey(device dbus.ObjectPath, passkey uint32, entered uint16) *dbus.Error {
+	return bluez.ToDBusError(a.handler.DisplayPasskey(device, passkey, entered))
+}
+
+//RequestConfirmation is called by bluez to confirm a passkey
+func (a *Agent1) RequestConfirmation(device dbus.ObjectPath, passkey uint32) *dbus.Error {
+	return bluez.ToDBusError(a.handler.RequestConfirmation(device, passkey))
+}
+
+//RequestAuthorization is called by bluez to authorize a pairing
+func (a *Agent1) RequestAuthorization(device dbus.ObjectPath) *dbus.Error {
+	return bluez.ToDBusError(a.handler.RequestAuthorization(device))
+}
+
+//AuthorizeService is called by bluez to authorize a service connection
+func (a *Agent1) AuthorizeService(device dbus.ObjectPath, uuid string) *dbus.Error {
+	return bluez.ToDBusError(a.handler.AuthorizeService(device, uuid))
+}
+
+//Cancel is called by bluez when a request is canceled
+func (a *Agent1) Cancel() *dbus.Error {
+	return bluez.ToDBusError(a.handler.Cancel())
+}
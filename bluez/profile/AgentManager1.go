@@ -0,0 +1,73 @@
+package profile
+
+import (
+	"context"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/bluez"
+)
+
+// The IO capabilities of an agent, used by bluez to choose the pairing method
+const (
+	AgentCapabilityDisplayOnly     = "DisplayOnly"
+	AgentCapabilityDisplayYesNo    = "DisplayYesNo"
+	AgentCapabilityKeyboardOnly    = "KeyboardOnly"
+	AgentCapabilityNoInputNoOutput = "NoInputNoOutput"
+	AgentCapabilityKeyboardDisplay = "KeyboardDisplay"
+)
+
+// NewAgentManager1 create a new AgentManager1 client
+func NewAgentManager1(opts ...Option) *AgentManager1 {
+	a := new(AgentManager1)
+	a.client = newClient(
+		&bluez.Config{
+			Name:  "org.bluez",
+			Iface: bluez.AgentManager1Interface,
+			Path:  "/org/bluez",
+			Bus:   bluez.SystemBus,
+		},
+		opts,
+	)
+	return a
+}
+
+// AgentManager1 client
+type AgentManager1 struct {
+	client *bluez.Client
+}
+
+// Close the connection
+func (a *AgentManager1) Close() {
+	a.client.Disconnect()
+}
+
+//RegisterAgent register the agent exported at path with an IO capability, eg. AgentCapabilityKeyboardDisplay
+func (a *AgentManager1) RegisterAgent(agent dbus.ObjectPath, capability string) error {
+	return a.RegisterAgentContext(context.Background(), agent, capability)
+}
+
+//RegisterAgentContext register an agent, aborting when ctx is done
+func (a *AgentManager1) RegisterAgentContext(ctx context.Context, agent dbus.ObjectPath, capability string) error {
+	return a.client.CallContext(ctx, "RegisterAgent", 0, agent, capability).Store()
+}
+
+//UnregisterAgent unregister an agent
+func (a *AgentManager1) UnregisterAgent(agent dbus.ObjectPath) error {
+	return a.UnregisterAgentContext(context.Background(), agent)
+}
+
+//UnregisterAgentContext unregister an agent, aborting when ctx is done
+func (a *AgentManager1) UnregisterAgentContext(ctx context.Context, agent dbus.ObjectPath) error {
+	return a.client.CallContext(ctx, "UnregisterAgent", 0, agent).Store()
+}
+
+//RequestDefaultAgent make a registered agent the default one, handling the pairing requests
+//not initiated by an application
+func (a *AgentManager1) RequestDefaultAgent(agent dbus.ObjectPath) error {
+	return a.RequestDefaultAgentContext(context.Background(), agent)
+}
+
+//RequestDefaultAgentContext make a registered agent the default one, aborting when ctx is done
+func (a *AgentManager1) RequestDefaultAgentContext(ctx context.Context, agent dbus.ObjectPath) error {
+	return a.client.CallContext(ctx, "RequestDefaultAgent", 0, agent).Store()
+}
@@ -0,0 +1,84 @@
+package profile
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/saurabh-newera/BLE/bluez"
+)
+
+// The UUIDs of the GATT Battery Service, used when bluez does not expose Battery1
+const (
+	BatteryServiceUUID = "0000180f-0000-1000-8000-00805f9b34fb"
+	BatteryLevelUUID   = "00002a19-0000-1000-8000-00805f9b34fb"
+)
+
+// BatterySourceGATT the source of a level read from the Battery Level characteristic
+const BatterySourceGATT = "GATT Battery Service"
+
+// MaxBatteryPercentage the highest battery level
+const MaxBatteryPercentage = 100
+
+// NewBattery1 create a new Battery1 client, path is the device path
+func NewBattery1(path string, opts ...Option) *Battery1 {
+	b := new(Battery1)
+	b.client = newClient(
+		&bluez.Config{
+			Name:  "org.bluez",
+			Iface: bluez.Battery1Interface,
+			Path:  path,
+			Bus:   bluez.SystemBus,
+		},
+		opts,
+	)
+	b.Properties = new(Battery1Properties)
+	return b
+}
+
+// Battery1 client
+type Battery1 struct {
+	client     *bluez.Client
+	Properties *Battery1Properties
+}
+
+//Battery1Properties contains the exposed properties of an interface
+type Battery1Properties struct {
+	// Percentage the battery level, between 0 and 100
+	Percentage byte
+	// Source of the level, eg. the profile reporting it (experimental)
+	Source string
+}
+
+// Close the connection
+func (b *Battery1) Close() {
+	b.client.Disconnect()
+}
+
+//GetProperties load all available properties
+func (b *Battery1) GetProperties() (*Battery1Properties, error) {
+	return b.GetPropertiesContext(context.Background())
+}
+
+//GetPropertiesContext load all available properties, aborting when ctx is done
+func (b *Battery1) GetPropertiesContext(ctx context.Context) (*Battery1Properties, error) {
+	err := b.client.GetPropertiesContext(ctx, b.Properties)
+	return b.Properties, err
+}
+
+//WatchProperties return a channel receiving the property changes, GetProperties updates Properties
+func (b *Battery1) WatchProperties() (chan *bluez.PropertyChange, error) {
+	return b.client.WatchProperties()
+}
+
+//UnwatchProperties stop the delivery of property changes to channel and close it
+func (b *Battery1) UnwatchProperties(channel chan *bluez.PropertyChange) {
+	b.client.UnwatchProperties(channel)
+}
+
+// ParseBatteryLevel decode the value of the Battery Level characteristic
+func ParseBatteryLevel(value []byte) (byte, error) {
+	if len(value) != 1 || value[0] > MaxBatteryPercentage {
+		return 0, fmt.Errorf("Invalid battery level %x", value)
+	}
+	return value[0], nil
+}
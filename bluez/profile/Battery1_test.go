@@ -0,0 +1,63 @@
+package profile
+
+import (
+	"testing"
+)
+
+func TestParseBatteryLevel(t *testing.T) {
+	level, err := ParseBatteryLevel([]byte{87})
+	if err != nil || level != 87 {
+		t.Fatalf("Expected 87, got %d %v", level, err)
+	}
+	for _, value := range [][]byte{nil, {101}, {50, 0}} {
+		if _, err := ParseBatteryLevel(value); err == nil {
+			t.Fatalf("Expected an error for %v", value)
+		}
+	}
+}
+
+func TestBatteryValidate(t *testing.T) {
+	battery := &Battery{Device: "/org/bluez/hci0/dev_AA_BB_CC_DD_EE_FF", Percentage: 40}
+	if err := battery.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range []Battery{
+		{Device: "", Percentage: 40},
+		{Device: "dev_AA", Percentage: 40},
+		{Device: "/org/bluez/hci0/dev_AA_BB_CC_DD_EE_FF", Percentage: 101},
+	} {
+		if err := b.Validate(); err == nil {
+			t.Fatalf("Expected an error for %+v", b)
+		}
+	}
+}
+
+func TestBatteryProviderProperties(t *testing.T) {
+	provider := NewBatteryProvider1("/org/bluez/go/battery0/battery0", &Battery{
+		Device:     "/org/bluez/hci0/dev_AA_BB_CC_DD_EE_FF",
+		Percentage: 40,
+	})
+	// not exported, no signal is sent
+	if err := provider.SetPercentage(35); err != nil {
+		t.Fatal(err)
+	}
+	if err := provider.SetPercentage(120); err == nil {
+		t.Fatal("Expected an error for 120%")
+	}
+	props, err := provider.ManagedProperties()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if props["Percentage"].Value() != byte(35) || props["Device"].Value() != provider.Battery().Device {
+		t.Fatalf("Unexpected properties %v", props)
+	}
+	if _, ok := props["Source"]; ok {
+		t.Fatalf("Expected no Source, got %v", props)
+	}
+	if err := provider.Update(30, "HFP"); err != nil {
+		t.Fatal(err)
+	}
+	if battery := provider.Battery(); battery.Percentage != 30 || battery.Source != "HFP" {
+		t.Fatalf("Unexpected battery %+v", battery)
+	}
+}
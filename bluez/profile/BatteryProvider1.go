@@ -0,0 +1,155 @@
+package profile
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/bluez"
+	"github.com/saurabh-newera/BLE/util"
+)
+
+// Battery the properties of a BatteryProvider1, a battery level known by the host
+type Battery struct {
+	// Device is the object path of the device owning the battery
+	Device dbus.ObjectPath `dbus:"Device"`
+	// Percentage the battery level, between 0 and 100
+	Percentage byte `dbus:"Percentage"`
+	// Source of the level, eg. the protocol it has been read from
+	Source string `dbus:"Source,omitempty"`
+}
+
+// Validate check the device and the percentage
+func (b *Battery) Validate() error {
+	if !b.Device.IsValid() || b.Device == "/" {
+		return fmt.Errorf("Invalid battery device %q", b.Device)
+	}
+	if b.Percentage > MaxBatteryPercentage {
+		return fmt.Errorf("Invalid battery percentage %d", b.Percentage)
+	}
+	return nil
+}
+
+// NewBatteryProvider1 create a battery exported at path, a child of the application
+// registered with BatteryProviderManager1.RegisterBatteryProvider
+func NewBatteryProvider1(path string, battery *Battery, opts ...Option) *BatteryProvider1 {
+	b := new(BatteryProvider1)
+	b.client = newClient(
+		&bluez.Config{
+			Name:  "org.bluez",
+			Iface: bluez.BatteryProvider1Interface,
+			Path:  path,
+			Bus:   bluez.SystemBus,
+		},
+		opts,
+	)
+	b.Path = dbus.ObjectPath(path)
+	b.battery = *battery
+	return b
+}
+
+// BatteryProvider1 an org.bluez.BatteryProvider1 object, the percentage can be updated once exported
+type BatteryProvider1 struct {
+	client *bluez.Client
+	Path   dbus.ObjectPath
+
+	lock     sync.Mutex
+	battery  Battery
+	exported bool
+}
+
+// Battery return the current properties of the battery
+func (b *BatteryProvider1) Battery() Battery {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.battery
+}
+
+// ManagedProperties return the properties listed by the ObjectManager of the application
+func (b *BatteryProvider1) ManagedProperties() (map[string]dbus.Variant, error) {
+	battery := b.Battery()
+	return util.StructToMap(&battery)
+}
+
+// Export validate the battery and export it on the bus
+func (b *BatteryProvider1) Export() error {
+
+	battery := b.Battery()
+	err := battery.Validate()
+	if err != nil {
+		return err
+	}
+
+	err = b.client.Export(&bluez.ExportedProperties{
+		Interface: bluez.BatteryProvider1Interface,
+		Values: func() map[string]dbus.Variant {
+			props, _ := b.ManagedProperties()
+			return props
+		},
+	}, b.Path, bluez.PropertiesInterface)
+	if err != nil {
+		return err
+	}
+
+	b.lock.Lock()
+	b.exported = true
+	b.lock.Unlock()
+	return nil
+}
+
+// Unexport remove the battery from the bus
+func (b *BatteryProvider1) Unexport() error {
+	b.lock.Lock()
+	b.exported = false
+	b.lock.Unlock()
+	return b.client.Unexport(b.Path, bluez.PropertiesInterface)
+}
+
+// Close remove the battery and the connection
+func (b *BatteryProvider1) Close() {
+	b.client.Disconnect()
+}
+
+// SetPercentage update the battery level, bluez is notified with PropertiesChanged once exported
+func (b *BatteryProvider1) SetPercentage(percentage byte) error {
+	return b.update(percentage, nil)
+}
+
+// Update update the battery level and its source, bluez is notified with PropertiesChanged once exported
+func (b *BatteryProvider1) Update(percentage byte, source string) error {
+	return b.update(percentage, &source)
+}
+
+// update set the percentage and the source when not nil, emitting the changes
+func (b *BatteryProvider1) update(percentage byte, source *string) error {
+
+	if percentage > MaxBatteryPercentage {
+		return fmt.Errorf("Invalid battery percentage %d", percentage)
+	}
+
+	changed := make(map[string]dbus.Variant)
+	invalidated := []string{}
+
+	b.lock.Lock()
+	if b.battery.Percentage != percentage {
+		b.battery.Percentage = percentage
+		changed["Percentage"] = dbus.MakeVariant(percentage)
+	}
+	if source != nil && b.battery.Source != *source {
+		b.battery.Source = *source
+		// Source is omitted once empty
+		if *source == "" {
+			invalidated = append(invalidated, "Source")
+		} else {
+			changed["Source"] = dbus.MakeVariant(*source)
+		}
+	}
+	exported := b.exported
+	b.lock.Unlock()
+
+	if !exported || (len(changed) == 0 && len(invalidated) == 0) {
+		return nil
+	}
+	return b.client.Emit(b.Path, bluez.PropertiesChanged, bluez.BatteryProvider1Interface,
+		changed, invalidated)
+}
@@ -0,0 +1,54 @@
+package profile
+
+import (
+	"context"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/bluez"
+)
+
+// NewBatteryProviderManager1 create a new BatteryProviderManager1 client
+func NewBatteryProviderManager1(hostID string, opts ...Option) *BatteryProviderManager1 {
+	a := new(BatteryProviderManager1)
+	a.client = newClient(
+		&bluez.Config{
+			Name:  "org.bluez",
+			Iface: bluez.BatteryProviderManager1Interface,
+			Path:  "/org/bluez/" + hostID,
+			Bus:   bluez.SystemBus,
+		},
+		opts,
+	)
+	return a
+}
+
+// BatteryProviderManager1 client
+type BatteryProviderManager1 struct {
+	client *bluez.Client
+}
+
+// Close the connection
+func (a *BatteryProviderManager1) Close() {
+	a.client.Disconnect()
+}
+
+//RegisterBatteryProvider register the application exported at root, its batteries are listed
+// by the ObjectManager of root and followed with InterfacesAdded and InterfacesRemoved
+func (a *BatteryProviderManager1) RegisterBatteryProvider(root dbus.ObjectPath) error {
+	return a.RegisterBatteryProviderContext(context.Background(), root)
+}
+
+//RegisterBatteryProviderContext register a battery provider, aborting when ctx is done
+func (a *BatteryProviderManager1) RegisterBatteryProviderContext(ctx context.Context, root dbus.ObjectPath) error {
+	return a.client.CallContext(ctx, "RegisterBatteryProvider", 0, root).Store()
+}
+
+//UnregisterBatteryProvider unregister a battery provider
+func (a *BatteryProviderManager1) UnregisterBatteryProvider(root dbus.ObjectPath) error {
+	return a.UnregisterBatteryProviderContext(context.Background(), root)
+}
+
+//UnregisterBatteryProviderContext unregister a battery provider, aborting when ctx is done
+func (a *BatteryProviderManager1) UnregisterBatteryProviderContext(ctx context.Context, root dbus.ObjectPath) error {
+	return a.client.CallContext(ctx, "UnregisterBatteryProvider", 0, root).Store()
+}
@@ -1,21 +1,24 @@
 package profile
 
 import (
+	"context"
+
 	"github.com/godbus/dbus"
 	"github.com/saurabh-newera/BLE/bluez"
 	"github.com/op/go-logging"
 )
 
 // NewDevice1 create a new Device1 client
-func NewDevice1(path string) *Device1 {
+func NewDevice1(path string, opts ...Option) *Device1 {
 	a := new(Device1)
-	a.client = bluez.NewClient(
+	a.client = newClient(
 		&bluez.Config{
 			Name:  "org.bluez",
 			Iface: "org.bluez.Device1",
 			Path:  path,
 			Bus:   bluez.SystemBus,
 		},
+		opts,
 	)
 	a.logger = logging.MustGetLogger(path)
 	a.Properties = new(Device1Properties)
@@ -30,27 +33,45 @@ type Device1 struct {
 	Properties *Device1Properties
 }
 
-// Device1Properties exposed properties for Device1
+// The address types of a device
+const (
+	AddressTypePublic = "public"
+	AddressTypeRandom = "random"
+)
+
+// Device1Properties exposed properties for Device1, the properties missing on older
+// bluez versions keep their zero value
 type Device1Properties struct {
 	UUIDs            []string
 	Blocked          bool
 	Connected        bool
 	LegacyPairing    bool
 	Paired           bool
+	Bonded           bool
 	ServicesResolved bool
 	Trusted          bool
+	WakeAllowed      bool
+	CablePairing     bool
 	ServiceData      map[string]dbus.Variant
 	ManufacturerData map[uint16]dbus.Variant
 	RSSI             int16
 	TxPower          int16
 	Adapter          dbus.ObjectPath
 	Address          string
+	AddressType      string
 	Alias            string
 	Icon             string
 	Modalias         string
 	Name             string
+	PreferredBearer  string
 	Appearance       uint16
 	Class            uint32
+	// AdvertisingFlags the flags of the last advertisement, eg. 0x06 for LE general discoverable
+	AdvertisingFlags []byte
+	// AdvertisingData the advertising data not handled by the other properties, by AD type
+	AdvertisingData map[byte]dbus.Variant
+	// Sets the coordinated sets of the device, with their properties (experimental)
+	Sets map[dbus.ObjectPath]map[string]dbus.Variant
 }
 
 // Close the connection
@@ -70,41 +91,167 @@ func (d *Device1) Unregister() error {
 
 //GetProperties load all available properties
 func (d *Device1) GetProperties() (*Device1Properties, error) {
-	err := d.client.GetProperties(d.Properties)
+	return d.GetPropertiesContext(context.Background())
+}
+
+//GetPropertiesContext load all available properties, aborting when ctx is done
+func (d *Device1) GetPropertiesContext(ctx context.Context) (*Device1Properties, error) {
+	err := d.client.GetPropertiesContext(ctx, d.Properties)
 	return d.Properties, err
 }
 
+//WatchProperties return a channel receiving the property changes, GetProperties updates Properties
+func (d *Device1) WatchProperties() (chan *bluez.PropertyChange, error) {
+	return d.client.WatchProperties()
+}
+
+//UnwatchProperties stop the delivery of property changes to channel and close it
+func (d *Device1) UnwatchProperties(channel chan *bluez.PropertyChange) {
+	d.client.UnwatchProperties(channel)
+}
+
 //GetProperty get a property
 func (d *Device1) GetProperty(name string) (dbus.Variant, error) {
-	return d.client.GetProperty(name)
+	return d.GetPropertyContext(context.Background(), name)
+}
+
+//GetPropertyContext get a property, aborting when ctx is done
+func (d *Device1) GetPropertyContext(ctx context.Context, name string) (dbus.Variant, error) {
+	return d.client.GetPropertyContext(ctx, name)
+}
+
+//SetProperty set a property
+func (d *Device1) SetProperty(name string, value interface{}) error {
+	return d.SetPropertyContext(context.Background(), name, value)
+}
+
+//SetPropertyContext set a property, aborting when ctx is done
+func (d *Device1) SetPropertyContext(ctx context.Context, name string, value interface{}) error {
+	return d.client.SetPropertyContext(ctx, name, value)
+}
+
+//SetTrusted trust the device, allowing its connections without authorization
+func (d *Device1) SetTrusted(trusted bool) error {
+	return d.SetTrustedContext(context.Background(), trusted)
+}
+
+//SetTrustedContext set Trusted, aborting when ctx is done
+func (d *Device1) SetTrustedContext(ctx context.Context, trusted bool) error {
+	return d.SetPropertyContext(ctx, "Trusted", trusted)
+}
+
+//SetBlocked block the device, its connections are rejected
+func (d *Device1) SetBlocked(blocked bool) error {
+	return d.SetBlockedContext(context.Background(), blocked)
+}
+
+//SetBlockedContext set Blocked, aborting when ctx is done
+func (d *Device1) SetBlockedContext(ctx context.Context, blocked bool) error {
+	return d.SetPropertyContext(ctx, "Blocked", blocked)
+}
+
+//SetAlias set the name of the device, an empty alias restores the remote name
+func (d *Device1) SetAlias(alias string) error {
+	return d.SetAliasContext(context.Background(), alias)
+}
+
+//SetAliasContext set Alias, aborting when ctx is done
+func (d *Device1) SetAliasContext(ctx context.Context, alias string) error {
+	return d.SetPropertyContext(ctx, "Alias", alias)
+}
+
+//SetWakeAllowed allow the device to wake up the host
+func (d *Device1) SetWakeAllowed(allowed bool) error {
+	return d.SetWakeAllowedContext(context.Background(), allowed)
+}
+
+//SetWakeAllowedContext set WakeAllowed, aborting when ctx is done
+func (d *Device1) SetWakeAllowedContext(ctx context.Context, allowed bool) error {
+	return d.SetPropertyContext(ctx, "WakeAllowed", allowed)
+}
+
+//CancelPairing stop the pairing process
+func (d *Device1) CancelPairing() error {
+	return d.CancelPairingContext(context.Background())
+}
+
+//CancelPairingContext stop the pairing process, aborting when ctx is done
+func (d *Device1) CancelPairingContext(ctx context.Context) error {
+	return d.client.CallContext(ctx, "CancelPairing", 0).Store()
 }
 
 //CancelParing stop the pairing process
+//
+//Deprecated: use CancelPairing
 func (d *Device1) CancelParing() error {
-	return d.client.Call("CancelParing", 0).Store()
+	return d.CancelPairing()
+}
+
+//CancelParingContext stop the pairing process, aborting when ctx is done
+//
+//Deprecated: use CancelPairingContext
+func (d *Device1) CancelParingContext(ctx context.Context) error {
+	return d.CancelPairingContext(ctx)
+}
+
+//GetServiceRecords return the raw SDP records of a BR/EDR device (experimental)
+func (d *Device1) GetServiceRecords() ([][]byte, error) {
+	return d.GetServiceRecordsContext(context.Background())
+}
+
+//GetServiceRecordsContext return the raw SDP records, aborting when ctx is done
+func (d *Device1) GetServiceRecordsContext(ctx context.Context) ([][]byte, error) {
+	var records [][]byte
+	err := d.client.CallContext(ctx, "GetServiceRecords", 0).Store(&records)
+	return records, err
 }
 
 //Connect to the device
 func (d *Device1) Connect() error {
-	return d.client.Call("Connect", 0).Store()
+	return d.ConnectContext(context.Background())
+}
+
+//ConnectContext connect to the device, aborting when ctx is done
+func (d *Device1) ConnectContext(ctx context.Context) error {
+	return d.client.CallContext(ctx, "Connect", 0).Store()
 }
 
 //ConnectProfile connect to the specific profile
 func (d *Device1) ConnectProfile(uuid string) error {
-	return d.client.Call("ConnectProfile", 0, uuid).Store()
+	return d.ConnectProfileContext(context.Background(), uuid)
+}
+
+//ConnectProfileContext connect to the specific profile, aborting when ctx is done
+func (d *Device1) ConnectProfileContext(ctx context.Context, uuid string) error {
+	return d.client.CallContext(ctx, "ConnectProfile", 0, uuid).Store()
 }
 
 //Disconnect from the device
 func (d *Device1) Disconnect() error {
-	return d.client.Call("Disconnect", 0).Store()
+	return d.DisconnectContext(context.Background())
+}
+
+//DisconnectContext disconnect from the device, aborting when ctx is done
+func (d *Device1) DisconnectContext(ctx context.Context) error {
+	return d.client.CallContext(ctx, "Disconnect", 0).Store()
 }
 
 //DisconnectProfile from the device
 func (d *Device1) DisconnectProfile(uuid string) error {
-	return d.client.Call("DisconnectProfile", 0, uuid).Store()
+	return d.DisconnectProfileContext(context.Background(), uuid)
+}
+
+//DisconnectProfileContext disconnect a profile from the device, aborting when ctx is done
+func (d *Device1) DisconnectProfileContext(ctx context.Context, uuid string) error {
+	return d.client.CallContext(ctx, "DisconnectProfile", 0, uuid).Store()
 }
 
 //Pair with the device
 func (d *Device1) Pair() error {
-	return d.client.Call("Pair", 0).Store()
+	return d.PairContext(context.Background())
+}
+
+//PairContext pair with the device, aborting when ctx is done
+func (d *Device1) PairContext(ctx context.Context) error {
+	return d.client.CallContext(ctx, "Pair", 0).Store()
 }
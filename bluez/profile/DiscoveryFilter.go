@@ -0,0 +1,56 @@
+package profile
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/util"
+)
+
+// The transports of a discovery
+const (
+	DiscoveryTransportAuto  = "auto"
+	DiscoveryTransportLE    = "le"
+	DiscoveryTransportBREDR = "bredr"
+)
+
+// DiscoveryFilter the filter of Adapter1.SetDiscoveryFilter, the empty fields are not sent
+type DiscoveryFilter struct {
+	// UUIDs report only the devices advertising one of the service UUIDs
+	UUIDs []string `dbus:"UUIDs,omitempty"`
+	// RSSI report only the devices with a stronger signal, in dBm, eg. -70
+	RSSI int16 `dbus:"RSSI,omitempty"`
+	// Pathloss report only the devices with a lower path loss, in dB. It cannot be used with RSSI
+	Pathloss uint16 `dbus:"Pathloss,omitempty"`
+	// Transport is DiscoveryTransportAuto, DiscoveryTransportLE or DiscoveryTransportBREDR
+	Transport string `dbus:"Transport,omitempty"`
+	// DuplicateData report every advertisement instead of the changes only, bluez default is true
+	DuplicateData *bool `dbus:"DuplicateData,omitempty"`
+	// Discoverable report only the discoverable devices
+	Discoverable bool `dbus:"Discoverable,omitempty"`
+	// Pattern report only the devices whose address or name starts with the pattern
+	Pattern string `dbus:"Pattern,omitempty"`
+}
+
+// Validate check the transport and the exclusive fields
+func (f *DiscoveryFilter) Validate() error {
+	if f.RSSI != 0 && f.Pathloss != 0 {
+		return errors.New("RSSI and Pathloss cannot be used together")
+	}
+	switch f.Transport {
+	case "", DiscoveryTransportAuto, DiscoveryTransportLE, DiscoveryTransportBREDR:
+	default:
+		return fmt.Errorf("Invalid discovery transport %q", f.Transport)
+	}
+	return nil
+}
+
+// ToMap return the filter as expected by SetDiscoveryFilter
+func (f *DiscoveryFilter) ToMap() (map[string]dbus.Variant, error) {
+	err := f.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return util.StructToMap(f)
+}
@@ -0,0 +1,305 @@
+package profile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"syscall"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/bluez"
+)
+
+//AcquireWrite acquire a socket to write without response, return its file descriptor and the MTU.
+// bluez releases the acquisition when the socket is closed
+func (d *GattCharacteristic1) AcquireWrite(options *AcquireOptions) (dbus.UnixFD, uint16, error) {
+	return d.AcquireWriteContext(context.Background(), options)
+}
+
+//AcquireWriteContext acquire a socket to write without response, aborting when ctx is done
+func (d *GattCharacteristic1) AcquireWriteContext(ctx context.Context, options *AcquireOptions) (dbus.UnixFD, uint16, error) {
+	return d.acquire(ctx, "AcquireWrite", options)
+}
+
+//AcquireNotify acquire a socket receiving the notifications, return its file descriptor and the MTU.
+// bluez releases the acquisition when the socket is closed
+func (d *GattCharacteristic1) AcquireNotify(options *AcquireOptions) (dbus.UnixFD, uint16, error) {
+	return d.AcquireNotifyContext(context.Background(), options)
+}
+
+//AcquireNotifyContext acquire a socket receiving the notifications, aborting when ctx is done
+func (d *GattCharacteristic1) AcquireNotifyContext(ctx context.Context, options *AcquireOptions) (dbus.UnixFD, uint16, error) {
+	return d.acquire(ctx, "AcquireNotify", options)
+}
+
+func (d *GattCharacteristic1) acquire(ctx context.Context, method string, options *AcquireOptions) (dbus.UnixFD, uint16, error) {
+	opts, err := options.ToMap()
+	if err != nil {
+		return -1, 0, err
+	}
+	var fd dbus.UnixFD
+	var mtu uint16
+	err = d.client.CallContext(ctx, method, 0, opts).Store(&fd, &mtu)
+	if err != nil {
+		return -1, 0, err
+	}
+	return fd, mtu, nil
+}
+
+// acquireUnsupported check if an acquisition failed because the daemon or the characteristic
+// does not support it, the D-Bus methods are used instead
+func acquireUnsupported(err error) bool {
+	return errors.Is(err, bluez.ErrUnknownMethod) ||
+		errors.Is(err, bluez.ErrNotSupported) ||
+		errors.Is(err, bluez.ErrNotPermitted)
+}
+
+// newSocketFile wrap an acquired socket, in non blocking mode so that Close interrupts a pending Read
+func newSocketFile(fd dbus.UnixFD, name string) (*os.File, error) {
+	err := syscall.SetNonblock(int(fd), true)
+	if err != nil {
+		syscall.Close(int(fd))
+		return nil, err
+	}
+	return os.NewFile(uintptr(fd), name), nil
+}
+
+//NewWriter return a writer sending packets without response through an acquired socket.
+// It falls back to WriteValue when the socket cannot be acquired, eg. on older daemons
+func (d *GattCharacteristic1) NewWriter() (*GattWriter, error) {
+	return d.NewWriterContext(context.Background())
+}
+
+//NewWriterContext return a writer on the characteristic, aborting when ctx is done
+func (d *GattCharacteristic1) NewWriterContext(ctx context.Context) (*GattWriter, error) {
+
+	w := &GattWriter{char: d}
+
+	fd, mtu, err := d.AcquireWriteContext(ctx, nil)
+	if err == nil {
+		w.file, err = newSocketFile(fd, d.client.Config.Path)
+		if err != nil {
+			return nil, err
+		}
+		w.mtu = mtu
+		return w, nil
+	}
+	if !acquireUnsupported(err) {
+		return nil, err
+	}
+
+	w.mtu, err = d.GetMTUContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	w.writeType = WriteTypeRequest
+	for _, flag := range d.Properties.Flags {
+		if flag == "write-without-response" {
+			w.writeType = WriteTypeCommand
+		}
+	}
+	return w, nil
+}
+
+// GattWriter write to a characteristic, each Write is split in packets fitting the MTU
+type GattWriter struct {
+	char      *GattCharacteristic1
+	file      *os.File
+	mtu       uint16
+	writeType string
+}
+
+// Acquired return true if the packets are written to an acquired socket
+func (w *GattWriter) Acquired() bool {
+	return w.file != nil
+}
+
+// MTU return the MTU of the link
+func (w *GattWriter) MTU() uint16 {
+	return w.mtu
+}
+
+// Write send b, in several packets if it is larger than the MTU. Without acquired socket
+// nor write-without-response, b is written as consecutive values of at most 512 bytes
+func (w *GattWriter) Write(b []byte) (int, error) {
+
+	if w.file == nil {
+		written := 0
+		for _, value := range chunks(b, maxAttributeValueSize) {
+			n := 0
+			err := w.char.WriteChunked(value, &ChunkedWriteOptions{
+				Type: w.writeType,
+				MTU:  w.mtu,
+				Progress: func(done, total int) {
+					n = done
+				},
+			})
+			written += n
+			if err != nil {
+				return written, err
+			}
+		}
+		return written, nil
+	}
+
+	written := 0
+	for _, chunk := range chunks(b, ChunkSize(w.mtu, WriteTypeCommand)) {
+		n, err := w.file.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// Close release the acquired socket
+func (w *GattWriter) Close() error {
+	if w.file == nil {
+		return nil
+	}
+	return w.file.Close()
+}
+
+//NewNotifyReader return a reader of the notifications received through an acquired socket.
+// It falls back to StartNotify and the PropertiesChanged signals of Value when the socket
+// cannot be acquired
+func (d *GattCharacteristic1) NewNotifyReader() (*GattNotifyReader, error) {
+	return d.NewNotifyReaderContext(context.Background())
+}
+
+//NewNotifyReaderContext return a reader of the notifications, aborting when ctx is done
+func (d *GattCharacteristic1) NewNotifyReaderContext(ctx context.Context) (*GattNotifyReader, error) {
+
+	r := &GattNotifyReader{char: d}
+
+	fd, mtu, err := d.AcquireNotifyContext(ctx, nil)
+	if err == nil {
+		r.file, err = newSocketFile(fd, d.client.Config.Path)
+		if err != nil {
+			return nil, err
+		}
+		r.mtu = mtu
+		return r, nil
+	}
+	if !acquireUnsupported(err) {
+		return nil, err
+	}
+
+	r.mtu, err = d.GetMTUContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// a subscription of its own delivers every packet, the property cache only
+	// keeps the last Value
+	r.signals, err = d.client.Subscribe(bluez.SignalMatch{
+		Sender:    d.client.Config.Name,
+		Path:      dbus.ObjectPath(d.client.Config.Path),
+		Interface: bluez.PropertiesInterface,
+		Member:    "PropertiesChanged",
+		Arg0:      bluez.GattCharacteristic1Interface,
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = d.StartNotifyContext(ctx)
+	if err != nil {
+		d.client.Unsubscribe(r.signals)
+		return nil, err
+	}
+	return r, nil
+}
+
+// GattNotifyReader read the notifications of a characteristic, one packet per notification
+type GattNotifyReader struct {
+	char    *GattCharacteristic1
+	file    *os.File
+	mtu     uint16
+	signals chan *dbus.Signal
+
+	// lock guard pending, it is held by Read while waiting for a packet
+	lock    sync.Mutex
+	pending []byte
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// Acquired return true if the notifications are read from an acquired socket
+func (r *GattNotifyReader) Acquired() bool {
+	return r.file != nil
+}
+
+// MTU return the MTU of the link
+func (r *GattNotifyReader) MTU() uint16 {
+	return r.mtu
+}
+
+// ReadPacket wait for the next notification and return its value, io.EOF once closed
+func (r *GattNotifyReader) ReadPacket() ([]byte, error) {
+
+	if r.file == nil {
+		for sig := range r.signals {
+			if len(sig.Body) < 2 {
+				continue
+			}
+			changed, ok := sig.Body[1].(map[string]dbus.Variant)
+			if !ok {
+				continue
+			}
+			if value, ok := changed["Value"]; ok {
+				b, ok := value.Value().([]byte)
+				if !ok {
+					return nil, fmt.Errorf("Unexpected notification value %v", value)
+				}
+				return b, nil
+			}
+		}
+		return nil, io.EOF
+	}
+
+	b := make([]byte, r.mtu)
+	n, err := r.file.Read(b)
+	if err != nil {
+		if errors.Is(err, os.ErrClosed) {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+	return b[:n], nil
+}
+
+// Read fill p with the notifications, a packet larger than p is returned over several reads
+func (r *GattNotifyReader) Read(p []byte) (int, error) {
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if len(r.pending) == 0 {
+		b, err := r.ReadPacket()
+		if err != nil {
+			return 0, err
+		}
+		r.pending = b
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+// Close release the acquired socket or stop the notifications
+func (r *GattNotifyReader) Close() error {
+
+	r.closeOnce.Do(func() {
+		if r.file != nil {
+			r.closeErr = r.file.Close()
+			return
+		}
+		r.char.client.Unsubscribe(r.signals)
+		r.closeErr = r.char.StopNotify()
+	})
+	return r.closeErr
+}
@@ -0,0 +1,133 @@
+package profile
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/bluez"
+)
+
+// charCall record a call of the test characteristic
+func charCall(member string, err error, args ...interface{}) recorded {
+	return recorded{
+		op: &bluez.Operation{
+			Kind:      bluez.OperationCall,
+			Path:      testCharPath,
+			Interface: bluez.GattCharacteristic1Interface,
+			Member:    member,
+			Args:      args,
+		},
+		err: err,
+	}
+}
+
+// getMTU record a read of the MTU property
+func getMTU(mtu uint16) recorded {
+	return recorded{op: &bluez.Operation{
+		Kind:      bluez.OperationGetProperty,
+		Path:      testCharPath,
+		Interface: bluez.GattCharacteristic1Interface,
+		Member:    "MTU",
+		Reply:     []interface{}{dbus.MakeVariant(mtu)},
+	}}
+}
+
+// valueChanged record a PropertiesChanged signal of the characteristic
+func valueChanged(changed map[string]dbus.Variant) recorded {
+	return recorded{op: &bluez.Operation{
+		Kind:        bluez.OperationSignal,
+		Destination: "org.bluez",
+		Path:        testCharPath,
+		Interface:   bluez.PropertiesInterface,
+		Member:      "PropertiesChanged",
+		Reply:       []interface{}{bluez.GattCharacteristic1Interface, changed, []string{}},
+	}}
+}
+
+func TestGattWriterFallback(t *testing.T) {
+
+	unknown := &bluez.Error{Name: "org.freedesktop.DBus.Error.UnknownMethod", Message: "Unknown method AcquireWrite"}
+	value := testValue(30)
+	replayer := newTestReplayer(t,
+		charCall("AcquireWrite", unknown, map[string]dbus.Variant{}),
+		getMTU(DefaultMTU),
+		writeValue(value[:20], 0, WriteTypeCommand, nil),
+		writeValue(value[20:], 0, WriteTypeCommand, nil),
+	)
+	defer replayer.Close()
+	char := newTestCharacteristic(testCharPath, withBackend(replayer))
+	defer char.Close()
+	char.Properties.Flags = []string{"read", "write-without-response"}
+
+	// the daemon cannot acquire the socket, the packets are written with WriteValue
+	w, err := char.NewWriter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if w.Acquired() || w.MTU() != DefaultMTU {
+		t.Fatalf("Expected the fallback with the default MTU, got %t and %d", w.Acquired(), w.MTU())
+	}
+	n, err := w.Write(value)
+	if err != nil || n != len(value) {
+		t.Fatalf("Expected %d bytes written, got %d: %v", len(value), n, err)
+	}
+
+	// other acquisition errors are returned
+	replayer = newTestReplayer(t, charCall("AcquireWrite", &bluez.Error{Name: "org.bluez.Error.Failed", Message: "Not connected"}, map[string]dbus.Variant{}))
+	defer replayer.Close()
+	char = newTestCharacteristic(testCharPath, withBackend(replayer))
+	defer char.Close()
+	if _, err := char.NewWriter(); err == nil {
+		t.Fatal("Expected the acquisition error")
+	}
+}
+
+func TestGattNotifyReaderFallback(t *testing.T) {
+
+	ops := []recorded{
+		charCall("AcquireNotify", &bluez.Error{Name: "org.bluez.Error.NotSupported", Message: "Not supported"}, map[string]dbus.Variant{}),
+		getMTU(DefaultMTU),
+		charCall("StartNotify", nil),
+		valueChanged(map[string]dbus.Variant{"Notifying": dbus.MakeVariant(true)}),
+	}
+	// more packets than a slow subscriber queue, none is merged
+	count := 40
+	for i := 0; i < count; i++ {
+		ops = append(ops, valueChanged(map[string]dbus.Variant{"Value": dbus.MakeVariant([]byte{byte(i), 1})}))
+	}
+	ops = append(ops, charCall("StopNotify", nil))
+	replayer := newTestReplayer(t, ops...)
+	defer replayer.Close()
+	char := newTestCharacteristic(testCharPath, withBackend(replayer))
+	defer char.Close()
+
+	r, err := char.NewNotifyReader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Acquired() || r.MTU() != DefaultMTU {
+		t.Fatalf("Expected the fallback with the default MTU, got %t and %d", r.Acquired(), r.MTU())
+	}
+
+	// the signals are queued while the reader is slow
+	time.Sleep(20 * time.Millisecond)
+	for i := 0; i < count; i++ {
+		b, err := r.ReadPacket()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(b) != 2 || b[0] != byte(i) {
+			t.Fatalf("Expected packet %d, got %v", i, b)
+		}
+	}
+
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.ReadPacket(); err != io.EOF {
+		t.Fatalf("Expected io.EOF once closed, got %v", err)
+	}
+}
@@ -1,21 +1,25 @@
 package profile
 
 import (
+	"context"
+	"sync"
+
 	"github.com/godbus/dbus"
 	"github.com/saurabh-newera/BLE/bluez"
 	//"github.com/op/go-logging"
 )
 //var log = logging.MustGetLogger("examples")
 // NewGattCharacteristic1 create a new GattCharacteristic1 client
-func NewGattCharacteristic1(path string) *GattCharacteristic1 {
+func NewGattCharacteristic1(path string, opts ...Option) *GattCharacteristic1 {
 	g := new(GattCharacteristic1)
-	g.client = bluez.NewClient(
+	g.client = newClient(
 		&bluez.Config{
 			Name:  "org.bluez",
 			Iface: bluez.GattCharacteristic1Interface,
 			Path:  path,
 			Bus:   bluez.SystemBus,
 		},
+		opts,
 	)
 
 	g.Properties = new(GattCharacteristic1Properties)
@@ -42,10 +46,17 @@ type GattCharacteristic1Properties struct {
 	Notifying bool
 	Service   dbus.ObjectPath
 	UUID      string
+	// MTU negotiated with the device, not available before bluez 5.62
+	MTU uint16
+	// WriteAcquired is true while a socket returned by AcquireWrite is open
+	WriteAcquired bool
+	// NotifyAcquired is true while a socket returned by AcquireNotify is open
+	NotifyAcquired bool
 }
 
-// Close the connection
+// Close the connection, the notifications are no longer restored after a bluetoothd restart
 func (d *GattCharacteristic1) Close() {
+	untrackNotify(d)
 	d.client.Disconnect()
 }
 
@@ -63,21 +74,40 @@ func (d *GattCharacteristic1) Register() (chan *dbus.Signal, error) {
 
 //Unregister for changes signalling
 func (d *GattCharacteristic1) Unregister() error {
-	if d.channel != nil {
-		close(d.channel)
-	}
+	// the channel is closed by the client
+	d.channel = nil
 	return d.client.Unregister(d.client.Config.Path, bluez.PropertiesInterface)
 }
 
 //GetProperties load all available properties
 func (d *GattCharacteristic1) GetProperties() (*GattCharacteristic1Properties, error) {
-	err := d.client.GetProperties(d.Properties)
+	return d.GetPropertiesContext(context.Background())
+}
+
+//GetPropertiesContext load all available properties, aborting when ctx is done
+func (d *GattCharacteristic1) GetPropertiesContext(ctx context.Context) (*GattCharacteristic1Properties, error) {
+	err := d.client.GetPropertiesContext(ctx, d.Properties)
 	return d.Properties, err
 }
 
+//WatchProperties return a channel receiving the property changes, GetProperties updates Properties
+func (d *GattCharacteristic1) WatchProperties() (chan *bluez.PropertyChange, error) {
+	return d.client.WatchProperties()
+}
+
+//UnwatchProperties stop the delivery of property changes to channel and close it
+func (d *GattCharacteristic1) UnwatchProperties(channel chan *bluez.PropertyChange) {
+	d.client.UnwatchProperties(channel)
+}
+
 //GetProperty load a single property
 func (d *GattCharacteristic1) GetProperty(name string) (interface{}, error) {
-	val, err := d.client.GetProperty(name)
+	return d.GetPropertyContext(context.Background(), name)
+}
+
+//GetPropertyContext load a single property, aborting when ctx is done
+func (d *GattCharacteristic1) GetPropertyContext(ctx context.Context, name string) (interface{}, error) {
+	val, err := d.client.GetPropertyContext(ctx, name)
 	if err != nil {
 		return nil, err
 	}
@@ -86,23 +116,186 @@ func (d *GattCharacteristic1) GetProperty(name string) (interface{}, error) {
 
 //ReadValue read a value from a characteristic
 func (d *GattCharacteristic1) ReadValue(options map[string]dbus.Variant) ([]byte, error) {
+	return d.ReadValueContext(context.Background(), options)
+}
+
+//ReadValueContext read a value from a characteristic, aborting when ctx is done
+func (d *GattCharacteristic1) ReadValueContext(ctx context.Context, options map[string]dbus.Variant) ([]byte, error) {
 	var b []byte
-	err := d.client.Call("ReadValue", 0, options).Store(&b)
+	err := d.client.CallContext(ctx, "ReadValue", 0, options).Store(&b)
 	return b, err
 }
 
 //WriteValue write a value to a characteristic
 func (d *GattCharacteristic1) WriteValue(b []byte, options map[string]dbus.Variant) error {
-	err := d.client.Call("WriteValue", 0, b, options).Store()
+	return d.WriteValueContext(context.Background(), b, options)
+}
+
+//WriteValueContext write a value to a characteristic, aborting when ctx is done
+func (d *GattCharacteristic1) WriteValueContext(ctx context.Context, b []byte, options map[string]dbus.Variant) error {
+	err := d.client.CallContext(ctx, "WriteValue", 0, b, options).Store()
 	return err
 }
 
+//Read read a value from a characteristic, options may be nil
+func (d *GattCharacteristic1) Read(options *ReadOptions) ([]byte, error) {
+	return d.ReadContext(context.Background(), options)
+}
+
+//ReadContext read a value from a characteristic, aborting when ctx is done
+func (d *GattCharacteristic1) ReadContext(ctx context.Context, options *ReadOptions) ([]byte, error) {
+	opts, err := options.ToMap()
+	if err != nil {
+		return nil, err
+	}
+	return d.ReadValueContext(ctx, opts)
+}
+
+//Write write a value to a characteristic, options may be nil
+func (d *GattCharacteristic1) Write(b []byte, options *WriteOptions) error {
+	return d.WriteContext(context.Background(), b, options)
+}
+
+//WriteContext write a value to a characteristic, aborting when ctx is done
+func (d *GattCharacteristic1) WriteContext(ctx context.Context, b []byte, options *WriteOptions) error {
+	opts, err := options.ToMap()
+	if err != nil {
+		return err
+	}
+	return d.WriteValueContext(ctx, b, opts)
+}
+
+//WriteWithoutResponse write a value with an ATT write command, the characteristic must
+//have the write-without-response flag
+func (d *GattCharacteristic1) WriteWithoutResponse(b []byte) error {
+	return d.WriteWithoutResponseContext(context.Background(), b)
+}
+
+//WriteWithoutResponseContext write a value without response, aborting when ctx is done
+func (d *GattCharacteristic1) WriteWithoutResponseContext(ctx context.Context, b []byte) error {
+	return d.WriteContext(ctx, b, &WriteOptions{Type: WriteTypeCommand})
+}
+
 //StartNotify start notifications
 func (d *GattCharacteristic1) StartNotify() error {
-	return d.client.Call("StartNotify", 0).Store()
+	return d.StartNotifyContext(context.Background())
+}
+
+//StartNotifyContext start notifications, aborting when ctx is done
+func (d *GattCharacteristic1) StartNotifyContext(ctx context.Context) error {
+	err := d.client.CallContext(ctx, "StartNotify", 0).Store()
+	if err != nil {
+		return err
+	}
+	trackNotify(d)
+	return nil
 }
 
 //StopNotify stop notifications
 func (d *GattCharacteristic1) StopNotify() error {
-	return d.client.Call("StopNotify", 0).Store()
+	return d.StopNotifyContext(context.Background())
+}
+
+//StopNotifyContext stop notifications, aborting when ctx is done
+func (d *GattCharacteristic1) StopNotifyContext(ctx context.Context) error {
+	untrackNotify(d)
+	return d.client.CallContext(ctx, "StopNotify", 0).Store()
+}
+
+// notifyState track a characteristic with notifications enabled
+type notifyState struct {
+	char *GattCharacteristic1
+	// suspended is true when notifications have been lost, eg. on bluetoothd restart
+	suspended bool
+	// restoring is true while StartNotify is called again, so a single caller restores it
+	restoring bool
+}
+
+var notifyLock sync.Mutex
+var notifying = make(map[string]*notifyState)
+
+// notifyEpoch count the calls to SuspendNotifications, a restore started before the
+// last suspend leaves the state suspended
+var notifyEpoch int
+
+func trackNotify(d *GattCharacteristic1) {
+	notifyLock.Lock()
+	defer notifyLock.Unlock()
+	notifying[d.client.Config.Path] = &notifyState{char: d}
+}
+
+// untrackNotify forget a characteristic, unless another client tracks the same path
+func untrackNotify(d *GattCharacteristic1) {
+	notifyLock.Lock()
+	defer notifyLock.Unlock()
+	if state, ok := notifying[d.client.Config.Path]; ok && state.char == d {
+		delete(notifying, d.client.Config.Path)
+	}
+}
+
+// isTracked check if a state is still in the registry, eg. it has not been closed
+func isTracked(state *notifyState) bool {
+	notifyLock.Lock()
+	defer notifyLock.Unlock()
+	return notifying[state.char.client.Config.Path] == state
+}
+
+//SuspendNotifications mark the enabled notifications as lost, eg. when bluetoothd stops
+func SuspendNotifications() {
+	notifyLock.Lock()
+	defer notifyLock.Unlock()
+	notifyEpoch++
+	for _, state := range notifying {
+		state.suspended = true
+	}
+}
+
+//RestoreNotifications start again the suspended notifications of the characteristics at paths,
+// or all the suspended ones if no path is given. Characteristics that cannot be restored yet
+// (eg. the device is not connected again) stay suspended and the last error is returned.
+// Characteristics being restored by a concurrent call are skipped
+func RestoreNotifications(paths ...string) error {
+
+	notifyLock.Lock()
+	var pending []*notifyState
+	if len(paths) == 0 {
+		for _, state := range notifying {
+			pending = append(pending, state)
+		}
+	} else {
+		for _, path := range paths {
+			if state, ok := notifying[path]; ok {
+				pending = append(pending, state)
+			}
+		}
+	}
+	var restoring []*notifyState
+	for _, state := range pending {
+		if state.suspended && !state.restoring {
+			state.restoring = true
+			restoring = append(restoring, state)
+		}
+	}
+	epoch := notifyEpoch
+	notifyLock.Unlock()
+
+	var lastErr error
+	for _, state := range restoring {
+		var err error
+		// closed or stopped meanwhile
+		if isTracked(state) {
+			err = state.char.client.Call("StartNotify", 0).Store()
+		}
+		notifyLock.Lock()
+		state.restoring = false
+		if err == nil && epoch == notifyEpoch {
+			state.suspended = false
+		}
+		notifyLock.Unlock()
+		if err != nil {
+			lastErr = err
+		}
+	}
+
+	return lastErr
 }
@@ -0,0 +1,132 @@
+package profile
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/bluez"
+)
+
+func newTestCharacteristic(path string, opts ...Option) *GattCharacteristic1 {
+	config := &bluez.Config{
+		Name:  "org.bluez",
+		Iface: bluez.GattCharacteristic1Interface,
+		Path:  path,
+		Bus:   bluez.SystemBus,
+	}
+	return &GattCharacteristic1{
+		client:     newClient(config, opts),
+		Properties: new(GattCharacteristic1Properties),
+	}
+}
+
+func TestUntrackNotifyOnClose(t *testing.T) {
+
+	path := "/org/bluez/hci0/dev_AA/service1/char1"
+	first := newTestCharacteristic(path)
+	trackNotify(first)
+
+	// a second client on the same path replaces the first one
+	second := newTestCharacteristic(path)
+	trackNotify(second)
+	first.Close()
+	if _, ok := notifying[path]; !ok {
+		t.Fatal("Closing a replaced client should not untrack the path")
+	}
+
+	second.Close()
+	if _, ok := notifying[path]; ok {
+		t.Fatal("Expected the closed characteristic to be untracked")
+	}
+
+	// nothing is left to restore
+	SuspendNotifications()
+	if err := RestoreNotifications(path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRestoreNotificationsOnce(t *testing.T) {
+
+	path := "/org/bluez/hci0/dev_AA/service1/char2"
+	replayer := newTestReplayer(t, recorded{op: &bluez.Operation{
+		Kind:      bluez.OperationCall,
+		Path:      dbus.ObjectPath(path),
+		Interface: bluez.GattCharacteristic1Interface,
+		Member:    "StartNotify",
+	}})
+	defer replayer.Close()
+
+	// the first StartNotify waits for release, fail makes the next ones fail
+	var calls int32
+	var fail int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	char := newTestCharacteristic(path, withBackend(replayer), withInterceptor(
+		func(ctx context.Context, op *bluez.Operation, next bluez.Handler) error {
+			if op.Member != "StartNotify" {
+				return next(ctx, op)
+			}
+			if atomic.AddInt32(&calls, 1) == 1 {
+				close(started)
+				<-release
+			}
+			if atomic.LoadInt32(&fail) == 1 {
+				return errors.New("Not connected")
+			}
+			return next(ctx, op)
+		}))
+	defer char.Close()
+	trackNotify(char)
+	SuspendNotifications()
+
+	restored := make(chan error, 1)
+	go func() {
+		restored <- RestoreNotifications(path)
+	}()
+	<-started
+
+	// bluetoothd is back and the characteristic is added again meanwhile
+	if err := RestoreNotifications(); err != nil {
+		t.Fatal(err)
+	}
+	if err := RestoreNotifications(path); err != nil {
+		t.Fatal(err)
+	}
+	close(release)
+	select {
+	case err := <-restored:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Restore not completed")
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Fatalf("Expected a single StartNotify, got %d", n)
+	}
+
+	// a failed restore is attempted again
+	SuspendNotifications()
+	atomic.StoreInt32(&fail, 1)
+	if err := RestoreNotifications(path); err == nil {
+		t.Fatal("Expected the restore to fail")
+	}
+	atomic.StoreInt32(&fail, 0)
+	if err := RestoreNotifications(path); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 3 {
+		t.Fatalf("Expected the failed restore to be retried, got %d calls", n)
+	}
+	notifyLock.Lock()
+	suspended := notifying[path].suspended
+	notifyLock.Unlock()
+	if suspended {
+		t.Fatal("Expected the notifications to be restored")
+	}
+}
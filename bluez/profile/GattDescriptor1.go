@@ -1,20 +1,23 @@
 package profile
 
 import (
+	"context"
+
 	"github.com/godbus/dbus"
 	"github.com/saurabh-newera/BLE/bluez"
 )
 
 // NewGattDescriptor1 create a new GattDescriptor1 client
-func NewGattDescriptor1(path string) *GattDescriptor1 {
+func NewGattDescriptor1(path string, opts ...Option) *GattDescriptor1 {
 	a := new(GattDescriptor1)
-	a.client = bluez.NewClient(
+	a.client = newClient(
 		&bluez.Config{
 			Name:  "org.bluez",
 			Iface: bluez.GattDescriptor1Interface,
 			Path:  path,
 			Bus:   bluez.SystemBus,
 		},
+		opts,
 	)
 	a.Properties = new(GattDescriptor1Properties)
 	a.GetProperties()
@@ -51,19 +54,72 @@ func (d *GattDescriptor1) Unregister() error {
 
 //GetProperties load all available properties
 func (d *GattDescriptor1) GetProperties() (*GattDescriptor1Properties, error) {
-	err := d.client.GetProperties(d.Properties)
+	return d.GetPropertiesContext(context.Background())
+}
+
+//GetPropertiesContext load all available properties, aborting when ctx is done
+func (d *GattDescriptor1) GetPropertiesContext(ctx context.Context) (*GattDescriptor1Properties, error) {
+	err := d.client.GetPropertiesContext(ctx, d.Properties)
 	return d.Properties, err
 }
 
+//WatchProperties return a channel receiving the property changes, GetProperties updates Properties
+func (d *GattDescriptor1) WatchProperties() (chan *bluez.PropertyChange, error) {
+	return d.client.WatchProperties()
+}
+
+//UnwatchProperties stop the delivery of property changes to channel and close it
+func (d *GattDescriptor1) UnwatchProperties(channel chan *bluez.PropertyChange) {
+	d.client.UnwatchProperties(channel)
+}
+
 //ReadValue read a value from a descriptor
 func (d *GattDescriptor1) ReadValue(options map[string]dbus.Variant) ([]byte, error) {
+	return d.ReadValueContext(context.Background(), options)
+}
+
+//ReadValueContext read a value from a descriptor, aborting when ctx is done
+func (d *GattDescriptor1) ReadValueContext(ctx context.Context, options map[string]dbus.Variant) ([]byte, error) {
 	var b []byte
-	err := d.client.Call("ReadValue", 0, options).Store(&b)
+	err := d.client.CallContext(ctx, "ReadValue", 0, options).Store(&b)
 	return b, err
 }
 
 //WriteValue write a value to a characteristic
 func (d *GattDescriptor1) WriteValue(b []byte, options map[string]dbus.Variant) error {
-	err := d.client.Call("WriteValue", 0, b, options).Store()
+	return d.WriteValueContext(context.Background(), b, options)
+}
+
+//WriteValueContext write a value to a descriptor, aborting when ctx is done
+func (d *GattDescriptor1) WriteValueContext(ctx context.Context, b []byte, options map[string]dbus.Variant) error {
+	err := d.client.CallContext(ctx, "WriteValue", 0, b, options).Store()
 	return err
 }
+
+//Read read a value from a descriptor, options may be nil
+func (d *GattDescriptor1) Read(options *ReadOptions) ([]byte, error) {
+	return d.ReadContext(context.Background(), options)
+}
+
+//ReadContext read a value from a descriptor, aborting when ctx is done
+func (d *GattDescriptor1) ReadContext(ctx context.Context, options *ReadOptions) ([]byte, error) {
+	opts, err := options.ToMap()
+	if err != nil {
+		return nil, err
+	}
+	return d.ReadValueContext(ctx, opts)
+}
+
+//Write write a value to a descriptor, options may be nil
+func (d *GattDescriptor1) Write(b []byte, options *WriteOptions) error {
+	return d.WriteContext(context.Background(), b, options)
+}
+
+//WriteContext write a value to a descriptor, aborting when ctx is done
+func (d *GattDescriptor1) WriteContext(ctx context.Context, b []byte, options *WriteOptions) error {
+	opts, err := options.ToMap()
+	if err != nil {
+		return err
+	}
+	return d.WriteValueContext(ctx, b, opts)
+}
@@ -0,0 +1,54 @@
+package profile
+
+import (
+	"context"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/bluez"
+)
+
+// NewGattManager1 create a new GattManager1 client
+func NewGattManager1(hostID string, opts ...Option) *GattManager1 {
+	a := new(GattManager1)
+	a.client = newClient(
+		&bluez.Config{
+			Name:  "org.bluez",
+			Iface: bluez.GattManager1Interface,
+			Path:  "/org/bluez/" + hostID,
+			Bus:   bluez.SystemBus,
+		},
+		opts,
+	)
+	return a
+}
+
+// GattManager1 client
+type GattManager1 struct {
+	client *bluez.Client
+}
+
+// Close the connection
+func (a *GattManager1) Close() {
+	a.client.Disconnect()
+}
+
+//RegisterApplication register a GATT application, the object at app must implement
+//org.freedesktop.DBus.ObjectManager and list the services, characteristics and descriptors
+func (a *GattManager1) RegisterApplication(app dbus.ObjectPath, options map[string]interface{}) error {
+	return a.RegisterApplicationContext(context.Background(), app, options)
+}
+
+//RegisterApplicationContext register a GATT application, aborting when ctx is done
+func (a *GattManager1) RegisterApplicationContext(ctx context.Context, app dbus.ObjectPath, options map[string]interface{}) error {
+	return a.client.CallContext(ctx, "RegisterApplication", 0, app, options).Store()
+}
+
+//UnregisterApplication unregister a GATT application
+func (a *GattManager1) UnregisterApplication(app dbus.ObjectPath) error {
+	return a.UnregisterApplicationContext(context.Background(), app)
+}
+
+//UnregisterApplicationContext unregister a GATT application, aborting when ctx is done
+func (a *GattManager1) UnregisterApplicationContext(ctx context.Context, app dbus.ObjectPath) error {
+	return a.client.CallContext(ctx, "UnregisterApplication", 0, app).Store()
+}
@@ -0,0 +1,85 @@
+package profile
+
+import (
+	"fmt"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/util"
+)
+
+// The ATT operations of a write, see WriteOptions.Type
+const (
+	// WriteTypeCommand write without response
+	WriteTypeCommand = "command"
+	// WriteTypeRequest write with response
+	WriteTypeRequest = "request"
+	// WriteTypeReliable reliable write, the value is checked by the remote device before execution
+	WriteTypeReliable = "reliable"
+)
+
+// ReadOptions the options of a ReadValue call, the empty fields are not sent
+type ReadOptions struct {
+	// Offset in the value, for long reads
+	Offset uint16 `dbus:"offset,omitempty"`
+	// MTU of the link
+	MTU uint16 `dbus:"mtu,omitempty"`
+	// Device is the remote device, when reading a local attribute
+	Device dbus.ObjectPath `dbus:"device,omitempty"`
+	// Link type, eg. LE or BR/EDR
+	Link string `dbus:"link,omitempty"`
+}
+
+// ToMap return the options as expected by ReadValue
+func (o *ReadOptions) ToMap() (map[string]dbus.Variant, error) {
+	if o == nil {
+		return map[string]dbus.Variant{}, nil
+	}
+	return util.StructToMap(o)
+}
+
+// WriteOptions the options of a WriteValue call, the empty fields are not sent
+type WriteOptions struct {
+	// Offset in the value, for long writes
+	Offset uint16 `dbus:"offset,omitempty"`
+	// Type of the ATT operation, eg. WriteTypeCommand. bluez chooses it from the flags when empty
+	Type string `dbus:"type,omitempty"`
+	// MTU of the link
+	MTU uint16 `dbus:"mtu,omitempty"`
+	// Device is the remote device, when writing a local attribute
+	Device dbus.ObjectPath `dbus:"device,omitempty"`
+	// Link type, eg. LE or BR/EDR
+	Link string `dbus:"link,omitempty"`
+	// PrepareAuthorize only check the authorization of a prepared write
+	PrepareAuthorize bool `dbus:"prepare-authorize,omitempty"`
+}
+
+// ToMap return the options as expected by WriteValue
+func (o *WriteOptions) ToMap() (map[string]dbus.Variant, error) {
+	if o == nil {
+		return map[string]dbus.Variant{}, nil
+	}
+	switch o.Type {
+	case "", WriteTypeCommand, WriteTypeRequest, WriteTypeReliable:
+	default:
+		return nil, fmt.Errorf("Invalid write type %q", o.Type)
+	}
+	return util.StructToMap(o)
+}
+
+// AcquireOptions the options of AcquireWrite and AcquireNotify, the empty fields are not sent
+type AcquireOptions struct {
+	// MTU of the link
+	MTU uint16 `dbus:"mtu,omitempty"`
+	// Device is the remote device, when acquiring a local attribute
+	Device dbus.ObjectPath `dbus:"device,omitempty"`
+	// Link type, eg. LE or BR/EDR
+	Link string `dbus:"link,omitempty"`
+}
+
+// ToMap return the options as expected by AcquireWrite and AcquireNotify
+func (o *AcquireOptions) ToMap() (map[string]dbus.Variant, error) {
+	if o == nil {
+		return map[string]dbus.Variant{}, nil
+	}
+	return util.StructToMap(o)
+}
@@ -0,0 +1,26 @@
+package profile
+
+import (
+	"testing"
+)
+
+func TestWriteOptions(t *testing.T) {
+
+	options, err := (&WriteOptions{Type: WriteTypeCommand, Offset: 20}).ToMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(options) != 2 || options["type"].Value() != "command" || options["offset"].Value() != uint16(20) {
+		t.Fatalf("Unexpected options %v", options)
+	}
+
+	if _, err := (&WriteOptions{Type: "cmd"}).ToMap(); err == nil {
+		t.Fatal("Expected an invalid write type error")
+	}
+
+	var read *ReadOptions
+	empty, err := read.ToMap()
+	if err != nil || empty == nil || len(empty) != 0 {
+		t.Fatalf("Expected empty options, got %v %v", empty, err)
+	}
+}
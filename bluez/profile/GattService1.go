@@ -1,20 +1,23 @@
 package profile
 
 import (
+	"context"
+
 	"github.com/godbus/dbus"
 	"github.com/saurabh-newera/BLE/bluez"
 )
 
 // NewGattService1 create a new GattService1 client
-func NewGattService1(path string) *GattService1 {
+func NewGattService1(path string, opts ...Option) *GattService1 {
 	a := new(GattService1)
-	a.client = bluez.NewClient(
+	a.client = newClient(
 		&bluez.Config{
 			Name:  "org.bluez",
 			Iface: "org.bluez.GattService1",
 			Path:  path,
 			Bus:   bluez.SystemBus,
 		},
+		opts,
 	)
 	a.Properties = new(GattService1Properties)
 	a.GetProperties()
@@ -51,6 +54,21 @@ func (d *GattService1) Unregister() error {
 
 //GetProperties load all available properties
 func (d *GattService1) GetProperties() (*GattService1Properties, error) {
-	err := d.client.GetProperties(d.Properties)
+	return d.GetPropertiesContext(context.Background())
+}
+
+//GetPropertiesContext load all available properties, aborting when ctx is done
+func (d *GattService1) GetPropertiesContext(ctx context.Context) (*GattService1Properties, error) {
+	err := d.client.GetPropertiesContext(ctx, d.Properties)
 	return d.Properties, err
 }
+
+//WatchProperties return a channel receiving the property changes, GetProperties updates Properties
+func (d *GattService1) WatchProperties() (chan *bluez.PropertyChange, error) {
+	return d.client.WatchProperties()
+}
+
+//UnwatchProperties stop the delivery of property changes to channel and close it
+func (d *GattService1) UnwatchProperties(channel chan *bluez.PropertyChange) {
+	d.client.UnwatchProperties(channel)
+}
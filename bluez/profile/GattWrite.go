@@ -0,0 +1,224 @@
+package profile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/saurabh-newera/BLE/bluez"
+)
+
+// DefaultMTU the ATT MTU of a link before any exchange
+const DefaultMTU = 23
+
+const (
+	// writeHeaderSize the size of the opcode and handle of a write request or command
+	writeHeaderSize = 3
+	// prepareWriteHeaderSize the size of the opcode, handle and offset of a prepare write
+	prepareWriteHeaderSize = 5
+	// defaultRetries the number of retries of a chunk written without response
+	defaultRetries = 5
+	// maxAttributeValueSize the largest value of a GATT attribute
+	maxAttributeValueSize = 512
+	// minRetryDelay the first delay before retrying a chunk written without response
+	minRetryDelay = 5 * time.Millisecond
+)
+
+// writeBusyMessage the message of the org.bluez.Error.Failed returned when a write
+// without response cannot be queued, eg. while the link is congested
+const writeBusyMessage = "Failed to initiate write"
+
+// unknownProperty the D-Bus errors returned when reading a missing property
+var unknownProperty = map[string]bool{
+	"org.freedesktop.DBus.Error.InvalidArgs":                 true,
+	"org.freedesktop.DBus.Properties.Error.PropertyNotFound": true,
+}
+
+// WriteProgress is called after each chunk with the number of bytes written and the total size
+type WriteProgress func(written, total int)
+
+// ChunkedWriteOptions configure WriteChunked, the zero value writes with response
+// using the MTU of the characteristic
+type ChunkedWriteOptions struct {
+	// Type WriteTypeCommand streams the chunks without response, WriteTypeRequest (default)
+	// and WriteTypeReliable write each chunk at its offset
+	Type string
+	// MTU overrides the MTU of the characteristic, read from bluez when 0
+	MTU uint16
+	// Interval paces the chunks written without response, the requests are paced by their replies
+	Interval time.Duration
+	// Retries of a chunk written without response when bluez reports the queue as busy, 5 by default
+	Retries int
+	// Progress is called after each chunk
+	Progress WriteProgress
+}
+
+// ChunkSize return the payload size of a chunk for a write type and an ATT MTU
+func ChunkSize(mtu uint16, writeType string) int {
+	if mtu < DefaultMTU {
+		mtu = DefaultMTU
+	}
+	if writeType == WriteTypeCommand {
+		return int(mtu) - writeHeaderSize
+	}
+	return int(mtu) - prepareWriteHeaderSize
+}
+
+// chunks split b in slices of at most size bytes
+func chunks(b []byte, size int) [][]byte {
+	var list [][]byte
+	for len(b) > size {
+		list = append(list, b[:size])
+		b = b[size:]
+	}
+	if len(b) > 0 {
+		list = append(list, b)
+	}
+	return list
+}
+
+//GetMTU return the MTU negotiated for the characteristic, DefaultMTU if bluez does not expose it
+func (d *GattCharacteristic1) GetMTU() (uint16, error) {
+	return d.GetMTUContext(context.Background())
+}
+
+//GetMTUContext return the negotiated MTU, aborting when ctx is done
+func (d *GattCharacteristic1) GetMTUContext(ctx context.Context) (uint16, error) {
+	val, err := d.GetPropertyContext(ctx, "MTU")
+	if err != nil {
+		// older daemons do not have the property
+		var bluezErr *bluez.Error
+		if errors.As(err, &bluezErr) && unknownProperty[bluezErr.Name] {
+			return DefaultMTU, nil
+		}
+		return 0, err
+	}
+	mtu, ok := val.(uint16)
+	if !ok || mtu < DefaultMTU {
+		return DefaultMTU, nil
+	}
+	return mtu, nil
+}
+
+//WriteLong write a value larger than one ATT payload, up to 512 bytes, with offset based writes
+func (d *GattCharacteristic1) WriteLong(b []byte, progress WriteProgress) error {
+	return d.WriteLongContext(context.Background(), b, progress)
+}
+
+//WriteLongContext write a long value, aborting when ctx is done
+func (d *GattCharacteristic1) WriteLongContext(ctx context.Context, b []byte, progress WriteProgress) error {
+	return d.WriteChunkedContext(ctx, b, &ChunkedWriteOptions{
+		Type:     WriteTypeRequest,
+		Progress: progress,
+	})
+}
+
+//WriteChunked split b according to the MTU and write the chunks in order, options may be nil
+func (d *GattCharacteristic1) WriteChunked(b []byte, options *ChunkedWriteOptions) error {
+	return d.WriteChunkedContext(context.Background(), b, options)
+}
+
+//WriteChunkedContext write b in chunks, aborting when ctx is done. With a request each
+// chunk is written at its offset, up to 512 bytes. The chunks already written are not
+// rolled back on error, write b at once with Write when the value must change atomically
+func (d *GattCharacteristic1) WriteChunkedContext(ctx context.Context, b []byte, options *ChunkedWriteOptions) error {
+
+	if options == nil {
+		options = &ChunkedWriteOptions{}
+	}
+	writeType := options.Type
+	if writeType == "" {
+		writeType = WriteTypeRequest
+	}
+
+	if writeType != WriteTypeCommand && len(b) > maxAttributeValueSize {
+		return fmt.Errorf("Value of %d bytes exceeds the maximum attribute size of %d bytes", len(b), maxAttributeValueSize)
+	}
+
+	mtu := options.MTU
+	if mtu == 0 {
+		var err error
+		mtu, err = d.GetMTUContext(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	// a value fitting in one write request is sent as is
+	if writeType != WriteTypeCommand && len(b) <= ChunkSize(mtu, WriteTypeCommand) {
+		err := d.WriteContext(ctx, b, &WriteOptions{Type: writeType})
+		if err != nil {
+			return err
+		}
+		if options.Progress != nil {
+			options.Progress(len(b), len(b))
+		}
+		return nil
+	}
+
+	written := 0
+	for i, chunk := range chunks(b, ChunkSize(mtu, writeType)) {
+
+		if i > 0 && writeType == WriteTypeCommand && options.Interval > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(options.Interval):
+			}
+		}
+
+		var err error
+		if writeType == WriteTypeCommand {
+			err = d.writeCommand(ctx, chunk, options.Retries)
+		} else {
+			err = d.WriteContext(ctx, chunk, &WriteOptions{
+				Type:   writeType,
+				Offset: uint16(written),
+			})
+		}
+		if err != nil {
+			return fmt.Errorf("Write at offset %d failed: %w", written, err)
+		}
+
+		written += len(chunk)
+		if options.Progress != nil {
+			options.Progress(written, len(b))
+		}
+	}
+
+	return nil
+}
+
+// writeCommand write a chunk without response, backing off while bluez cannot queue it
+func (d *GattCharacteristic1) writeCommand(ctx context.Context, chunk []byte, retries int) error {
+
+	if retries == 0 {
+		retries = defaultRetries
+	}
+
+	delay := minRetryDelay
+	for {
+		err := d.WriteWithoutResponseContext(ctx, chunk)
+		if err == nil || !isWriteBusy(err) || retries <= 0 {
+			return err
+		}
+		retries--
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// isWriteBusy check if a write without response failed only because bluez could not queue it
+func isWriteBusy(err error) bool {
+	if errors.Is(err, bluez.ErrInProgress) {
+		return true
+	}
+	var bluezErr *bluez.Error
+	return errors.As(err, &bluezErr) && errors.Is(bluezErr, bluez.ErrFailed) && bluezErr.Message == writeBusyMessage
+}
@@ -0,0 +1,240 @@
+package profile
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/bluez"
+)
+
+const testCharPath = "/org/bluez/hci0/dev_AA/service1/char1"
+
+// writeValue record a WriteValue of value at offset
+func writeValue(value []byte, offset uint16, writeType string, err error) recorded {
+	opts := map[string]dbus.Variant{"type": dbus.MakeVariant(writeType)}
+	if offset > 0 {
+		opts["offset"] = dbus.MakeVariant(offset)
+	}
+	return recorded{
+		op: &bluez.Operation{
+			Kind:      bluez.OperationCall,
+			Path:      testCharPath,
+			Interface: bluez.GattCharacteristic1Interface,
+			Member:    "WriteValue",
+			Args:      []interface{}{value, opts},
+		},
+		err: err,
+	}
+}
+
+// countWrites count the WriteValue calls of a characteristic and their time
+func countWrites(times *[]time.Time) Option {
+	return withInterceptor(func(ctx context.Context, op *bluez.Operation, next bluez.Handler) error {
+		if op.Member == "WriteValue" {
+			*times = append(*times, time.Now())
+		}
+		return next(ctx, op)
+	})
+}
+
+func testValue(size int) []byte {
+	value := make([]byte, size)
+	for i := range value {
+		value[i] = byte(i)
+	}
+	return value
+}
+
+func TestChunks(t *testing.T) {
+
+	if size := ChunkSize(0, WriteTypeCommand); size != 20 {
+		t.Fatalf("Expected 20 bytes with the default MTU, got %d", size)
+	}
+	if size := ChunkSize(247, WriteTypeRequest); size != 242 {
+		t.Fatalf("Expected 242 bytes for a prepare write, got %d", size)
+	}
+
+	value := make([]byte, 45)
+	for i := range value {
+		value[i] = byte(i)
+	}
+
+	list := chunks(value, 20)
+	if len(list) != 3 || len(list[0]) != 20 || len(list[2]) != 5 {
+		t.Fatalf("Unexpected chunks %v", list)
+	}
+	if !bytes.Equal(bytes.Join(list, nil), value) {
+		t.Fatal("Chunks do not rebuild the value")
+	}
+
+	if list := chunks(value[:20], 20); len(list) != 1 {
+		t.Fatalf("Expected a single chunk, got %d", len(list))
+	}
+	if list := chunks(nil, 20); len(list) != 0 {
+		t.Fatalf("Expected no chunk, got %d", len(list))
+	}
+}
+
+func TestIsWriteBusy(t *testing.T) {
+	for _, c := range []struct {
+		err  error
+		busy bool
+	}{
+		{&bluez.Error{Name: "org.bluez.Error.InProgress", Err: bluez.ErrInProgress}, true},
+		{&bluez.Error{Name: "org.bluez.Error.Failed", Message: writeBusyMessage, Err: bluez.ErrFailed}, true},
+		{&bluez.Error{Name: "org.bluez.Error.Failed", Message: "Not connected", Err: bluez.ErrFailed}, false},
+		{&bluez.Error{Name: "org.bluez.Error.NotPermitted", Err: bluez.ErrNotPermitted}, false},
+	} {
+		if busy := isWriteBusy(c.err); busy != c.busy {
+			t.Fatalf("Expected busy %t for %v", c.busy, c.err)
+		}
+	}
+}
+
+func TestWriteChunked(t *testing.T) {
+
+	value := testValue(40)
+	replayer := newTestReplayer(t,
+		writeValue(value[:18], 0, WriteTypeRequest, nil),
+		writeValue(value[18:36], 18, WriteTypeRequest, nil),
+		writeValue(value[36:], 36, WriteTypeRequest, nil),
+		writeValue(value[:20], 0, WriteTypeRequest, nil),
+	)
+	defer replayer.Close()
+	char := newTestCharacteristic(testCharPath, withBackend(replayer))
+	defer char.Close()
+
+	// each chunk is written at its offset, with progress
+	var progress []int
+	err := char.WriteChunked(value, &ChunkedWriteOptions{
+		MTU: DefaultMTU,
+		Progress: func(written, total int) {
+			if total != len(value) {
+				t.Fatalf("Unexpected total %d", total)
+			}
+			progress = append(progress, written)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(progress) != 3 || progress[0] != 18 || progress[1] != 36 || progress[2] != 40 {
+		t.Fatalf("Unexpected progress %v", progress)
+	}
+
+	// a value fitting in one request is not split
+	if err := char.WriteChunked(value[:20], &ChunkedWriteOptions{MTU: DefaultMTU}); err != nil {
+		t.Fatal(err)
+	}
+	if err := char.WriteChunked(testValue(513), &ChunkedWriteOptions{MTU: DefaultMTU}); err == nil {
+		t.Fatal("Expected an error writing more than 512 bytes")
+	}
+}
+
+func TestWriteChunkedBusy(t *testing.T) {
+
+	busy := &bluez.Error{Name: "org.bluez.Error.Failed", Message: writeBusyMessage}
+	value := testValue(30)
+	replayer := newTestReplayer(t,
+		writeValue(value[:20], 0, WriteTypeCommand, busy),
+		writeValue(value[:20], 0, WriteTypeCommand, busy),
+		writeValue(value[:20], 0, WriteTypeCommand, nil),
+		writeValue(value[20:], 0, WriteTypeCommand, &bluez.Error{Name: "org.bluez.Error.Failed", Message: "Not connected"}),
+	)
+	defer replayer.Close()
+	var times []time.Time
+	char := newTestCharacteristic(testCharPath, withBackend(replayer), countWrites(&times))
+	defer char.Close()
+
+	// the busy chunk is retried, the other failures are returned at once
+	var written int
+	err := char.WriteChunked(value, &ChunkedWriteOptions{
+		Type: WriteTypeCommand,
+		MTU:  DefaultMTU,
+		Progress: func(n, total int) {
+			written = n
+		},
+	})
+	if err == nil {
+		t.Fatal("Expected the second chunk to fail")
+	}
+	if len(times) != 4 || written != 20 {
+		t.Fatalf("Expected 4 writes and 20 bytes written, got %d and %d", len(times), written)
+	}
+	if delay := times[2].Sub(times[1]); delay < 2*minRetryDelay {
+		t.Fatalf("Expected the retries to back off, got %s", delay)
+	}
+
+	// the retries are limited
+	replayer = newTestReplayer(t, writeValue(value[:20], 0, WriteTypeCommand, busy))
+	defer replayer.Close()
+	char = newTestCharacteristic(testCharPath, withBackend(replayer), countWrites(&times))
+	defer char.Close()
+	times = nil
+	err = char.WriteChunked(value[:20], &ChunkedWriteOptions{Type: WriteTypeCommand, MTU: DefaultMTU, Retries: 2})
+	if !isWriteBusy(err) || len(times) != 3 {
+		t.Fatalf("Expected 3 busy writes, got %d: %v", len(times), err)
+	}
+}
+
+func TestWriteChunkedInterval(t *testing.T) {
+
+	value := testValue(50)
+	replayer := newTestReplayer(t,
+		writeValue(value[:20], 0, WriteTypeCommand, nil),
+		writeValue(value[20:40], 0, WriteTypeCommand, nil),
+		writeValue(value[40:], 0, WriteTypeCommand, nil),
+	)
+	defer replayer.Close()
+	var times []time.Time
+	char := newTestCharacteristic(testCharPath, withBackend(replayer), countWrites(&times))
+	defer char.Close()
+
+	interval := 20 * time.Millisecond
+	err := char.WriteChunked(value, &ChunkedWriteOptions{Type: WriteTypeCommand, MTU: DefaultMTU, Interval: interval})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(times) != 3 {
+		t.Fatalf("Expected 3 chunks, got %d", len(times))
+	}
+	for i := 1; i < len(times); i++ {
+		if delay := times[i].Sub(times[i-1]); delay < interval {
+			t.Fatalf("Chunk %d written after %s", i, delay)
+		}
+	}
+
+	// a cancelled write stops between the chunks
+	ctx, cancel := context.WithCancel(context.Background())
+	times = nil
+	go func() {
+		time.Sleep(interval / 2)
+		cancel()
+	}()
+	err = char.WriteChunkedContext(ctx, value, &ChunkedWriteOptions{Type: WriteTypeCommand, MTU: DefaultMTU, Interval: interval})
+	if err != context.Canceled || len(times) != 1 {
+		t.Fatalf("Expected the write to be cancelled after a chunk, got %d: %v", len(times), err)
+	}
+}
+
+func TestGattWriterLongValue(t *testing.T) {
+
+	value := testValue(600)
+	replayer := newTestReplayer(t,
+		writeValue(value[:512], 0, WriteTypeRequest, nil),
+		writeValue(value[512:], 0, WriteTypeRequest, nil),
+	)
+	defer replayer.Close()
+	char := newTestCharacteristic(testCharPath, withBackend(replayer))
+	defer char.Close()
+
+	// without write-without-response the writer sends consecutive values
+	w := &GattWriter{char: char, mtu: 517, writeType: WriteTypeRequest}
+	n, err := w.Write(value)
+	if err != nil || n != len(value) {
+		t.Fatalf("Expected %d bytes written, got %d: %v", len(value), n, err)
+	}
+}
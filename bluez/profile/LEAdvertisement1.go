@@ -0,0 +1,274 @@
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/bluez"
+	"github.com/saurabh-newera/BLE/util"
+)
+
+// The types of advertisement
+const (
+	AdvertisementTypeBroadcast  = "broadcast"
+	AdvertisementTypePeripheral = "peripheral"
+)
+
+// The values added by bluez to an advertisement, see LEAdvertisingManager1Properties.SupportedIncludes
+const (
+	AdvertisementIncludeTxPower    = "tx-power"
+	AdvertisementIncludeAppearance = "appearance"
+	AdvertisementIncludeLocalName  = "local-name"
+)
+
+// MaxAdvertisementSize the size of a legacy advertising payload
+const MaxAdvertisementSize = 31
+
+// ErrAdvertisementTooLarge is returned when the data does not fit in a legacy advertising payload
+var ErrAdvertisementTooLarge = errors.New("Advertisement data exceeds 31 bytes")
+
+// Advertisement the data of an LEAdvertisement1, the empty fields are not advertised
+type Advertisement struct {
+	// Type is AdvertisementTypePeripheral (connectable) or AdvertisementTypeBroadcast
+	Type         string   `dbus:"Type"`
+	ServiceUUIDs []string `dbus:"ServiceUUIDs,omitempty"`
+	// ManufacturerData by company identifier
+	ManufacturerData map[uint16][]byte `dbus:"-"`
+	SolicitUUIDs     []string          `dbus:"SolicitUUIDs,omitempty"`
+	// ServiceData by service UUID
+	ServiceData map[string][]byte `dbus:"-"`
+	// Includes the values added by bluez, eg. AdvertisementIncludeTxPower
+	Includes   []string `dbus:"Includes,omitempty"`
+	LocalName  string   `dbus:"LocalName,omitempty"`
+	Appearance uint16   `dbus:"Appearance,omitempty"`
+	// Duration of the advertisement in seconds, when rotating with other advertisements
+	Duration uint16 `dbus:"Duration,omitempty"`
+	// Timeout after which the advertisement is released, in seconds
+	Timeout uint16 `dbus:"Timeout,omitempty"`
+	// MinInterval and MaxInterval of the advertising, in milliseconds (experimental)
+	MinInterval uint32 `dbus:"MinInterval,omitempty"`
+	MaxInterval uint32 `dbus:"MaxInterval,omitempty"`
+	// TxPower requested, in dBm, 0 is not sent (experimental)
+	TxPower int16 `dbus:"TxPower,omitempty"`
+}
+
+// baseUUID the suffix of the UUIDs shortened by bluez
+var baseUUID = regexp.MustCompile(`^(?i)([0-9a-f]{8})-0000-1000-8000-00805f9b34fb$`)
+
+// uuidSize return the size of an UUID in the advertising data
+func uuidSize(uuid string) (int, error) {
+	if match := baseUUID.FindStringSubmatch(uuid); match != nil {
+		uuid = strings.TrimLeft(match[1][:4], "0") + match[1][4:]
+		if len(uuid) <= 4 {
+			return 2, nil
+		}
+		return 4, nil
+	}
+	switch len(uuid) {
+	case 4:
+		return 2, nil
+	case 8:
+		return 4, nil
+	case 36:
+		return 16, nil
+	}
+	return 0, fmt.Errorf("Invalid UUID %s", uuid)
+}
+
+// uuidListSize return the size of the AD structures of an UUID list, grouped by UUID size
+func uuidListSize(uuids []string) (int, error) {
+	sizes := make(map[int]int)
+	for _, uuid := range uuids {
+		size, err := uuidSize(uuid)
+		if err != nil {
+			return 0, err
+		}
+		sizes[size] += size
+	}
+	total := 0
+	for _, size := range sizes {
+		// length and type
+		total += 2 + size
+	}
+	return total, nil
+}
+
+// Size return the size of the advertising data built by bluez, the values added
+// with Includes are counted except the adapter name
+func (a *Advertisement) Size() (int, error) {
+
+	size := 0
+	if a.Type == AdvertisementTypePeripheral {
+		// flags
+		size += 3
+	}
+
+	for _, list := range [][]string{a.ServiceUUIDs, a.SolicitUUIDs} {
+		s, err := uuidListSize(list)
+		if err != nil {
+			return 0, err
+		}
+		size += s
+	}
+
+	for _, data := range a.ManufacturerData {
+		size += 2 + 2 + len(data)
+	}
+	for uuid, data := range a.ServiceData {
+		s, err := uuidSize(uuid)
+		if err != nil {
+			return 0, err
+		}
+		size += 2 + s + len(data)
+	}
+
+	if a.LocalName != "" {
+		size += 2 + len(a.LocalName)
+	}
+	includes := make(map[string]bool)
+	for _, include := range a.Includes {
+		includes[include] = true
+	}
+	if a.Appearance != 0 || includes[AdvertisementIncludeAppearance] {
+		size += 4
+	}
+	if includes[AdvertisementIncludeTxPower] {
+		size += 3
+	}
+
+	return size, nil
+}
+
+// Validate check the type and that the data fits in a legacy advertising payload
+func (a *Advertisement) Validate() error {
+	switch a.Type {
+	case AdvertisementTypeBroadcast, AdvertisementTypePeripheral:
+	default:
+		return fmt.Errorf("Invalid advertisement type %q", a.Type)
+	}
+	size, err := a.Size()
+	if err != nil {
+		return err
+	}
+	if size > MaxAdvertisementSize {
+		return fmt.Errorf("%w: %d bytes", ErrAdvertisementTooLarge, size)
+	}
+	return nil
+}
+
+// properties return the D-Bus properties of the advertisement
+func (a *Advertisement) properties() (map[string]dbus.Variant, error) {
+	props, err := util.StructToMap(a)
+	if err != nil {
+		return nil, err
+	}
+	if len(a.ManufacturerData) > 0 {
+		data := make(map[uint16]dbus.Variant)
+		for id, value := range a.ManufacturerData {
+			data[id] = dbus.MakeVariant(value)
+		}
+		props["ManufacturerData"] = dbus.MakeVariant(data)
+	}
+	if len(a.ServiceData) > 0 {
+		data := make(map[string]dbus.Variant)
+		for uuid, value := range a.ServiceData {
+			data[uuid] = dbus.MakeVariant(value)
+		}
+		props["ServiceData"] = dbus.MakeVariant(data)
+	}
+	return props, nil
+}
+
+// NewLEAdvertisement1 create an advertisement exported at path, eg. /org/bluez/advertisement/go0
+func NewLEAdvertisement1(path string, adv *Advertisement, opts ...Option) *LEAdvertisement1 {
+	a := new(LEAdvertisement1)
+	a.client = newClient(
+		&bluez.Config{
+			Name:  "org.bluez",
+			Iface: bluez.LEAdvertisement1Interface,
+			Path:  path,
+			Bus:   bluez.SystemBus,
+		},
+		opts,
+	)
+	a.Path = dbus.ObjectPath(path)
+	a.Advertisement = adv
+	return a
+}
+
+// LEAdvertisement1 an org.bluez.LEAdvertisement1 object, register it with LEAdvertisingManager1.RegisterAdvertisement
+type LEAdvertisement1 struct {
+	client        *bluez.Client
+	Path          dbus.ObjectPath
+	Advertisement *Advertisement
+
+	lock      sync.Mutex
+	onRelease func()
+	released  bool
+}
+
+// Export validate the advertisement and export it on the bus, the data cannot change afterwards
+func (a *LEAdvertisement1) Export() error {
+
+	err := a.Advertisement.Validate()
+	if err != nil {
+		return err
+	}
+	props, err := a.Advertisement.properties()
+	if err != nil {
+		return err
+	}
+
+	err = a.client.Export(a, a.Path, bluez.LEAdvertisement1Interface)
+	if err != nil {
+		return err
+	}
+	return a.client.Export(&bluez.ExportedProperties{
+		Interface: bluez.LEAdvertisement1Interface,
+		Values: func() map[string]dbus.Variant {
+			return props
+		},
+	}, a.Path, bluez.PropertiesInterface)
+}
+
+// Unexport remove the advertisement from the bus
+func (a *LEAdvertisement1) Unexport() error {
+	err := a.client.Unexport(a.Path, bluez.LEAdvertisement1Interface)
+	if err != nil {
+		return err
+	}
+	return a.client.Unexport(a.Path, bluez.PropertiesInterface)
+}
+
+// Close remove the advertisement and the connection
+func (a *LEAdvertisement1) Close() {
+	a.client.Disconnect()
+}
+
+// OnRelease set a function called when bluez drops the advertisement, eg. on Timeout.
+// It is called at once if the advertisement has already been released
+func (a *LEAdvertisement1) OnRelease(handler func()) {
+	a.lock.Lock()
+	a.onRelease = handler
+	released := a.released
+	a.lock.Unlock()
+	if released && handler != nil {
+		handler()
+	}
+}
+
+//Release is called by bluez when the advertisement is removed
+func (a *LEAdvertisement1) Release() *dbus.Error {
+	a.lock.Lock()
+	handler := a.onRelease
+	a.released = true
+	a.lock.Unlock()
+	if handler != nil {
+		handler()
+	}
+	return nil
+}
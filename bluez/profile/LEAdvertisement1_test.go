@@ -0,0 +1,62 @@
+package profile
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAdvertisementSize(t *testing.T) {
+
+	adv := &Advertisement{
+		Type:             AdvertisementTypePeripheral,
+		ServiceUUIDs:     []string{"180f", "0000180a-0000-1000-8000-00805f9b34fb"},
+		ManufacturerData: map[uint16][]byte{0xffff: {1, 2, 3, 4}},
+		LocalName:        "gateway",
+		Includes:         []string{AdvertisementIncludeTxPower},
+	}
+
+	// flags 3, 16-bit UUIDs 2+4, manufacturer data 2+2+4, name 2+7, tx power 3
+	size, err := adv.Size()
+	if err != nil || size != 29 {
+		t.Fatalf("Expected 29 bytes, got %d %v", size, err)
+	}
+	if err := adv.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	adv.ServiceUUIDs = append(adv.ServiceUUIDs, "6e400001-b5a3-f393-e0a9-e50e24dcca9e")
+	err = adv.Validate()
+	if !errors.Is(err, ErrAdvertisementTooLarge) {
+		t.Fatalf("Expected ErrAdvertisementTooLarge, got %v", err)
+	}
+
+	if err := (&Advertisement{Type: "other"}).Validate(); err == nil {
+		t.Fatal("Expected an invalid type error")
+	}
+}
+
+func TestAdvertisementProperties(t *testing.T) {
+
+	adv := &Advertisement{
+		Type:             AdvertisementTypeBroadcast,
+		ManufacturerData: map[uint16][]byte{0x004c: {2, 21}},
+		Timeout:          30,
+	}
+	props, err := adv.properties()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(props) != 3 {
+		t.Fatalf("Expected Type, ManufacturerData and Timeout, got %v", props)
+	}
+	if props["ManufacturerData"].Signature().String() != "a{qv}" {
+		t.Fatalf("Unexpected ManufacturerData %v", props["ManufacturerData"])
+	}
+	if props["Timeout"].Value() != uint16(30) || props["Type"].Value() != "broadcast" {
+		t.Fatalf("Unexpected properties %v", props)
+	}
+	if _, ok := props["LocalName"]; ok {
+		t.Fatal("Empty LocalName should be omitted")
+	}
+}
@@ -0,0 +1,74 @@
+package profile
+
+import (
+	"context"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/bluez"
+)
+
+// NewLEAdvertisingManager1 create a new LEAdvertisingManager1 client
+func NewLEAdvertisingManager1(hostID string, opts ...Option) *LEAdvertisingManager1 {
+	a := new(LEAdvertisingManager1)
+	a.client = newClient(
+		&bluez.Config{
+			Name:  "org.bluez",
+			Iface: bluez.LEAdvertisingManager1Interface,
+			Path:  "/org/bluez/" + hostID,
+			Bus:   bluez.SystemBus,
+		},
+		opts,
+	)
+	a.Properties = new(LEAdvertisingManager1Properties)
+	return a
+}
+
+// LEAdvertisingManager1 client
+type LEAdvertisingManager1 struct {
+	client     *bluez.Client
+	Properties *LEAdvertisingManager1Properties
+}
+
+//LEAdvertisingManager1Properties contains the exposed properties of an interface
+type LEAdvertisingManager1Properties struct {
+	ActiveInstances            byte
+	SupportedInstances         byte
+	SupportedIncludes          []string
+	SupportedSecondaryChannels []string
+}
+
+// Close the connection
+func (a *LEAdvertisingManager1) Close() {
+	a.client.Disconnect()
+}
+
+//GetProperties load all available properties
+func (a *LEAdvertisingManager1) GetProperties() (*LEAdvertisingManager1Properties, error) {
+	return a.GetPropertiesContext(context.Background())
+}
+
+//GetPropertiesContext load all available properties, aborting when ctx is done
+func (a *LEAdvertisingManager1) GetPropertiesContext(ctx context.Context) (*LEAdvertisingManager1Properties, error) {
+	err := a.client.GetPropertiesContext(ctx, a.Properties)
+	return a.Properties, err
+}
+
+//RegisterAdvertisement register the advertisement exported at path
+func (a *LEAdvertisingManager1) RegisterAdvertisement(advertisement dbus.ObjectPath, options map[string]interface{}) error {
+	return a.RegisterAdvertisementContext(context.Background(), advertisement, options)
+}
+
+//RegisterAdvertisementContext register an advertisement, aborting when ctx is done
+func (a *LEAdvertisingManager1) RegisterAdvertisementContext(ctx context.Context, advertisement dbus.ObjectPath, options map[string]interface{}) error {
+	return a.client.CallContext(ctx, "RegisterAdvertisement", 0, advertisement, options).Store()
+}
+
+//UnregisterAdvertisement unregister an advertisement
+func (a *LEAdvertisingManager1) UnregisterAdvertisement(advertisement dbus.ObjectPath) error {
+	return a.UnregisterAdvertisementContext(context.Background(), advertisement)
+}
+
+//UnregisterAdvertisementContext unregister an advertisement, aborting when ctx is done
+func (a *LEAdvertisingManager1) UnregisterAdvertisementContext(ctx context.Context, advertisement dbus.ObjectPath) error {
+	return a.client.CallContext(ctx, "UnregisterAdvertisement", 0, advertisement).Store()
+}
@@ -1,20 +1,23 @@
 package profile
 
 import (
+	"context"
+
 	"github.com/godbus/dbus"
 	"github.com/saurabh-newera/BLE/bluez"
 )
 
 // NewObjectManager create a new Device1 client
-func NewObjectManager() *ObjectManager {
+func NewObjectManager(opts ...Option) *ObjectManager {
 	om := new(ObjectManager)
-	om.client = bluez.NewClient(
+	om.client = newClient(
 		&bluez.Config{
 			Name:  "org.bluez",
 			Iface: "org.freedesktop.DBus.ObjectManager",
 			Path:  "/",
 			Bus:   bluez.SystemBus,
 		},
+		opts,
 	)
 
 	return om
@@ -32,8 +35,13 @@ func (o *ObjectManager) Close() {
 
 // GetManagedObjects return a list of all available objects registered
 func (o *ObjectManager) GetManagedObjects() (map[dbus.ObjectPath]map[string]map[string]dbus.Variant, error) {
+	return o.GetManagedObjectsContext(context.Background())
+}
+
+// GetManagedObjectsContext return a list of all available objects registered, aborting when ctx is done
+func (o *ObjectManager) GetManagedObjectsContext(ctx context.Context) (map[dbus.ObjectPath]map[string]map[string]dbus.Variant, error) {
 	var objs map[dbus.ObjectPath]map[string]map[string]dbus.Variant
-	err := o.client.Call("GetManagedObjects", 0).Store(&objs)
+	err := o.client.CallContext(ctx, "GetManagedObjects", 0).Store(&objs)
 	return objs, err
 }
 
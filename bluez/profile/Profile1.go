@@ -0,0 +1,110 @@
+package profile
+
+import (
+	"net"
+	"strings"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/bluez"
+	"github.com/saurabh-newera/BLE/util"
+)
+
+// ProfileNetwork the network of the addresses of a profile connection
+const ProfileNetwork = "bluetooth"
+
+// ProfileHandler receive the connections of a profile. Return bluez.ErrRejected to refuse
+// a request, other errors are reported as org.bluez.Error.Failed
+type ProfileHandler interface {
+	// NewConnection is called when a device connects, the handler owns conn.
+	// properties contain eg. the Version and Features of the remote profile
+	NewConnection(device dbus.ObjectPath, conn net.Conn, properties map[string]dbus.Variant) error
+	// RequestDisconnection is called when the device is disconnected by bluez, conn must be closed
+	RequestDisconnection(device dbus.ObjectPath) error
+	// Release is called when bluez unregisters the profile
+	Release()
+}
+
+// NewProfile1 create a profile exporting handler at path, eg. /org/bluez/profile/go
+func NewProfile1(path string, handler ProfileHandler, opts ...Option) *Profile1 {
+	p := new(Profile1)
+	p.client = newClient(
+		&bluez.Config{
+			Name:  "org.bluez",
+			Iface: bluez.Profile1Interface,
+			Path:  path,
+			Bus:   bluez.SystemBus,
+		},
+		opts,
+	)
+	p.Path = dbus.ObjectPath(path)
+	p.handler = handler
+	return p
+}
+
+// Profile1 an org.bluez.Profile1 object handing the connections of bluez to a ProfileHandler
+type Profile1 struct {
+	client  *bluez.Client
+	handler ProfileHandler
+	Path    dbus.ObjectPath
+}
+
+// Export the profile on the bus, it must be registered with ProfileManager1.RegisterProfile
+func (p *Profile1) Export() error {
+	return p.client.Export(p, p.Path, bluez.Profile1Interface)
+}
+
+// Unexport remove the profile from the bus
+func (p *Profile1) Unexport() error {
+	return p.client.Unexport(p.Path, bluez.Profile1Interface)
+}
+
+// Close remove the profile and the connection
+func (p *Profile1) Close() {
+	p.client.Disconnect()
+}
+
+// DeviceAddress return the address of a device from its object path, eg.
+// /org/bluez/hci0/dev_00_11_22_33_44_55 is 00:11:22:33:44:55
+func DeviceAddress(device dbus.ObjectPath) string {
+	parts := strings.Split(string(device), "/")
+	name := parts[len(parts)-1]
+	return strings.Replace(strings.TrimPrefix(name, "dev_"), "_", ":", -1)
+}
+
+// adapterName return the adapter of a device object path, eg. hci0
+func adapterName(device dbus.ObjectPath) string {
+	parts := strings.Split(string(device), "/")
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[len(parts)-2]
+}
+
+//Release is called by bluez when the profile is unregistered
+func (p *Profile1) Release() *dbus.Error {
+	p.handler.Release()
+	return nil
+}
+
+//NewConnection is called by bluez with the socket of a new connection
+func (p *Profile1) NewConnection(device dbus.ObjectPath, fd dbus.UnixFD, properties map[string]dbus.Variant) *dbus.Error {
+	conn, err := util.NewSocketConn(
+		int(fd),
+		&util.Addr{Net: ProfileNetwork, Address: adapterName(device)},
+		&util.Addr{Net: ProfileNetwork, Address: DeviceAddress(device)},
+	)
+	if err != nil {
+		return bluez.ToDBusError(err)
+	}
+	err = p.handler.NewConnection(device, conn, properties)
+	if err != nil {
+		conn.Close()
+		return bluez.ToDBusError(err)
+	}
+	return nil
+}
+
+//RequestDisconnection is called by bluez when a device is disconnected from the profile
+func (p *Profile1) RequestDisconnection(device dbus.ObjectPath) *dbus.Error {
+	return bluez.ToDBusError(p.handler.RequestDisconnection(device))
+}
@@ -1,20 +1,23 @@
 package profile
 
 import (
+	"context"
+
 	"github.com/godbus/dbus"
 	"github.com/saurabh-newera/BLE/bluez"
 )
 
 // NewProfileManager1 create a new ProfileManager1 client
-func NewProfileManager1(hostID string) *ProfileManager1 {
+func NewProfileManager1(hostID string, opts ...Option) *ProfileManager1 {
 	a := new(ProfileManager1)
-	a.client = bluez.NewClient(
+	a.client = newClient(
 		&bluez.Config{
 			Name:  "org.bluez",
-			Iface: "org.bluez.ProfileManager1",
+			Iface: bluez.ProfileManager1Interface,
 			Path:  "/org/bluez",
 			Bus:   bluez.SystemBus,
 		},
+		opts,
 	)
 	return a
 }
@@ -31,10 +34,34 @@ func (a *ProfileManager1) Close() {
 
 //RegisterProfile add a new Profile for an UUID
 func (a *ProfileManager1) RegisterProfile(profile string, UUID string, options map[string]interface{}) error {
-	return a.client.Call("RegisterProfile", 0, dbus.ObjectPath(profile), UUID, options).Store()
+	return a.RegisterProfileContext(context.Background(), profile, UUID, options)
+}
+
+//RegisterProfileContext add a new Profile for an UUID, aborting when ctx is done
+func (a *ProfileManager1) RegisterProfileContext(ctx context.Context, profile string, UUID string, options map[string]interface{}) error {
+	return a.client.CallContext(ctx, "RegisterProfile", 0, dbus.ObjectPath(profile), UUID, options).Store()
+}
+
+//RegisterProfileWithOptions add a new Profile for an UUID, options may be nil
+func (a *ProfileManager1) RegisterProfileWithOptions(profile string, UUID string, options *ProfileOptions) error {
+	return a.RegisterProfileWithOptionsContext(context.Background(), profile, UUID, options)
+}
+
+//RegisterProfileWithOptionsContext add a new Profile for an UUID, aborting when ctx is done
+func (a *ProfileManager1) RegisterProfileWithOptionsContext(ctx context.Context, profile string, UUID string, options *ProfileOptions) error {
+	opts, err := options.ToMap()
+	if err != nil {
+		return err
+	}
+	return a.client.CallContext(ctx, "RegisterProfile", 0, dbus.ObjectPath(profile), UUID, opts).Store()
 }
 
 //UnregisterProfile add a new Profile for an UUID
 func (a *ProfileManager1) UnregisterProfile(profile string) error {
-	return a.client.Call("UnregisterProfile", 0, dbus.ObjectPath(profile)).Store()
+	return a.UnregisterProfileContext(context.Background(), profile)
+}
+
+//UnregisterProfileContext remove a Profile, aborting when ctx is done
+func (a *ProfileManager1) UnregisterProfileContext(ctx context.Context, profile string) error {
+	return a.client.CallContext(ctx, "UnregisterProfile", 0, dbus.ObjectPath(profile)).Store()
 }
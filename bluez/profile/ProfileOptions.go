@@ -0,0 +1,62 @@
+package profile
+
+import (
+	"fmt"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/util"
+)
+
+// The roles of a profile, see ProfileOptions.Role
+const (
+	ProfileRoleClient = "client"
+	ProfileRoleServer = "server"
+)
+
+// ProfileOptions the options of ProfileManager1.RegisterProfile, the empty fields are not sent
+type ProfileOptions struct {
+	// Name of the service in the SDP record
+	Name string `dbus:"Name,omitempty"`
+	// Service UUID to advertise in the SDP record, when different from the profile UUID
+	Service string `dbus:"Service,omitempty"`
+	// Role is ProfileRoleClient or ProfileRoleServer, both when empty
+	Role string `dbus:"Role,omitempty"`
+	// Channel is the RFCOMM channel, chosen by bluez when 0
+	Channel uint16 `dbus:"Channel,omitempty"`
+	// PSM is the L2CAP PSM, chosen by bluez when 0
+	PSM uint16 `dbus:"PSM,omitempty"`
+	// RequireAuthentication of the remote device for incoming connections
+	RequireAuthentication bool `dbus:"RequireAuthentication,omitempty"`
+	// RequireAuthorization of the incoming connections by the agent
+	RequireAuthorization bool `dbus:"RequireAuthorization,omitempty"`
+	// AutoConnect the client profile when the device connects, bluez default is true
+	AutoConnect *bool `dbus:"AutoConnect,omitempty"`
+	// ServiceRecord is the SDP record in XML, see the sdp package. bluez builds it when empty
+	ServiceRecord string `dbus:"ServiceRecord,omitempty"`
+	// Version of the profile in the SDP record, eg. 0x0102
+	Version uint16 `dbus:"Version,omitempty"`
+	// Features of the profile in the SDP record
+	Features uint16 `dbus:"Features,omitempty"`
+}
+
+// Validate check the role
+func (o *ProfileOptions) Validate() error {
+	switch o.Role {
+	case "", ProfileRoleClient, ProfileRoleServer:
+	default:
+		return fmt.Errorf("Invalid profile role %q", o.Role)
+	}
+	return nil
+}
+
+// ToMap return the options as expected by RegisterProfile
+func (o *ProfileOptions) ToMap() (map[string]dbus.Variant, error) {
+	if o == nil {
+		return map[string]dbus.Variant{}, nil
+	}
+	err := o.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return util.StructToMap(o)
+}
@@ -0,0 +1,84 @@
+package obex
+
+import (
+	"context"
+	"errors"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/bluez"
+	"github.com/saurabh-newera/BLE/bluez/profile"
+	"github.com/saurabh-newera/BLE/util"
+)
+
+// The targets of a session, see SessionArgs.Target
+const (
+	TargetFTP  = "ftp"
+	TargetOPP  = "opp"
+	TargetPBAP = "pbap"
+	TargetMAP  = "map"
+	TargetSync = "sync"
+	TargetBIP  = "bip-avrcp"
+)
+
+// SessionArgs the arguments of CreateSession, the empty fields are not sent
+type SessionArgs struct {
+	// Target is the profile of the session, eg. TargetOPP
+	Target string `dbus:"Target"`
+	// Source is the address of the local adapter, the default adapter when empty
+	Source string `dbus:"Source,omitempty"`
+	// Channel is the RFCOMM channel, found with SDP when 0
+	Channel byte `dbus:"Channel,omitempty"`
+	// PSM is the L2CAP PSM, found with SDP when 0
+	PSM uint16 `dbus:"PSM,omitempty"`
+}
+
+// NewClient1 create a new Client1 client
+func NewClient1(opts ...profile.Option) *Client1 {
+	c := new(Client1)
+	c.client = newClient(RootPath, Client1Interface, opts)
+	c.opts = opts
+	return c
+}
+
+// Client1 client
+type Client1 struct {
+	client *bluez.Client
+	opts   []profile.Option
+}
+
+// Close the connection
+func (c *Client1) Close() {
+	c.client.Disconnect()
+}
+
+// CreateSession connect to the OBEX service of a device, eg. 00:11:22:33:44:55
+func (c *Client1) CreateSession(destination string, args *SessionArgs) (*Session1, error) {
+	return c.CreateSessionContext(context.Background(), destination, args)
+}
+
+// CreateSessionContext connect to the OBEX service of a device, aborting when ctx is done
+func (c *Client1) CreateSessionContext(ctx context.Context, destination string, args *SessionArgs) (*Session1, error) {
+	if args == nil || args.Target == "" {
+		return nil, errors.New("A session needs a target, eg. TargetOPP")
+	}
+	options, err := util.StructToMap(args)
+	if err != nil {
+		return nil, err
+	}
+	var path dbus.ObjectPath
+	err = c.client.CallContext(ctx, "CreateSession", 0, destination, options).Store(&path)
+	if err != nil {
+		return nil, err
+	}
+	return NewSession1(string(path), c.opts...), nil
+}
+
+// RemoveSession disconnect a session
+func (c *Client1) RemoveSession(session *Session1) error {
+	return c.RemoveSessionContext(context.Background(), session)
+}
+
+// RemoveSessionContext disconnect a session, aborting when ctx is done
+func (c *Client1) RemoveSessionContext(ctx context.Context, session *Session1) error {
+	return c.client.CallContext(ctx, "RemoveSession", 0, session.Path).Store()
+}
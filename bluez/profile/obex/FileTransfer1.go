@@ -0,0 +1,150 @@
+package obex
+
+import (
+	"context"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/bluez"
+	"github.com/saurabh-newera/BLE/bluez/profile"
+	"github.com/saurabh-newera/BLE/util"
+)
+
+// FolderEntry an entry of ListFolder
+type FolderEntry struct {
+	Name string
+	// Type is folder or file
+	Type string
+	Size uint64
+	// The permissions, eg. RWD
+	UserPermission  string `dbus:"User-perm"`
+	GroupPermission string `dbus:"Group-perm"`
+	OtherPermission string `dbus:"Other-perm"`
+	// The times, eg. 20230102T150405Z
+	Modified string
+	Accessed string
+	Created  string
+	// Unknown collect the other values
+	Unknown map[string]dbus.Variant `dbus:",unknown"`
+}
+
+// NewFileTransfer1 create a new FileTransfer1 client on a session
+func NewFileTransfer1(session string, opts ...profile.Option) *FileTransfer1 {
+	f := new(FileTransfer1)
+	f.client = newClient(session, FileTransfer1Interface, opts)
+	f.opts = opts
+	return f
+}
+
+// FileTransfer1 client
+type FileTransfer1 struct {
+	client *bluez.Client
+	opts   []profile.Option
+}
+
+// Close the connection
+func (f *FileTransfer1) Close() {
+	f.client.Disconnect()
+}
+
+// ChangeFolder change the current folder of the device, .. is the parent folder
+func (f *FileTransfer1) ChangeFolder(folder string) error {
+	return f.ChangeFolderContext(context.Background(), folder)
+}
+
+// ChangeFolderContext change the current folder, aborting when ctx is done
+func (f *FileTransfer1) ChangeFolderContext(ctx context.Context, folder string) error {
+	return f.client.CallContext(ctx, "ChangeFolder", 0, folder).Store()
+}
+
+// CreateFolder create a folder in the current folder and enter it
+func (f *FileTransfer1) CreateFolder(folder string) error {
+	return f.CreateFolderContext(context.Background(), folder)
+}
+
+// CreateFolderContext create a folder, aborting when ctx is done
+func (f *FileTransfer1) CreateFolderContext(ctx context.Context, folder string) error {
+	return f.client.CallContext(ctx, "CreateFolder", 0, folder).Store()
+}
+
+// ListFolder list the current folder
+func (f *FileTransfer1) ListFolder() ([]FolderEntry, error) {
+	return f.ListFolderContext(context.Background())
+}
+
+// ListFolderContext list the current folder, aborting when ctx is done
+func (f *FileTransfer1) ListFolderContext(ctx context.Context) ([]FolderEntry, error) {
+	var list []map[string]dbus.Variant
+	err := f.client.CallContext(ctx, "ListFolder", 0).Store(&list)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]FolderEntry, len(list))
+	for i, props := range list {
+		err = util.MapToStruct(&entries[i], props)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// transfer call a method returning a transfer
+func (f *FileTransfer1) transfer(ctx context.Context, method string, args ...interface{}) (*Transfer1, error) {
+	var path dbus.ObjectPath
+	var props map[string]dbus.Variant
+	err := f.client.CallContext(ctx, method, 0, args...).Store(&path, &props)
+	if err != nil {
+		return nil, err
+	}
+	return newTransfer(path, props, f.opts)
+}
+
+// GetFile pull sourcefile of the current folder to the local targetfile
+func (f *FileTransfer1) GetFile(targetfile string, sourcefile string) (*Transfer1, error) {
+	return f.GetFileContext(context.Background(), targetfile, sourcefile)
+}
+
+// GetFileContext pull a file, aborting when ctx is done
+func (f *FileTransfer1) GetFileContext(ctx context.Context, targetfile string, sourcefile string) (*Transfer1, error) {
+	return f.transfer(ctx, "GetFile", targetfile, sourcefile)
+}
+
+// PutFile push the local sourcefile as targetfile in the current folder
+func (f *FileTransfer1) PutFile(sourcefile string, targetfile string) (*Transfer1, error) {
+	return f.PutFileContext(context.Background(), sourcefile, targetfile)
+}
+
+// PutFileContext push a file, aborting when ctx is done
+func (f *FileTransfer1) PutFileContext(ctx context.Context, sourcefile string, targetfile string) (*Transfer1, error) {
+	return f.transfer(ctx, "PutFile", sourcefile, targetfile)
+}
+
+// CopyFile copy a file of the device
+func (f *FileTransfer1) CopyFile(sourcefile string, targetfile string) error {
+	return f.CopyFileContext(context.Background(), sourcefile, targetfile)
+}
+
+// CopyFileContext copy a file of the device, aborting when ctx is done
+func (f *FileTransfer1) CopyFileContext(ctx context.Context, sourcefile string, targetfile string) error {
+	return f.client.CallContext(ctx, "CopyFile", 0, sourcefile, targetfile).Store()
+}
+
+// MoveFile move a file of the device
+func (f *FileTransfer1) MoveFile(sourcefile string, targetfile string) error {
+	return f.MoveFileContext(context.Background(), sourcefile, targetfile)
+}
+
+// MoveFileContext move a file of the device, aborting when ctx is done
+func (f *FileTransfer1) MoveFileContext(ctx context.Context, sourcefile string, targetfile string) error {
+	return f.client.CallContext(ctx, "MoveFile", 0, sourcefile, targetfile).Store()
+}
+
+// Delete remove a file or an empty folder of the current folder
+func (f *FileTransfer1) Delete(file string) error {
+	return f.DeleteContext(context.Background(), file)
+}
+
+// DeleteContext remove a file or folder, aborting when ctx is done
+func (f *FileTransfer1) DeleteContext(ctx context.Context, file string) error {
+	return f.client.CallContext(ctx, "Delete", 0, file).Store()
+}
@@ -0,0 +1,310 @@
+package obex
+
+import (
+	"context"
+	"os"
+	"sort"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/bluez"
+	"github.com/saurabh-newera/BLE/bluez/profile"
+	"github.com/saurabh-newera/BLE/util"
+)
+
+// The message types of MessageFilter.Types
+const (
+	MessageTypeSMS   = "sms"
+	MessageTypeEmail = "email"
+	MessageTypeMMS   = "mms"
+)
+
+// FolderFilter the filter of ListFolders, the empty fields are not sent
+type FolderFilter struct {
+	Offset   uint16 `dbus:"Offset,omitempty"`
+	MaxCount uint16 `dbus:"MaxCount,omitempty"`
+}
+
+// MessageFilter the filter of ListMessages, the empty fields are not sent
+type MessageFilter struct {
+	// Offset of the first message
+	Offset uint16 `dbus:"Offset,omitempty"`
+	// MaxCount of messages, obexd default is 1024
+	MaxCount uint16 `dbus:"MaxCount,omitempty"`
+	// SubjectLength truncate the subjects
+	SubjectLength byte `dbus:"SubjectLength,omitempty"`
+	// Fields of the messages, see ListFilterFields
+	Fields []string `dbus:"Fields,omitempty"`
+	// Types of messages, eg. MessageTypeSMS
+	Types []string `dbus:"Types,omitempty"`
+	// PeriodBegin and PeriodEnd as YYYYMMDDTHHMMSS
+	PeriodBegin string `dbus:"PeriodBegin,omitempty"`
+	PeriodEnd   string `dbus:"PeriodEnd,omitempty"`
+	// Read select the read or unread messages, all of them when nil
+	Read *bool `dbus:"Read,omitempty"`
+	// Recipient and Sender filter by name or address
+	Recipient string `dbus:"Recipient,omitempty"`
+	Sender    string `dbus:"Sender,omitempty"`
+	// Priority select the high or normal priority messages, all of them when nil
+	Priority *bool `dbus:"Priority,omitempty"`
+}
+
+// MessageFolder a folder of ListFolders
+type MessageFolder struct {
+	Name string
+}
+
+// NewMessageAccess1 create a new MessageAccess1 client on a session
+func NewMessageAccess1(session string, opts ...profile.Option) *MessageAccess1 {
+	m := new(MessageAccess1)
+	m.client = newClient(session, MessageAccess1Interface, opts)
+	m.opts = opts
+	return m
+}
+
+// MessageAccess1 client
+type MessageAccess1 struct {
+	client *bluez.Client
+	opts   []profile.Option
+}
+
+// Close the connection
+func (m *MessageAccess1) Close() {
+	m.client.Disconnect()
+}
+
+// SetFolder change the current folder, eg. telecom/msg/inbox or .. for the parent folder
+func (m *MessageAccess1) SetFolder(name string) error {
+	return m.SetFolderContext(context.Background(), name)
+}
+
+// SetFolderContext change the current folder, aborting when ctx is done
+func (m *MessageAccess1) SetFolderContext(ctx context.Context, name string) error {
+	return m.client.CallContext(ctx, "SetFolder", 0, name).Store()
+}
+
+// toMap return the filter as expected by obexd
+func (f *FolderFilter) toMap() (map[string]dbus.Variant, error) {
+	if f == nil {
+		return map[string]dbus.Variant{}, nil
+	}
+	return util.StructToMap(f)
+}
+
+// toMap return the filter as expected by obexd
+func (f *MessageFilter) toMap() (map[string]dbus.Variant, error) {
+	if f == nil {
+		return map[string]dbus.Variant{}, nil
+	}
+	return util.StructToMap(f)
+}
+
+// ListFolders list the sub folders of the current folder, filter may be nil
+func (m *MessageAccess1) ListFolders(filter *FolderFilter) ([]MessageFolder, error) {
+	return m.ListFoldersContext(context.Background(), filter)
+}
+
+// ListFoldersContext list the sub folders, aborting when ctx is done
+func (m *MessageAccess1) ListFoldersContext(ctx context.Context, filter *FolderFilter) ([]MessageFolder, error) {
+	f, err := filter.toMap()
+	if err != nil {
+		return nil, err
+	}
+	var list []map[string]dbus.Variant
+	err = m.client.CallContext(ctx, "ListFolders", 0, f).Store(&list)
+	if err != nil {
+		return nil, err
+	}
+	folders := make([]MessageFolder, len(list))
+	for i, props := range list {
+		err = util.MapToStruct(&folders[i], props)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return folders, nil
+}
+
+// ListFilterFields return the fields usable in MessageFilter.Fields
+func (m *MessageAccess1) ListFilterFields() ([]string, error) {
+	return m.ListFilterFieldsContext(context.Background())
+}
+
+// ListFilterFieldsContext return the fields of the filters, aborting when ctx is done
+func (m *MessageAccess1) ListFilterFieldsContext(ctx context.Context) ([]string, error) {
+	var fields []string
+	err := m.client.CallContext(ctx, "ListFilterFields", 0).Store(&fields)
+	return fields, err
+}
+
+// ListMessages list the messages of a sub folder, or of the current folder when folder is empty
+func (m *MessageAccess1) ListMessages(folder string, filter *MessageFilter) ([]*Message1, error) {
+	return m.ListMessagesContext(context.Background(), folder, filter)
+}
+
+// ListMessagesContext list the messages of a folder, aborting when ctx is done
+func (m *MessageAccess1) ListMessagesContext(ctx context.Context, folder string, filter *MessageFilter) ([]*Message1, error) {
+	f, err := filter.toMap()
+	if err != nil {
+		return nil, err
+	}
+	var list map[dbus.ObjectPath]map[string]dbus.Variant
+	err = m.client.CallContext(ctx, "ListMessages", 0, folder, f).Store(&list)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(list))
+	for path := range list {
+		paths = append(paths, string(path))
+	}
+	sort.Strings(paths)
+
+	messages := make([]*Message1, 0, len(list))
+	for _, path := range paths {
+		message := NewMessage1(path, m.opts...)
+		err = util.MapToStruct(message.Properties, list[dbus.ObjectPath(path)])
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+// UpdateInbox ask the device to fetch the new messages
+func (m *MessageAccess1) UpdateInbox() error {
+	return m.UpdateInboxContext(context.Background())
+}
+
+// UpdateInboxContext ask the device to fetch the new messages, aborting when ctx is done
+func (m *MessageAccess1) UpdateInboxContext(ctx context.Context) error {
+	return m.client.CallContext(ctx, "UpdateInbox", 0).Store()
+}
+
+// PushMessage send the bMessage of sourcefile from a folder, eg. telecom/msg/outbox
+func (m *MessageAccess1) PushMessage(sourcefile string, folder string, args map[string]interface{}) (*Transfer1, error) {
+	return m.PushMessageContext(context.Background(), sourcefile, folder, args)
+}
+
+// PushMessageContext send a bMessage, aborting when ctx is done
+func (m *MessageAccess1) PushMessageContext(ctx context.Context, sourcefile string, folder string, args map[string]interface{}) (*Transfer1, error) {
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	var path dbus.ObjectPath
+	var props map[string]dbus.Variant
+	err := m.client.CallContext(ctx, "PushMessage", 0, sourcefile, folder, args).Store(&path, &props)
+	if err != nil {
+		return nil, err
+	}
+	return newTransfer(path, props, m.opts)
+}
+
+// NewMessage1 create a new Message1 client
+func NewMessage1(path string, opts ...profile.Option) *Message1 {
+	m := new(Message1)
+	m.client = newClient(path, Message1Interface, opts)
+	m.opts = opts
+	m.Path = dbus.ObjectPath(path)
+	m.Properties = new(Message1Properties)
+	return m
+}
+
+// Message1 client
+type Message1 struct {
+	client     *bluez.Client
+	opts       []profile.Option
+	Path       dbus.ObjectPath
+	Properties *Message1Properties
+}
+
+// Message1Properties exposed properties for Message1
+type Message1Properties struct {
+	Folder           string
+	Subject          string
+	Timestamp        string
+	Sender           string
+	SenderAddress    string
+	ReplyTo          string
+	Recipient        string
+	RecipientAddress string
+	// Type eg. sms-gsm or email
+	Type      string
+	Size      uint64
+	Status    string
+	Priority  bool
+	Read      bool
+	Deleted   bool
+	Sent      bool
+	Protected bool
+}
+
+// Close the connection
+func (m *Message1) Close() {
+	m.client.Disconnect()
+}
+
+// GetProperties load all available properties
+func (m *Message1) GetProperties() (*Message1Properties, error) {
+	return m.GetPropertiesContext(context.Background())
+}
+
+// GetPropertiesContext load all available properties, aborting when ctx is done
+func (m *Message1) GetPropertiesContext(ctx context.Context) (*Message1Properties, error) {
+	err := m.client.GetPropertiesContext(ctx, m.Properties)
+	return m.Properties, err
+}
+
+// SetRead mark the message as read or unread
+func (m *Message1) SetRead(read bool) error {
+	return m.client.SetProperty("Read", read)
+}
+
+// SetDeleted mark the message as deleted or restore it
+func (m *Message1) SetDeleted(deleted bool) error {
+	return m.client.SetProperty("Deleted", deleted)
+}
+
+// Get pull the bMessage to targetfile, obexd creates a temporary file when it is empty
+func (m *Message1) Get(targetfile string, attachment bool) (*Transfer1, error) {
+	return m.GetContext(context.Background(), targetfile, attachment)
+}
+
+// GetContext pull the bMessage, aborting when ctx is done
+func (m *Message1) GetContext(ctx context.Context, targetfile string, attachment bool) (*Transfer1, error) {
+	var path dbus.ObjectPath
+	var props map[string]dbus.Variant
+	err := m.client.CallContext(ctx, "Get", 0, targetfile, attachment).Store(&path, &props)
+	if err != nil {
+		return nil, err
+	}
+	return newTransfer(path, props, m.opts)
+}
+
+// GetBMessage pull and parse the message, the temporary file is removed
+func (m *Message1) GetBMessage() (*BMessage, error) {
+	return m.GetBMessageContext(context.Background())
+}
+
+// GetBMessageContext pull and parse the message, aborting when ctx is done
+func (m *Message1) GetBMessageContext(ctx context.Context) (*BMessage, error) {
+
+	transfer, err := m.GetContext(ctx, "", false)
+	if err != nil {
+		return nil, err
+	}
+	defer transfer.Close()
+	defer os.Remove(transfer.Properties.Filename)
+
+	err = transfer.waitFile(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(transfer.Properties.Filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseBMessage(f)
+}
@@ -0,0 +1,69 @@
+package obex
+
+import (
+	"context"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/bluez"
+	"github.com/saurabh-newera/BLE/bluez/profile"
+)
+
+// NewObjectPush1 create a new ObjectPush1 client on a session
+func NewObjectPush1(session string, opts ...profile.Option) *ObjectPush1 {
+	o := new(ObjectPush1)
+	o.client = newClient(session, ObjectPush1Interface, opts)
+	o.opts = opts
+	return o
+}
+
+// ObjectPush1 client
+type ObjectPush1 struct {
+	client *bluez.Client
+	opts   []profile.Option
+}
+
+// Close the connection
+func (o *ObjectPush1) Close() {
+	o.client.Disconnect()
+}
+
+// transfer call a method returning a transfer
+func (o *ObjectPush1) transfer(ctx context.Context, method string, args ...interface{}) (*Transfer1, error) {
+	var path dbus.ObjectPath
+	var props map[string]dbus.Variant
+	err := o.client.CallContext(ctx, method, 0, args...).Store(&path, &props)
+	if err != nil {
+		return nil, err
+	}
+	return newTransfer(path, props, o.opts)
+}
+
+// SendFile push a local file to the device
+func (o *ObjectPush1) SendFile(sourcefile string) (*Transfer1, error) {
+	return o.SendFileContext(context.Background(), sourcefile)
+}
+
+// SendFileContext push a local file to the device, aborting when ctx is done
+func (o *ObjectPush1) SendFileContext(ctx context.Context, sourcefile string) (*Transfer1, error) {
+	return o.transfer(ctx, "SendFile", sourcefile)
+}
+
+// PullBusinessCard pull the business card of the device to targetfile
+func (o *ObjectPush1) PullBusinessCard(targetfile string) (*Transfer1, error) {
+	return o.PullBusinessCardContext(context.Background(), targetfile)
+}
+
+// PullBusinessCardContext pull the business card of the device, aborting when ctx is done
+func (o *ObjectPush1) PullBusinessCardContext(ctx context.Context, targetfile string) (*Transfer1, error) {
+	return o.transfer(ctx, "PullBusinessCard", targetfile)
+}
+
+// ExchangeBusinessCards push clientfile and pull the business card of the device to targetfile
+func (o *ObjectPush1) ExchangeBusinessCards(clientfile string, targetfile string) (*Transfer1, error) {
+	return o.ExchangeBusinessCardsContext(context.Background(), clientfile, targetfile)
+}
+
+// ExchangeBusinessCardsContext exchange the business cards, aborting when ctx is done
+func (o *ObjectPush1) ExchangeBusinessCardsContext(ctx context.Context, clientfile string, targetfile string) (*Transfer1, error) {
+	return o.transfer(ctx, "ExchangeBusinessCards", clientfile, targetfile)
+}
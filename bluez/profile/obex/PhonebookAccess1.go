@@ -0,0 +1,257 @@
+package obex
+
+import (
+	"context"
+	"os"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/bluez"
+	"github.com/saurabh-newera/BLE/bluez/profile"
+	"github.com/saurabh-newera/BLE/util"
+)
+
+// The locations of the phonebooks, see PhonebookAccess1.Select
+const (
+	PhonebookLocationInternal = "int"
+	PhonebookLocationSIM1     = "sim1"
+)
+
+// The phonebooks of a location
+const (
+	// PhonebookContacts the main phonebook
+	PhonebookContacts = "pb"
+	// PhonebookIncoming the incoming calls history
+	PhonebookIncoming = "ich"
+	// PhonebookOutgoing the outgoing calls history
+	PhonebookOutgoing = "och"
+	// PhonebookMissed the missed calls history
+	PhonebookMissed = "mch"
+	// PhonebookCombined the combined calls history
+	PhonebookCombined = "cch"
+	// PhonebookSpeedDial the speed dial entries
+	PhonebookSpeedDial = "spd"
+	// PhonebookFavorites the favorite contacts
+	PhonebookFavorites = "fav"
+)
+
+// The vCard formats of PhonebookFilters.Format
+const (
+	VCardFormat21 = "vcard21"
+	VCardFormat30 = "vcard30"
+)
+
+// PhonebookFilters the filters of the phonebook methods, the empty fields are not sent
+type PhonebookFilters struct {
+	// Format is VCardFormat21 or VCardFormat30
+	Format string `dbus:"Format,omitempty"`
+	// Order of List and Search, indexed, alphanumeric or phonetic
+	Order string `dbus:"Order,omitempty"`
+	// Offset of the first entry
+	Offset uint16 `dbus:"Offset,omitempty"`
+	// MaxCount of entries, obexd default is 65535
+	MaxCount uint16 `dbus:"MaxCount,omitempty"`
+	// Fields of the vCards, see ListFilterFields
+	Fields []string `dbus:"Fields,omitempty"`
+}
+
+// PhonebookEntry an entry of List and Search
+type PhonebookEntry struct {
+	// Handle of the vCard, eg. 1.vcf, to use with Pull
+	Handle string
+	Name   string
+}
+
+// NewPhonebookAccess1 create a new PhonebookAccess1 client on a session
+func NewPhonebookAccess1(session string, opts ...profile.Option) *PhonebookAccess1 {
+	p := new(PhonebookAccess1)
+	p.client = newClient(session, PhonebookAccess1Interface, opts)
+	p.opts = opts
+	p.Properties = new(PhonebookAccess1Properties)
+	return p
+}
+
+// PhonebookAccess1 client
+type PhonebookAccess1 struct {
+	client     *bluez.Client
+	opts       []profile.Option
+	Properties *PhonebookAccess1Properties
+}
+
+// PhonebookAccess1Properties exposed properties for PhonebookAccess1
+type PhonebookAccess1Properties struct {
+	Folder             string
+	DatabaseIdentifier string
+	PrimaryCounter     string
+	SecondaryCounter   string
+	FixedImageSize     bool
+}
+
+// Close the connection
+func (p *PhonebookAccess1) Close() {
+	p.client.Disconnect()
+}
+
+// GetProperties load all available properties
+func (p *PhonebookAccess1) GetProperties() (*PhonebookAccess1Properties, error) {
+	return p.GetPropertiesContext(context.Background())
+}
+
+// GetPropertiesContext load all available properties, aborting when ctx is done
+func (p *PhonebookAccess1) GetPropertiesContext(ctx context.Context) (*PhonebookAccess1Properties, error) {
+	err := p.client.GetPropertiesContext(ctx, p.Properties)
+	return p.Properties, err
+}
+
+// Select the phonebook used by the other methods, eg. PhonebookLocationInternal and PhonebookContacts
+func (p *PhonebookAccess1) Select(location string, phonebook string) error {
+	return p.SelectContext(context.Background(), location, phonebook)
+}
+
+// SelectContext select the phonebook, aborting when ctx is done
+func (p *PhonebookAccess1) SelectContext(ctx context.Context, location string, phonebook string) error {
+	return p.client.CallContext(ctx, "Select", 0, location, phonebook).Store()
+}
+
+// toMap return the filters as expected by obexd
+func (f *PhonebookFilters) toMap() (map[string]dbus.Variant, error) {
+	if f == nil {
+		return map[string]dbus.Variant{}, nil
+	}
+	return util.StructToMap(f)
+}
+
+// transfer call a method returning a transfer
+func (p *PhonebookAccess1) transfer(ctx context.Context, method string, args ...interface{}) (*Transfer1, error) {
+	var path dbus.ObjectPath
+	var props map[string]dbus.Variant
+	err := p.client.CallContext(ctx, method, 0, args...).Store(&path, &props)
+	if err != nil {
+		return nil, err
+	}
+	return newTransfer(path, props, p.opts)
+}
+
+// PullAll pull the selected phonebook to targetfile, obexd creates a temporary file when it is empty
+func (p *PhonebookAccess1) PullAll(targetfile string, filters *PhonebookFilters) (*Transfer1, error) {
+	return p.PullAllContext(context.Background(), targetfile, filters)
+}
+
+// PullAllContext pull the selected phonebook, aborting when ctx is done
+func (p *PhonebookAccess1) PullAllContext(ctx context.Context, targetfile string, filters *PhonebookFilters) (*Transfer1, error) {
+	f, err := filters.toMap()
+	if err != nil {
+		return nil, err
+	}
+	return p.transfer(ctx, "PullAll", targetfile, f)
+}
+
+// Pull pull the vCard of a handle to targetfile
+func (p *PhonebookAccess1) Pull(vcard string, targetfile string, filters *PhonebookFilters) (*Transfer1, error) {
+	return p.PullContext(context.Background(), vcard, targetfile, filters)
+}
+
+// PullContext pull the vCard of a handle, aborting when ctx is done
+func (p *PhonebookAccess1) PullContext(ctx context.Context, vcard string, targetfile string, filters *PhonebookFilters) (*Transfer1, error) {
+	f, err := filters.toMap()
+	if err != nil {
+		return nil, err
+	}
+	return p.transfer(ctx, "Pull", vcard, targetfile, f)
+}
+
+// entries call a method returning a list of handles and names
+func (p *PhonebookAccess1) entries(ctx context.Context, method string, args ...interface{}) ([]PhonebookEntry, error) {
+	var entries []PhonebookEntry
+	err := p.client.CallContext(ctx, method, 0, args...).Store(&entries)
+	return entries, err
+}
+
+// List the entries of the selected phonebook
+func (p *PhonebookAccess1) List(filters *PhonebookFilters) ([]PhonebookEntry, error) {
+	return p.ListContext(context.Background(), filters)
+}
+
+// ListContext list the entries of the selected phonebook, aborting when ctx is done
+func (p *PhonebookAccess1) ListContext(ctx context.Context, filters *PhonebookFilters) ([]PhonebookEntry, error) {
+	f, err := filters.toMap()
+	if err != nil {
+		return nil, err
+	}
+	return p.entries(ctx, "List", f)
+}
+
+// Search the entries of the selected phonebook, field is name, number or sound
+func (p *PhonebookAccess1) Search(field string, value string, filters *PhonebookFilters) ([]PhonebookEntry, error) {
+	return p.SearchContext(context.Background(), field, value, filters)
+}
+
+// SearchContext search the entries of the selected phonebook, aborting when ctx is done
+func (p *PhonebookAccess1) SearchContext(ctx context.Context, field string, value string, filters *PhonebookFilters) ([]PhonebookEntry, error) {
+	f, err := filters.toMap()
+	if err != nil {
+		return nil, err
+	}
+	return p.entries(ctx, "Search", field, value, f)
+}
+
+// GetSize return the number of entries of the selected phonebook
+func (p *PhonebookAccess1) GetSize() (uint16, error) {
+	return p.GetSizeContext(context.Background())
+}
+
+// GetSizeContext return the number of entries, aborting when ctx is done
+func (p *PhonebookAccess1) GetSizeContext(ctx context.Context) (uint16, error) {
+	var size uint16
+	err := p.client.CallContext(ctx, "GetSize", 0).Store(&size)
+	return size, err
+}
+
+// UpdateVersion ask the device to update the counters of the phonebook
+func (p *PhonebookAccess1) UpdateVersion() error {
+	return p.UpdateVersionContext(context.Background())
+}
+
+// UpdateVersionContext update the counters of the phonebook, aborting when ctx is done
+func (p *PhonebookAccess1) UpdateVersionContext(ctx context.Context) error {
+	return p.client.CallContext(ctx, "UpdateVersion", 0).Store()
+}
+
+// ListFilterFields return the vCard fields usable in PhonebookFilters.Fields
+func (p *PhonebookAccess1) ListFilterFields() ([]string, error) {
+	return p.ListFilterFieldsContext(context.Background())
+}
+
+// ListFilterFieldsContext return the vCard fields of the filters, aborting when ctx is done
+func (p *PhonebookAccess1) ListFilterFieldsContext(ctx context.Context) ([]string, error) {
+	var fields []string
+	err := p.client.CallContext(ctx, "ListFilterFields", 0).Store(&fields)
+	return fields, err
+}
+
+// PullAllVCards pull and parse the selected phonebook, the temporary file is removed
+func (p *PhonebookAccess1) PullAllVCards(filters *PhonebookFilters) ([]VCard, error) {
+	return p.PullAllVCardsContext(context.Background(), filters)
+}
+
+// PullAllVCardsContext pull and parse the selected phonebook, aborting when ctx is done
+func (p *PhonebookAccess1) PullAllVCardsContext(ctx context.Context, filters *PhonebookFilters) ([]VCard, error) {
+
+	transfer, err := p.PullAllContext(ctx, "", filters)
+	if err != nil {
+		return nil, err
+	}
+	defer transfer.Close()
+	defer os.Remove(transfer.Properties.Filename)
+
+	err = transfer.waitFile(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(transfer.Properties.Filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseVCards(f)
+}
@@ -0,0 +1,75 @@
+package obex
+
+import (
+	"context"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/bluez"
+	"github.com/saurabh-newera/BLE/bluez/profile"
+)
+
+// NewSession1 create a new Session1 client
+func NewSession1(path string, opts ...profile.Option) *Session1 {
+	s := new(Session1)
+	s.client = newClient(path, Session1Interface, opts)
+	s.opts = opts
+	s.Path = dbus.ObjectPath(path)
+	s.Properties = new(Session1Properties)
+	return s
+}
+
+// Session1 client
+type Session1 struct {
+	client     *bluez.Client
+	opts       []profile.Option
+	Path       dbus.ObjectPath
+	Properties *Session1Properties
+}
+
+// Session1Properties exposed properties for Session1
+type Session1Properties struct {
+	Source      string
+	Destination string
+	Channel     byte
+	PSM         uint16
+	Target      string
+	Root        string
+}
+
+// Close the connection
+func (s *Session1) Close() {
+	s.client.Disconnect()
+}
+
+// GetProperties load all available properties
+func (s *Session1) GetProperties() (*Session1Properties, error) {
+	return s.GetPropertiesContext(context.Background())
+}
+
+// GetPropertiesContext load all available properties, aborting when ctx is done
+func (s *Session1) GetPropertiesContext(ctx context.Context) (*Session1Properties, error) {
+	err := s.client.GetPropertiesContext(ctx, s.Properties)
+	return s.Properties, err
+}
+
+// GetCapabilities return the OBEX capabilities object of the remote device, in XML
+func (s *Session1) GetCapabilities() (string, error) {
+	return s.GetCapabilitiesContext(context.Background())
+}
+
+// GetCapabilitiesContext return the capabilities of the remote device, aborting when ctx is done
+func (s *Session1) GetCapabilitiesContext(ctx context.Context) (string, error) {
+	var capabilities string
+	err := s.client.CallContext(ctx, "GetCapabilities", 0).Store(&capabilities)
+	return capabilities, err
+}
+
+// ObjectPush return the Object Push client of the session, its target must be TargetOPP
+func (s *Session1) ObjectPush() *ObjectPush1 {
+	return NewObjectPush1(string(s.Path), s.opts...)
+}
+
+// FileTransfer return the File Transfer client of the session, its target must be TargetFTP
+func (s *Session1) FileTransfer() *FileTransfer1 {
+	return NewFileTransfer1(string(s.Path), s.opts...)
+}
@@ -0,0 +1,236 @@
+package obex
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/bluez"
+	"github.com/saurabh-newera/BLE/bluez/profile"
+	"github.com/saurabh-newera/BLE/emitter"
+	"github.com/saurabh-newera/BLE/util"
+)
+
+// The status of a transfer
+const (
+	TransferStatusQueued    = "queued"
+	TransferStatusActive    = "active"
+	TransferStatusSuspended = "suspended"
+	TransferStatusComplete  = "complete"
+	TransferStatusError     = "error"
+)
+
+var (
+	// ErrTransferFailed is returned by Wait when the transfer ends with an error
+	ErrTransferFailed = errors.New("obex: transfer failed")
+	// ErrTransferRemoved is returned by Wait when obexd removed the transfer before its
+	// status could be read, eg. a small file was sent at once
+	ErrTransferRemoved = errors.New("obex: transfer removed before completion was observed")
+)
+
+// TransferEvent reports the progress of a transfer, emitted as "transfer" and
+// "<transfer path>.transfer" while waiting for it
+type TransferEvent struct {
+	Path        string
+	Status      string
+	Transferred uint64
+	Size        uint64
+	Filename    string
+}
+
+// NewTransfer1 create a new Transfer1 client
+func NewTransfer1(path string, opts ...profile.Option) *Transfer1 {
+	t := new(Transfer1)
+	t.client = newClient(path, Transfer1Interface, opts)
+	t.Path = dbus.ObjectPath(path)
+	t.Properties = new(Transfer1Properties)
+	return t
+}
+
+// newTransfer create a transfer client from the path and properties returned by obexd
+func newTransfer(path dbus.ObjectPath, props map[string]dbus.Variant, opts []profile.Option) (*Transfer1, error) {
+	t := NewTransfer1(string(path), opts...)
+	err := util.MapToStruct(t.Properties, props)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Transfer1 client
+type Transfer1 struct {
+	client     *bluez.Client
+	Path       dbus.ObjectPath
+	Properties *Transfer1Properties
+}
+
+// Transfer1Properties exposed properties for Transfer1
+type Transfer1Properties struct {
+	Status      string
+	Session     dbus.ObjectPath
+	Name        string
+	Type        string
+	Time        uint64
+	Size        uint64
+	Transferred uint64
+	Filename    string
+}
+
+// Close the connection
+func (t *Transfer1) Close() {
+	t.client.Disconnect()
+}
+
+// GetProperties load all available properties
+func (t *Transfer1) GetProperties() (*Transfer1Properties, error) {
+	return t.GetPropertiesContext(context.Background())
+}
+
+// GetPropertiesContext load all available properties, aborting when ctx is done
+func (t *Transfer1) GetPropertiesContext(ctx context.Context) (*Transfer1Properties, error) {
+	err := t.client.GetPropertiesContext(ctx, t.Properties)
+	return t.Properties, err
+}
+
+// Cancel stop the transfer
+func (t *Transfer1) Cancel() error {
+	return t.CancelContext(context.Background())
+}
+
+// CancelContext stop the transfer, aborting when ctx is done
+func (t *Transfer1) CancelContext(ctx context.Context) error {
+	return t.client.CallContext(ctx, "Cancel", 0).Store()
+}
+
+// Suspend pause the transfer
+func (t *Transfer1) Suspend() error {
+	return t.SuspendContext(context.Background())
+}
+
+// SuspendContext pause the transfer, aborting when ctx is done
+func (t *Transfer1) SuspendContext(ctx context.Context) error {
+	return t.client.CallContext(ctx, "Suspend", 0).Store()
+}
+
+// Resume continue a suspended transfer
+func (t *Transfer1) Resume() error {
+	return t.ResumeContext(context.Background())
+}
+
+// ResumeContext continue a suspended transfer, aborting when ctx is done
+func (t *Transfer1) ResumeContext(ctx context.Context) error {
+	return t.client.CallContext(ctx, "Resume", 0).Store()
+}
+
+// WatchProperties return a channel receiving the property changes
+func (t *Transfer1) WatchProperties() (chan *bluez.PropertyChange, error) {
+	return t.client.WatchProperties()
+}
+
+// UnwatchProperties stop the delivery of property changes to channel and close it
+func (t *Transfer1) UnwatchProperties(channel chan *bluez.PropertyChange) {
+	t.client.UnwatchProperties(channel)
+}
+
+// emit send the progress of the transfer to the emitter
+func (t *Transfer1) emit() {
+	ev := TransferEvent{
+		Path:        string(t.Path),
+		Status:      t.Properties.Status,
+		Transferred: t.Properties.Transferred,
+		Size:        t.Properties.Size,
+		Filename:    t.Properties.Filename,
+	}
+	emitter.Emit("transfer", ev)
+	emitter.Emit(string(t.Path)+".transfer", ev)
+}
+
+// Wait follow the transfer until it completes, emitting its progress as TransferEvent
+func (t *Transfer1) Wait() error {
+	return t.WaitContext(context.Background())
+}
+
+// WaitContext follow the transfer until it completes, aborting when ctx is done.
+// The transfer is not canceled when ctx is done. ErrTransferRemoved is returned if
+// the transfer or obexd goes away before the transfer ends
+func (t *Transfer1) WaitContext(ctx context.Context) error {
+
+	// obexd exiting does not remove its objects
+	owners, err := t.client.Subscribe(bluez.NameOwnerChangedMatch(t.client.Config.Name))
+	if err != nil {
+		return err
+	}
+	defer t.client.Unsubscribe(owners)
+
+	cache, err := t.client.PropertyCache()
+	if err != nil {
+		if errors.Is(err, bluez.ErrUnknownObject) {
+			return ErrTransferRemoved
+		}
+		return err
+	}
+	// the status may have changed before watching, the next changes follow the snapshot
+	changes, values := cache.WatchSnapshot()
+	defer cache.Unwatch(changes)
+
+	for {
+		err = util.MapToStruct(t.Properties, values)
+		if err != nil {
+			return err
+		}
+		t.emit()
+
+		switch t.Properties.Status {
+		case TransferStatusComplete:
+			return nil
+		case TransferStatusError:
+			return ErrTransferFailed
+		}
+
+		values = nil
+		for values == nil {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case sig, ok := <-owners:
+				if !ok {
+					owners = nil
+				} else if len(sig.Body) > 2 && sig.Body[2] == "" {
+					return ErrTransferRemoved
+				}
+			case change, ok := <-changes:
+				if !ok {
+					return ErrTransferRemoved
+				}
+				values = change.Snapshot
+			}
+		}
+	}
+}
+
+// waitFile wait for a transfer to a file. A transfer removed before its completion was
+// observed succeeds if its file is complete, eg. a small file received at once
+func (t *Transfer1) waitFile(ctx context.Context) error {
+	err := t.WaitContext(ctx)
+	if errors.Is(err, ErrTransferRemoved) && t.fileComplete() {
+		return nil
+	}
+	return err
+}
+
+// fileComplete check if the file of the transfer has the announced size, or is not
+// empty when the size is unknown
+func (t *Transfer1) fileComplete() bool {
+	if t.Properties.Filename == "" {
+		return false
+	}
+	info, err := os.Stat(t.Properties.Filename)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	if t.Properties.Size == 0 {
+		return info.Size() > 0
+	}
+	return uint64(info.Size()) == t.Properties.Size
+}
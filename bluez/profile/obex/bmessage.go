@@ -0,0 +1,238 @@
+package obex
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// The types of a bMessage
+const (
+	BMessageTypeEmail   = "EMAIL"
+	BMessageTypeSMSGSM  = "SMS_GSM"
+	BMessageTypeSMSCDMA = "SMS_CDMA"
+	BMessageTypeMMS     = "MMS"
+	BMessageTypeIM      = "IM"
+)
+
+// BMessageBody the content of a bMessage
+type BMessageBody struct {
+	PartID   string
+	Encoding string
+	Charset  string
+	Language string
+	// Length declared by the message, it includes the BEGIN:MSG and END:MSG lines
+	Length int
+	// Content is the text of the MSG parts, joined by new lines
+	Content string
+}
+
+// BMessage a message pulled with Message1.Get, in the bMessage format of MAP
+type BMessage struct {
+	Version string
+	// Status is READ or UNREAD
+	Status string
+	// Type eg. BMessageTypeSMSGSM
+	Type   string
+	Folder string
+	// Originators the senders of the message, usually one
+	Originators []VCard
+	// Recipients of the message, the nested envelopes are flattened
+	Recipients []VCard
+	Body       BMessageBody
+}
+
+// bMessageParser parse the lines of a bMessage, pos is the offset of the next line in data
+type bMessageParser struct {
+	data []byte
+	pos  int
+}
+
+// next return the next line without its line break
+func (p *bMessageParser) next() (string, bool) {
+	if p.pos >= len(p.data) {
+		return "", false
+	}
+	line := p.data[p.pos:]
+	end := bytes.IndexByte(line, '\n')
+	if end < 0 {
+		p.pos = len(p.data)
+	} else {
+		line = line[:end]
+		p.pos += end + 1
+	}
+	return strings.TrimSuffix(string(line), "\r"), true
+}
+
+// vcard collect the lines until END:VCARD and parse them
+func (p *bMessageParser) vcard() (VCard, error) {
+	lines := []string{"BEGIN:VCARD"}
+	for {
+		line, ok := p.next()
+		if !ok {
+			return VCard{}, errors.New("Missing END:VCARD")
+		}
+		lines = append(lines, line)
+		if strings.EqualFold(line, "END:VCARD") {
+			break
+		}
+	}
+	cards, err := ParseVCards(strings.NewReader(strings.Join(lines, "\n")))
+	if err != nil {
+		return VCard{}, err
+	}
+	if len(cards) == 0 {
+		return VCard{}, errors.New("Invalid vCard in bMessage")
+	}
+	return cards[0], nil
+}
+
+// ParseBMessage parse a bMessage, eg. the file of Message1.Get
+func ParseBMessage(r io.Reader) (*BMessage, error) {
+
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	p := &bMessageParser{data: b}
+
+	line, _ := p.next()
+	if !strings.EqualFold(line, "BEGIN:BMSG") {
+		return nil, fmt.Errorf("Expected BEGIN:BMSG, got %q", line)
+	}
+
+	msg := new(BMessage)
+	// depth of the envelopes, the originators are outside of any envelope
+	envelopes := 0
+	for {
+		line, ok := p.next()
+		if !ok {
+			return nil, errors.New("Missing END:BMSG")
+		}
+		key, value := splitLine(line)
+
+		switch {
+		case key == "END" && value == "BMSG":
+			return msg, nil
+		case key == "VERSION":
+			msg.Version = value
+		case key == "STATUS":
+			msg.Status = value
+		case key == "TYPE":
+			msg.Type = value
+		case key == "FOLDER":
+			msg.Folder = value
+		case key == "BEGIN" && value == "VCARD":
+			card, err := p.vcard()
+			if err != nil {
+				return nil, err
+			}
+			if envelopes == 0 {
+				msg.Originators = append(msg.Originators, card)
+			} else {
+				msg.Recipients = append(msg.Recipients, card)
+			}
+		case key == "BEGIN" && value == "BENV":
+			envelopes++
+		case key == "END" && value == "BENV":
+			envelopes--
+		case key == "BEGIN" && value == "BBODY":
+			err = p.body(&msg.Body)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// body parse a BBODY until END:BBODY
+func (p *bMessageParser) body(body *BMessageBody) error {
+
+	var parts []string
+	for {
+		start := p.pos
+		line, ok := p.next()
+		if !ok {
+			return errors.New("Missing END:BBODY")
+		}
+		key, value := splitLine(line)
+
+		switch {
+		case key == "END" && value == "BBODY":
+			body.Content = strings.Join(parts, "\n")
+			return nil
+		case key == "PARTID":
+			body.PartID = value
+		case key == "ENCODING":
+			body.Encoding = value
+		case key == "CHARSET":
+			body.Charset = value
+		case key == "LANGUAGE":
+			body.Language = value
+		case key == "LENGTH":
+			length, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("Invalid LENGTH %q", value)
+			}
+			body.Length = length
+		case key == "BEGIN" && value == "MSG":
+			if content, ok := p.messages(start, body.Length); ok {
+				parts = append(parts, content...)
+				continue
+			}
+			// LENGTH is missing or wrong, END:MSG ends the part
+			var content []string
+			for {
+				line, ok := p.next()
+				if !ok {
+					return errors.New("Missing END:MSG")
+				}
+				if line == "END:MSG" {
+					break
+				}
+				content = append(content, line)
+			}
+			parts = append(parts, strings.Join(content, "\n"))
+		}
+	}
+}
+
+// messages read the MSG parts covered by length, counted in bytes from start, the
+// first BEGIN:MSG line, to the end of the last END:MSG line as required by MAP.
+// False if length does not end on an END:MSG line
+func (p *bMessageParser) messages(start int, length int) ([]string, bool) {
+
+	end := start + length
+	if length <= 0 || end > len(p.data) {
+		return nil, false
+	}
+	data := strings.Replace(string(p.data[start:end]), "\r\n", "\n", -1)
+	data = strings.TrimSuffix(data, "\n")
+	if !strings.HasPrefix(data, "BEGIN:MSG\n") || !strings.HasSuffix(data, "\nEND:MSG") {
+		return nil, false
+	}
+	data = data[len("BEGIN:MSG\n") : len(data)-len("END:MSG")]
+	data = strings.TrimSuffix(data, "\n")
+
+	p.pos = end
+	return strings.Split(data, "\nEND:MSG\nBEGIN:MSG\n"), true
+}
+
+// splitLine return the upper case key and the value of a line, the value of BEGIN
+// and END is upper case too
+func splitLine(line string) (string, string) {
+	colon := strings.Index(line, ":")
+	if colon < 0 {
+		return strings.ToUpper(strings.TrimSpace(line)), ""
+	}
+	key := strings.ToUpper(strings.TrimSpace(line[:colon]))
+	value := strings.TrimSpace(line[colon+1:])
+	if key == "BEGIN" || key == "END" {
+		value = strings.ToUpper(value)
+	}
+	return key, value
+}
@@ -0,0 +1,98 @@
+package obex
+
+import (
+	"strings"
+	"testing"
+)
+
+const testBMessage = "BEGIN:BMSG\r\n" +
+	"VERSION:1.0\r\n" +
+	"STATUS:UNREAD\r\n" +
+	"TYPE:SMS_GSM\r\n" +
+	"FOLDER:telecom/msg/inbox\r\n" +
+	"BEGIN:VCARD\r\n" +
+	"VERSION:2.1\r\n" +
+	"N:Doe;John\r\n" +
+	"TEL:+33600000000\r\n" +
+	"END:VCARD\r\n" +
+	"BEGIN:BENV\r\n" +
+	"BEGIN:VCARD\r\n" +
+	"VERSION:2.1\r\n" +
+	"TEL:+33611111111\r\n" +
+	"END:VCARD\r\n" +
+	"BEGIN:BBODY\r\n" +
+	"CHARSET:UTF-8\r\n" +
+	"LENGTH:34\r\n" +
+	"BEGIN:MSG\r\n" +
+	"Hello\r\n" +
+	"world\r\n" +
+	"END:MSG\r\n" +
+	"END:BBODY\r\n" +
+	"END:BENV\r\n" +
+	"END:BMSG\r\n"
+
+func TestParseBMessage(t *testing.T) {
+
+	msg, err := ParseBMessage(strings.NewReader(testBMessage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Version != "1.0" || msg.Status != "UNREAD" || msg.Type != BMessageTypeSMSGSM || msg.Folder != "telecom/msg/inbox" {
+		t.Fatalf("Unexpected message %+v", msg)
+	}
+	if len(msg.Originators) != 1 || msg.Originators[0].Name.Family != "Doe" {
+		t.Fatalf("Unexpected originators %+v", msg.Originators)
+	}
+	if len(msg.Recipients) != 1 || msg.Recipients[0].Telephones[0].Number != "+33611111111" {
+		t.Fatalf("Unexpected recipients %+v", msg.Recipients)
+	}
+	if msg.Body.Charset != "UTF-8" || msg.Body.Length != 34 || msg.Body.Content != "Hello\nworld" {
+		t.Fatalf("Unexpected body %+v", msg.Body)
+	}
+}
+
+func TestParseBMessageErrors(t *testing.T) {
+	for _, data := range []string{
+		"BEGIN:VCARD\nEND:VCARD\n",
+		"BEGIN:BMSG\nVERSION:1.0\n",
+		"BEGIN:BMSG\nBEGIN:BBODY\nLENGTH:x\nEND:BBODY\nEND:BMSG\n",
+		"BEGIN:BMSG\nBEGIN:BBODY\nBEGIN:MSG\nHello\n",
+		"BEGIN:BMSG\r\nBEGIN:VCARD\r\n x\r\nN;ENCODING=QUOTED-PRINTABLE:a=\r\nEND:VCARD\r\nEND:BMSG\r\n",
+	} {
+		if _, err := ParseBMessage(strings.NewReader(data)); err == nil {
+			t.Fatalf("Expected an error for %q", data)
+		}
+	}
+}
+
+func TestParseBMessageLength(t *testing.T) {
+
+	// the content holds an END:MSG line, only LENGTH tells where the part ends
+	data := "BEGIN:BMSG\r\n" +
+		"BEGIN:BBODY\r\n" +
+		"LENGTH:43\r\n" +
+		"BEGIN:MSG\r\n" +
+		"Hello\r\n" +
+		"END:MSG\r\n" +
+		"world\r\n" +
+		"END:MSG\r\n" +
+		"END:BBODY\r\n" +
+		"END:BMSG\r\n"
+	msg, err := ParseBMessage(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Body.Content != "Hello\nEND:MSG\nworld" {
+		t.Fatalf("Unexpected content %q", msg.Body.Content)
+	}
+
+	// a wrong LENGTH falls back to the END:MSG line
+	data = strings.Replace(data, "LENGTH:43", "LENGTH:20", 1)
+	msg, err = ParseBMessage(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Body.Content != "Hello" {
+		t.Fatalf("Unexpected content %q", msg.Body.Content)
+	}
+}
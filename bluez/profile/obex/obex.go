@@ -0,0 +1,49 @@
+// Package obex implement the clients of the bluez OBEX daemon, obexd, exposed as
+// org.bluez.obex on the session bus. Sessions are created with Client1 and the
+// transfers of files are followed with Transfer1
+package obex
+
+import (
+	"github.com/saurabh-newera/BLE/bluez"
+	"github.com/saurabh-newera/BLE/bluez/profile"
+)
+
+// ServiceName the bus name of obexd
+const ServiceName = "org.bluez.obex"
+
+// RootPath the object path of the OBEX client manager
+const RootPath = "/org/bluez/obex"
+
+// The interfaces of obexd
+const (
+	//Client1Interface the interface creating the sessions
+	Client1Interface = "org.bluez.obex.Client1"
+	//Session1Interface the interface of a session
+	Session1Interface = "org.bluez.obex.Session1"
+	//Transfer1Interface the interface of a transfer
+	Transfer1Interface = "org.bluez.obex.Transfer1"
+	//ObjectPush1Interface the Object Push profile of a session
+	ObjectPush1Interface = "org.bluez.obex.ObjectPush1"
+	//FileTransfer1Interface the File Transfer profile of a session
+	FileTransfer1Interface = "org.bluez.obex.FileTransfer1"
+	//PhonebookAccess1Interface the Phonebook Access profile of a session
+	PhonebookAccess1Interface = "org.bluez.obex.PhonebookAccess1"
+	//MessageAccess1Interface the Message Access profile of a session
+	MessageAccess1Interface = "org.bluez.obex.MessageAccess1"
+	//Message1Interface the interface of a message listed by MessageAccess1
+	Message1Interface = "org.bluez.obex.Message1"
+)
+
+// newClient create a bluez.Client on the session bus for config, applying the options
+func newClient(path string, iface string, opts []profile.Option) *bluez.Client {
+	config := &bluez.Config{
+		Name:  ServiceName,
+		Iface: iface,
+		Path:  path,
+		Bus:   bluez.SessionBus,
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+	return bluez.NewClient(config)
+}
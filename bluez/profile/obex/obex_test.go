@@ -0,0 +1,72 @@
+package obex
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/util"
+)
+
+func TestFolderEntry(t *testing.T) {
+	entry := FolderEntry{}
+	err := util.MapToStruct(&entry, map[string]dbus.Variant{
+		"Name":      dbus.MakeVariant("log.txt"),
+		"Type":      dbus.MakeVariant("file"),
+		"Size":      dbus.MakeVariant(uint64(2048)),
+		"User-perm": dbus.MakeVariant("RW"),
+		"Mime-type": dbus.MakeVariant("text/plain"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Name != "log.txt" || entry.Size != 2048 || entry.UserPermission != "RW" {
+		t.Fatalf("Unexpected entry %+v", entry)
+	}
+	if _, ok := entry.Unknown["Mime-type"]; !ok {
+		t.Fatalf("Expected Mime-type in Unknown, got %v", entry.Unknown)
+	}
+}
+
+func TestNewTransfer(t *testing.T) {
+	transfer, err := newTransfer("/org/bluez/obex/client/session0/transfer0", map[string]dbus.Variant{
+		"Status":   dbus.MakeVariant(TransferStatusQueued),
+		"Size":     dbus.MakeVariant(uint64(300)),
+		"Filename": dbus.MakeVariant("/tmp/log.txt"),
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transfer.Properties.Status != TransferStatusQueued || transfer.Properties.Size != 300 {
+		t.Fatalf("Unexpected properties %+v", transfer.Properties)
+	}
+}
+
+func TestTransferFileComplete(t *testing.T) {
+
+	f, err := ioutil.TempFile("", "transfer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("BEGIN:VCARD\r\nEND:VCARD\r\n")
+	f.Close()
+
+	transfer := NewTransfer1("/org/bluez/obex/client/session0/transfer0")
+	if transfer.fileComplete() {
+		t.Fatal("Expected an incomplete transfer without file")
+	}
+	transfer.Properties.Filename = f.Name()
+	if !transfer.fileComplete() {
+		t.Fatal("Expected a complete transfer of unknown size")
+	}
+	transfer.Properties.Size = 300
+	if transfer.fileComplete() {
+		t.Fatal("Expected an incomplete transfer")
+	}
+	transfer.Properties.Size = 24
+	if !transfer.fileComplete() {
+		t.Fatal("Expected a complete transfer")
+	}
+}
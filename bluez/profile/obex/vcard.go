@@ -0,0 +1,354 @@
+package obex
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Property a line of a vCard, eg. TEL;TYPE=CELL:+33600000000
+type Property struct {
+	Group string
+	Name  string
+	// Params by upper case name, the bare vCard 2.1 parameters are TYPE values
+	Params map[string][]string
+	// Value decoded from quoted-printable, vCard 3.0 escapes are kept
+	Value string
+}
+
+// HasType check if the property has a TYPE parameter, case insensitive
+func (p *Property) HasType(t string) bool {
+	for _, v := range p.Params["TYPE"] {
+		if strings.EqualFold(v, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Name the structured name of a vCard
+type Name struct {
+	Family     string
+	Given      string
+	Additional string
+	Prefix     string
+	Suffix     string
+}
+
+// Telephone a phone number with its types, eg. CELL or HOME
+type Telephone struct {
+	Number string
+	Types  []string
+}
+
+// Email an email address with its types
+type Email struct {
+	Address string
+	Types   []string
+}
+
+// Address a postal address with its types
+type Address struct {
+	Types      []string
+	POBox      string
+	Extended   string
+	Street     string
+	Locality   string
+	Region     string
+	PostalCode string
+	Country    string
+}
+
+// CallDateTime the date of a call history entry, Type is MISSED, RECEIVED or DIALED
+type CallDateTime struct {
+	Type string
+	Time string
+}
+
+// VCard a contact pulled with PhonebookAccess1, vCard 2.1 or 3.0
+type VCard struct {
+	Version       string
+	FormattedName string
+	Name          Name
+	Telephones    []Telephone
+	Emails        []Email
+	Addresses     []Address
+	Organization  string
+	Title         string
+	Birthday      string
+	Note          string
+	URL           string
+	UID           string
+	Photo         []byte
+	// CallDateTime is set for the entries of the call history phonebooks, eg. ich
+	CallDateTime *CallDateTime
+	// Properties all the lines of the vCard, in order
+	Properties []Property
+}
+
+// Get return the first property named name, case insensitive
+func (v *VCard) Get(name string) (*Property, bool) {
+	for i := range v.Properties {
+		if strings.EqualFold(v.Properties[i].Name, name) {
+			return &v.Properties[i], true
+		}
+	}
+	return nil, false
+}
+
+// ParseVCards parse the vCards of a phonebook, eg. the file of PullAll
+func ParseVCards(r io.Reader) ([]VCard, error) {
+
+	lines, err := unfoldLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var cards []VCard
+	var card *VCard
+	for i, line := range lines {
+		if line == "" {
+			continue
+		}
+		prop, err := parseProperty(line)
+		if err != nil {
+			return nil, fmt.Errorf("Line %d: %w", i+1, err)
+		}
+		switch {
+		case prop.Name == "BEGIN" && strings.EqualFold(prop.Value, "VCARD"):
+			card = &VCard{}
+		case prop.Name == "END" && strings.EqualFold(prop.Value, "VCARD"):
+			if card == nil {
+				return nil, fmt.Errorf("Line %d: END:VCARD without BEGIN", i+1)
+			}
+			cards = append(cards, *card)
+			card = nil
+		case card != nil:
+			card.add(prop)
+		}
+	}
+	if card != nil {
+		return nil, errors.New("Missing END:VCARD")
+	}
+	return cards, nil
+}
+
+// unfoldLines join the folded lines of vCard 3.0 and the soft line breaks of quoted-printable values
+func unfoldLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	softBreak := false
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		switch {
+		case softBreak && len(lines) > 0:
+			lines[len(lines)-1] += line
+		case (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0:
+			lines[len(lines)-1] += line[1:]
+		default:
+			lines = append(lines, line)
+		}
+		last := lines[len(lines)-1]
+		softBreak = strings.HasSuffix(last, "=") && isQuotedPrintable(last)
+		if softBreak {
+			lines[len(lines)-1] = strings.TrimSuffix(last, "=")
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// isQuotedPrintable check if a raw line declares a quoted-printable value
+func isQuotedPrintable(line string) bool {
+	colon := strings.Index(line, ":")
+	if colon < 0 {
+		return false
+	}
+	return strings.Contains(strings.ToUpper(line[:colon]), "QUOTED-PRINTABLE")
+}
+
+// parseProperty parse an unfolded line
+func parseProperty(line string) (Property, error) {
+
+	prop := Property{Params: make(map[string][]string)}
+
+	colon := strings.Index(line, ":")
+	if colon < 0 {
+		return prop, fmt.Errorf("Invalid property %q", line)
+	}
+	head := strings.Split(line[:colon], ";")
+	prop.Value = line[colon+1:]
+
+	name := head[0]
+	if dot := strings.Index(name, "."); dot >= 0 {
+		prop.Group = name[:dot]
+		name = name[dot+1:]
+	}
+	prop.Name = strings.ToUpper(name)
+
+	for _, param := range head[1:] {
+		key, values := "TYPE", param
+		if eq := strings.Index(param, "="); eq >= 0 {
+			key, values = strings.ToUpper(param[:eq]), param[eq+1:]
+		}
+		for _, v := range strings.Split(values, ",") {
+			prop.Params[key] = append(prop.Params[key], strings.Trim(v, `"`))
+		}
+	}
+
+	for _, encoding := range prop.Params["ENCODING"] {
+		if strings.EqualFold(encoding, "QUOTED-PRINTABLE") {
+			prop.Value = decodeQuotedPrintable(prop.Value)
+		}
+	}
+	return prop, nil
+}
+
+// decodeQuotedPrintable decode =XX sequences, invalid sequences are kept
+func decodeQuotedPrintable(s string) string {
+	var b bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		if s[i] == '=' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]) {
+			b.WriteByte(unhex(s[i+1])<<4 | unhex(s[i+2]))
+			i += 2
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func isHex(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func unhex(c byte) byte {
+	switch {
+	case c >= 'a':
+		return c - 'a' + 10
+	case c >= 'A':
+		return c - 'A' + 10
+	}
+	return c - '0'
+}
+
+// splitValue split a compound value on the unescaped separators and unescape the parts
+func splitValue(value string, sep byte) []string {
+	var parts []string
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c == '\\' && i+1 < len(value) {
+			i++
+			switch value[i] {
+			case 'n', 'N':
+				b.WriteByte('\n')
+			default:
+				b.WriteByte(value[i])
+			}
+			continue
+		}
+		if c == sep {
+			parts = append(parts, b.String())
+			b.Reset()
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return append(parts, b.String())
+}
+
+// unescape decode the escapes of a text value
+func unescape(value string) string {
+	return splitValue(value, 0)[0]
+}
+
+// field return the part i of a compound value, empty if missing
+func field(parts []string, i int) string {
+	if i < len(parts) {
+		return parts[i]
+	}
+	return ""
+}
+
+// types return the TYPE parameters of a property, without the encoding related ones
+func (p *Property) types() []string {
+	var types []string
+	for _, t := range p.Params["TYPE"] {
+		switch strings.ToUpper(t) {
+		case "QUOTED-PRINTABLE", "BASE64", "B", "8BIT":
+			continue
+		}
+		types = append(types, strings.ToUpper(t))
+	}
+	return types
+}
+
+// add set the fields matching a property
+func (v *VCard) add(prop Property) {
+
+	v.Properties = append(v.Properties, prop)
+
+	switch prop.Name {
+	case "VERSION":
+		v.Version = prop.Value
+	case "FN":
+		v.FormattedName = unescape(prop.Value)
+	case "N":
+		parts := splitValue(prop.Value, ';')
+		v.Name = Name{
+			Family:     field(parts, 0),
+			Given:      field(parts, 1),
+			Additional: field(parts, 2),
+			Prefix:     field(parts, 3),
+			Suffix:     field(parts, 4),
+		}
+	case "TEL":
+		v.Telephones = append(v.Telephones, Telephone{Number: unescape(prop.Value), Types: prop.types()})
+	case "EMAIL":
+		v.Emails = append(v.Emails, Email{Address: unescape(prop.Value), Types: prop.types()})
+	case "ADR":
+		parts := splitValue(prop.Value, ';')
+		v.Addresses = append(v.Addresses, Address{
+			Types:      prop.types(),
+			POBox:      field(parts, 0),
+			Extended:   field(parts, 1),
+			Street:     field(parts, 2),
+			Locality:   field(parts, 3),
+			Region:     field(parts, 4),
+			PostalCode: field(parts, 5),
+			Country:    field(parts, 6),
+		})
+	case "ORG":
+		v.Organization = strings.TrimRight(strings.Join(splitValue(prop.Value, ';'), ";"), ";")
+	case "TITLE":
+		v.Title = unescape(prop.Value)
+	case "BDAY":
+		v.Birthday = prop.Value
+	case "NOTE":
+		v.Note = unescape(prop.Value)
+	case "URL":
+		v.URL = prop.Value
+	case "UID":
+		v.UID = prop.Value
+	case "PHOTO":
+		for _, encoding := range append(prop.Params["ENCODING"], prop.Params["TYPE"]...) {
+			if strings.EqualFold(encoding, "BASE64") || strings.EqualFold(encoding, "B") {
+				if b, err := base64.StdEncoding.DecodeString(strings.Replace(prop.Value, " ", "", -1)); err == nil {
+					v.Photo = b
+				}
+			}
+		}
+	case "X-IRMC-CALL-DATETIME":
+		types := prop.types()
+		t := ""
+		if len(types) > 0 {
+			t = types[0]
+		}
+		v.CallDateTime = &CallDateTime{Type: t, Time: prop.Value}
+	}
+}
@@ -0,0 +1,105 @@
+package obex
+
+import (
+	"strings"
+	"testing"
+)
+
+const testVCards = "BEGIN:VCARD\r\n" +
+	"VERSION:2.1\r\n" +
+	"N;CHARSET=UTF-8;ENCODING=QUOTED-PRINTABLE:Dupr=C3=A9;Jean;;;\r\n" +
+	"FN:Jean Dupré\r\n" +
+	"TEL;CELL:+33600000000\r\n" +
+	"TEL;HOME;VOICE:0100000000\r\n" +
+	"NOTE;ENCODING=QUOTED-PRINTABLE:first line=0D=0A=\r\n" +
+	"second line\r\n" +
+	"X-IRMC-CALL-DATETIME;MISSED:20200101T101010\r\n" +
+	"END:VCARD\r\n" +
+	"BEGIN:VCARD\r\n" +
+	"VERSION:3.0\r\n" +
+	"FN:Jane Doe\r\n" +
+	"N:Doe;Jane;;Dr.;\r\n" +
+	"EMAIL;TYPE=INTERNET,WORK:jane@example.com\r\n" +
+	"ADR;TYPE=HOME:;;1 Main St\\, Apt 2;Springfield;;12345;USA\r\n" +
+	"NOTE:a long note folded\r\n" +
+	"  over two lines\\nwith a break\r\n" +
+	"item1.URL:http://example.com\r\n" +
+	"PHOTO;ENCODING=b;TYPE=JPEG:AQID\r\n" +
+	"END:VCARD\r\n"
+
+func TestParseVCards(t *testing.T) {
+
+	cards, err := ParseVCards(strings.NewReader(testVCards))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cards) != 2 {
+		t.Fatalf("Expected 2 vCards, got %d", len(cards))
+	}
+
+	card := cards[0]
+	if card.Version != "2.1" || card.FormattedName != "Jean Dupré" {
+		t.Fatalf("Unexpected vCard %+v", card)
+	}
+	if card.Name.Family != "Dupré" || card.Name.Given != "Jean" {
+		t.Fatalf("Unexpected name %+v", card.Name)
+	}
+	if len(card.Telephones) != 2 || card.Telephones[0].Number != "+33600000000" || card.Telephones[0].Types[0] != "CELL" {
+		t.Fatalf("Unexpected telephones %+v", card.Telephones)
+	}
+	if card.Note != "first line\r\nsecond line" {
+		t.Fatalf("Unexpected note %q", card.Note)
+	}
+	if card.CallDateTime == nil || card.CallDateTime.Type != "MISSED" || card.CallDateTime.Time != "20200101T101010" {
+		t.Fatalf("Unexpected call date %+v", card.CallDateTime)
+	}
+
+	card = cards[1]
+	if card.Name.Prefix != "Dr." || card.Name.Family != "Doe" {
+		t.Fatalf("Unexpected name %+v", card.Name)
+	}
+	if len(card.Emails) != 1 || card.Emails[0].Address != "jane@example.com" || len(card.Emails[0].Types) != 2 {
+		t.Fatalf("Unexpected emails %+v", card.Emails)
+	}
+	if len(card.Addresses) != 1 || card.Addresses[0].Street != "1 Main St, Apt 2" || card.Addresses[0].Country != "USA" {
+		t.Fatalf("Unexpected addresses %+v", card.Addresses)
+	}
+	if card.Note != "a long note folded over two lines\nwith a break" {
+		t.Fatalf("Unexpected note %q", card.Note)
+	}
+	if string(card.Photo) != "\x01\x02\x03" {
+		t.Fatalf("Unexpected photo %v", card.Photo)
+	}
+	url, ok := card.Get("url")
+	if !ok || url.Group != "item1" || url.Value != "http://example.com" {
+		t.Fatalf("Unexpected URL %+v", url)
+	}
+}
+
+func TestParseVCardsErrors(t *testing.T) {
+	for _, data := range []string{
+		"BEGIN:VCARD\nFN:Jane\n",
+		"FN:Jane\nEND:VCARD\n",
+		"BEGIN:VCARD\ninvalid\nEND:VCARD\n",
+	} {
+		if _, err := ParseVCards(strings.NewReader(data)); err == nil {
+			t.Fatalf("Expected an error for %q", data)
+		}
+	}
+}
+
+func TestPhonebookFilters(t *testing.T) {
+	var filters *PhonebookFilters
+	m, err := filters.toMap()
+	if err != nil || len(m) != 0 {
+		t.Fatalf("Expected no filters, got %v %v", m, err)
+	}
+	filters = &PhonebookFilters{Format: VCardFormat30, MaxCount: 10}
+	m, err = filters.toMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m) != 2 || m["Format"].Value() != VCardFormat30 || m["MaxCount"].Value() != uint16(10) {
+		t.Fatalf("Unexpected filters %v", m)
+	}
+}
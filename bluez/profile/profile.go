@@ -1,6 +1,36 @@
 package profile
 
+import (
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/bluez"
+)
+
 /*import "github.com/tj/go-debug"
 
 var dbg = debug.Debug("bluez:profile")
 */
+
+// Option customize the bluez.Config used by a profile client
+type Option func(config *bluez.Config)
+
+// WithConn use an existing DBus connection, eg. a private bus or a test bus
+func WithConn(conn *dbus.Conn) Option {
+	return func(config *bluez.Config) {
+		config.Conn = conn
+	}
+}
+
+// WithAddress dial the DBus at address instead of the system bus
+func WithAddress(address string) Option {
+	return func(config *bluez.Config) {
+		config.Address = address
+	}
+}
+
+// newClient create a bluez.Client for config, applying the options
+func newClient(config *bluez.Config, opts []Option) *bluez.Client {
+	for _, opt := range opts {
+		opt(config)
+	}
+	return bluez.NewClient(config)
+}
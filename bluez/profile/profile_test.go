@@ -0,0 +1,63 @@
+package profile
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/bluez"
+)
+
+// recorded an operation served by a test Replayer, with its error
+type recorded struct {
+	op  *bluez.Operation
+	err error
+}
+
+// newTestReplayer return a Replayer serving the operations in order
+func newTestReplayer(t *testing.T, ops ...recorded) *bluez.Replayer {
+	buf := new(bytes.Buffer)
+	recorder, err := bluez.NewRecorder(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rec := range ops {
+		if err := recorder.Record(rec.op, rec.err); err != nil {
+			t.Fatal(err)
+		}
+	}
+	replayer, err := bluez.NewReplayer(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return replayer
+}
+
+// withBackend serve the operations of a client from b
+func withBackend(b bluez.Backend) Option {
+	return func(config *bluez.Config) {
+		config.Backend = b
+	}
+}
+
+// withInterceptor wrap the operations of a client
+func withInterceptor(interceptor bluez.Interceptor) Option {
+	return func(config *bluez.Config) {
+		config.Interceptors = append(config.Interceptors, interceptor)
+	}
+}
+
+func TestOptions(t *testing.T) {
+
+	conn := new(dbus.Conn)
+	agents := NewAgentManager1(WithConn(conn))
+	if agents.client.Config.Conn != conn {
+		t.Fatalf("Expected the injected connection, got %+v", agents.client.Config)
+	}
+
+	batteries := NewBatteryProviderManager1("hci0", WithAddress("tcp:host=10.0.0.2,port=5000"))
+	config := batteries.client.Config
+	if config.Address != "tcp:host=10.0.0.2,port=5000" || config.Bus != bluez.SystemBus {
+		t.Fatalf("Expected the bus address, got %+v", config)
+	}
+}
@@ -0,0 +1,180 @@
+package bluez
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus"
+)
+
+// RecordingVersion the version of the recording format written by Recorder
+const RecordingVersion = 1
+
+// RecordingHeader the first line of a recording
+type RecordingHeader struct {
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+}
+
+// RecordedError the error returned by a recorded operation
+type RecordedError struct {
+	// Name is the D-Bus error name, empty for errors not returned by the bus
+	Name    string `json:"name,omitempty"`
+	Message string `json:"message"`
+	// Method is the D-Bus method which failed
+	Method string `json:"method,omitempty"`
+}
+
+// RecordedOperation an operation of a recording. Args and Reply are marshalled
+// as the body of a D-Bus message, so the replayed values have the types received from the bus
+type RecordedOperation struct {
+	Seq int `json:"seq"`
+	// Time is the offset from the start of the recording
+	Time        time.Duration   `json:"time"`
+	Kind        string          `json:"kind"`
+	Destination string          `json:"destination,omitempty"`
+	Path        dbus.ObjectPath `json:"path"`
+	Interface   string          `json:"interface"`
+	Member      string          `json:"member"`
+	Match       *SignalMatch    `json:"match,omitempty"`
+	Args        []byte          `json:"args,omitempty"`
+	Reply       []byte          `json:"reply,omitempty"`
+	Error       *RecordedError  `json:"error,omitempty"`
+}
+
+// Recorder write the calls, replies, errors and signals flowing through the clients
+// as JSON lines, the recording can be served back by a Replayer
+type Recorder struct {
+	lock    sync.Mutex
+	encoder *json.Encoder
+	start   time.Time
+	seq     int
+}
+
+// NewRecorder create a recorder writing to w, eg. a file
+func NewRecorder(w io.Writer) (*Recorder, error) {
+	r := &Recorder{
+		encoder: json.NewEncoder(w),
+		start:   time.Now(),
+	}
+	err := r.encoder.Encode(RecordingHeader{
+		Version: RecordingVersion,
+		Created: r.start,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Interceptor return the interceptor recording the operations. Operations are
+// numbered when they start, so signals caused by a call are ordered after it
+func (r *Recorder) Interceptor() Interceptor {
+	return func(ctx context.Context, op *Operation, next Handler) error {
+		seq, offset := r.next()
+		err := next(ctx, op)
+		if recErr := r.record(seq, offset, op, err); recErr != nil {
+			logger.Warningf("Failed to record %s: %s", op.Method(), recErr)
+		}
+		return err
+	}
+}
+
+// next return the sequence number and time offset of a new operation
+func (r *Recorder) next() (int, time.Duration) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.seq++
+	return r.seq, time.Since(r.start)
+}
+
+// Record write an executed operation and its error
+func (r *Recorder) Record(op *Operation, err error) error {
+	seq, offset := r.next()
+	return r.record(seq, offset, op, err)
+}
+
+func (r *Recorder) record(seq int, offset time.Duration, op *Operation, err error) error {
+
+	entry := RecordedOperation{
+		Seq:         seq,
+		Time:        offset,
+		Kind:        op.Kind.String(),
+		Destination: op.Destination,
+		Path:        op.Path,
+		Interface:   op.Interface,
+		Member:      op.Member,
+		Match:       op.Match,
+	}
+
+	var encErr error
+	if entry.Args, encErr = encodeBody(op.Args); encErr != nil {
+		return encErr
+	}
+	if entry.Reply, encErr = encodeBody(op.Reply); encErr != nil {
+		return encErr
+	}
+
+	if err != nil {
+		entry.Error = &RecordedError{Message: err.Error()}
+		var bluezErr *Error
+		if errors.As(err, &bluezErr) {
+			entry.Error.Name = bluezErr.Name
+			entry.Error.Message = bluezErr.Message
+			entry.Error.Method = bluezErr.Method
+		}
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.encoder.Encode(entry)
+}
+
+// encodeBody marshal values as the body of a D-Bus message, nil if there are no values
+func encodeBody(values []interface{}) (raw []byte, err error) {
+
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	defer func() {
+		// the signature of values not representable in D-Bus cannot be computed
+		if r := recover(); r != nil {
+			err = fmt.Errorf("Cannot encode %v: %v", values, r)
+		}
+	}()
+
+	msg := &dbus.Message{
+		Type: dbus.TypeMethodReply,
+		Headers: map[dbus.HeaderField]dbus.Variant{
+			dbus.FieldReplySerial: dbus.MakeVariant(uint32(1)),
+			dbus.FieldSignature:   dbus.MakeVariant(dbus.SignatureOf(values...)),
+		},
+		Body: values,
+	}
+	buf := new(bytes.Buffer)
+	err = msg.EncodeTo(buf, binary.LittleEndian)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeBody unmarshal the values encoded by encodeBody
+func decodeBody(raw []byte) ([]interface{}, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	msg, err := dbus.DecodeMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	return msg.Body, nil
+}
@@ -0,0 +1,332 @@
+package bluez
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/godbus/dbus"
+)
+
+// ErrNotRecorded is returned by a Replayer for an operation missing from the recording
+var ErrNotRecorded = errors.New("Operation not found in the recording")
+
+// replayEntry a recorded operation and its decoded values
+type replayEntry struct {
+	RecordedOperation
+	kind  OperationKind
+	args  []interface{}
+	reply []interface{}
+	used  bool
+}
+
+// err rebuild the recorded error, D-Bus errors are translated as the bus would
+func (e *replayEntry) err() error {
+	if e.Error == nil {
+		return nil
+	}
+	if e.Error.Name == "" {
+		return errors.New(e.Error.Message)
+	}
+	return wrapError(dbus.Error{
+		Name: e.Error.Name,
+		Body: []interface{}{e.Error.Message},
+	}, e.Path, e.Error.Method)
+}
+
+// signal return the recorded signal
+func (e *replayEntry) signal() *dbus.Signal {
+	return &dbus.Signal{
+		Sender: e.Destination,
+		Path:   e.Path,
+		Name:   e.Interface + "." + e.Member,
+		Body:   e.reply,
+	}
+}
+
+// Replayer is a Backend serving a recording written by Recorder, so that a captured
+// session can run offline. Calls are answered with the first unused recorded operation
+// matching path, method and arguments; once all the matching ones are used the last reply is
+// repeated. Recorded signals are delivered in order, as soon as the calls preceding them are replayed
+type Replayer struct {
+	lock        sync.Mutex
+	entries     []*replayEntry
+	subscribers []*subscriber
+	// rounds distribute the signals recorded for identical subscriptions
+	rounds map[SignalMatch]int
+	// cursor is the next entry to deliver, lastUsed the highest replayed entry
+	cursor   int
+	lastUsed int
+	wake     chan struct{}
+	closed   chan struct{}
+	once     sync.Once
+}
+
+// LoadRecording create a Replayer from a recording file
+func LoadRecording(path string) (*Replayer, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return NewReplayer(file)
+}
+
+// NewReplayer create a Replayer reading a recording from r
+func NewReplayer(r io.Reader) (*Replayer, error) {
+
+	decoder := json.NewDecoder(r)
+
+	var header RecordingHeader
+	err := decoder.Decode(&header)
+	if err != nil {
+		return nil, err
+	}
+	if header.Version < 1 || header.Version > RecordingVersion {
+		return nil, fmt.Errorf("Unsupported recording version %d", header.Version)
+	}
+
+	replayer := &Replayer{
+		rounds:   make(map[SignalMatch]int),
+		lastUsed: -1,
+		wake:     make(chan struct{}, 1),
+		closed:   make(chan struct{}),
+	}
+
+	for {
+		entry := new(replayEntry)
+		err = decoder.Decode(&entry.RecordedOperation)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if entry.kind, err = parseOperationKind(entry.Kind); err != nil {
+			return nil, err
+		}
+		if entry.args, err = decodeBody(entry.Args); err != nil {
+			return nil, err
+		}
+		if entry.reply, err = decodeBody(entry.Reply); err != nil {
+			return nil, err
+		}
+		replayer.entries = append(replayer.entries, entry)
+	}
+
+	// calls are written once completed, replay them in the order they started
+	sort.SliceStable(replayer.entries, func(i, j int) bool {
+		return replayer.entries[i].Seq < replayer.entries[j].Seq
+	})
+
+	go replayer.run()
+
+	return replayer, nil
+}
+
+// Invoke serve an operation from the recording
+func (r *Replayer) Invoke(ctx context.Context, op *Operation) error {
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// compare the arguments as they would be received from the bus
+	raw, err := encodeBody(op.Args)
+	if err != nil {
+		return err
+	}
+	args, err := decodeBody(raw)
+	if err != nil {
+		return err
+	}
+
+	r.lock.Lock()
+	index := r.find(op, args)
+	if index == -1 {
+		r.lock.Unlock()
+		return fmt.Errorf("%w: %s on %s", ErrNotRecorded, op.Method(), op.Path)
+	}
+	entry := r.entries[index]
+	entry.used = true
+	if index > r.lastUsed {
+		r.lastUsed = index
+	}
+	r.lock.Unlock()
+
+	r.notify()
+
+	op.Reply = append([]interface{}{}, entry.reply...)
+	return entry.err()
+}
+
+// find return the index of the entry answering op, -1 if there is none
+func (r *Replayer) find(op *Operation, args []interface{}) int {
+	last := -1
+	for i, entry := range r.entries {
+		if entry.kind != op.Kind || entry.Path != op.Path ||
+			entry.Interface != op.Interface || entry.Member != op.Member ||
+			!reflect.DeepEqual(entry.args, args) {
+			continue
+		}
+		if !entry.used {
+			return i
+		}
+		last = i
+	}
+	return last
+}
+
+// Subscribe return a channel receiving the recorded signals selected by match
+func (r *Replayer) Subscribe(match SignalMatch) (chan *dbus.Signal, error) {
+
+	sub := newSubscriber(match)
+	go sub.forward()
+
+	r.lock.Lock()
+	r.subscribers = append(r.subscribers, sub)
+	r.lock.Unlock()
+
+	r.notify()
+
+	return sub.channel, nil
+}
+
+// Unsubscribe stop the delivery of signals to channel and close it
+func (r *Replayer) Unsubscribe(channel chan *dbus.Signal) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for i, sub := range r.subscribers {
+		if sub.channel == channel {
+			r.subscribers = append(r.subscribers[:i], r.subscribers[i+1:]...)
+			close(sub.done)
+			break
+		}
+	}
+	return nil
+}
+
+// Remaining return the number of recorded operations not replayed yet
+func (r *Replayer) Remaining() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	count := 0
+	for i, entry := range r.entries {
+		if entry.kind == OperationSignal {
+			if i >= r.cursor {
+				count++
+			}
+		} else if !entry.used {
+			count++
+		}
+	}
+	return count
+}
+
+// Close stop the replay and close the subscribed channels
+func (r *Replayer) Close() {
+	r.once.Do(func() {
+		close(r.closed)
+		r.lock.Lock()
+		for _, sub := range r.subscribers {
+			close(sub.done)
+		}
+		r.subscribers = nil
+		r.lock.Unlock()
+	})
+}
+
+// notify wake up the delivery of signals
+func (r *Replayer) notify() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (r *Replayer) run() {
+	for {
+		select {
+		case <-r.wake:
+		case <-r.closed:
+			return
+		}
+		for {
+			r.lock.Lock()
+			sig, sub := r.next()
+			r.lock.Unlock()
+			if sig == nil {
+				break
+			}
+			sub.deliver(sig)
+		}
+	}
+}
+
+// next return the next signal to deliver and its subscriber, nil when waiting for
+// calls to be replayed or for a subscriber. Entries skipped by the replayed code are dropped
+func (r *Replayer) next() (*dbus.Signal, *subscriber) {
+	for r.cursor < len(r.entries) {
+
+		entry := r.entries[r.cursor]
+		skipped := r.lastUsed > r.cursor
+
+		if entry.kind != OperationSignal {
+			if !entry.used && !skipped {
+				return nil, nil
+			}
+			r.cursor++
+			continue
+		}
+
+		sig := entry.signal()
+		matching := r.matching(entry, sig)
+		if len(matching) == 0 {
+			if !skipped {
+				return nil, nil
+			}
+			r.cursor++
+			continue
+		}
+
+		r.cursor++
+
+		var key SignalMatch
+		if entry.Match != nil {
+			key = *entry.Match
+		}
+		sub := matching[r.rounds[key]%len(matching)]
+		r.rounds[key]++
+
+		return sig, sub
+	}
+	return nil, nil
+}
+
+// matching return the subscribers of a recorded signal, the ones with the recorded
+// subscription match or, if none, the ones selecting the signal
+func (r *Replayer) matching(entry *replayEntry, sig *dbus.Signal) []*subscriber {
+	var list []*subscriber
+	if entry.Match != nil {
+		for _, sub := range r.subscribers {
+			if sub.match == *entry.Match {
+				list = append(list, sub)
+			}
+		}
+		if len(list) > 0 {
+			return list
+		}
+	}
+	for _, sub := range r.subscribers {
+		if sub.match.Matches(sig) {
+			list = append(list, sub)
+		}
+	}
+	return list
+}
@@ -0,0 +1,105 @@
+package bluez
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus"
+)
+
+func TestRecordReplay(t *testing.T) {
+
+	path := dbus.ObjectPath("/org/bluez/hci0/dev_B0_B4_48_C9_4B_01/service0024/char0025")
+	iface := "org.bluez.GattCharacteristic1"
+	match := SignalMatch{Path: path, Interface: PropertiesInterface}
+
+	buf := new(bytes.Buffer)
+	recorder, err := NewRecorder(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record := func(op *Operation, err error) {
+		if err := recorder.Record(op, err); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	record(&Operation{
+		Kind: OperationGetProperty, Path: path, Interface: iface, Member: "UUID",
+		Reply: []interface{}{dbus.MakeVariant("f000aa01-0451-4000-b000-000000000000")},
+	}, nil)
+	record(&Operation{
+		Kind: OperationCall, Path: path, Interface: iface, Member: "StartNotify",
+	}, nil)
+	record(&Operation{
+		Kind: OperationSignal, Path: path, Interface: PropertiesInterface, Member: "PropertiesChanged",
+		Reply: []interface{}{iface, map[string]dbus.Variant{"Value": dbus.MakeVariant([]byte{1, 2})}, []string{}},
+		Match: &match,
+	}, nil)
+	record(&Operation{
+		Kind: OperationCall, Path: path, Interface: iface, Member: "WriteValue",
+		Args: []interface{}{[]byte{1}, map[string]interface{}{"offset": uint16(0)}},
+	}, wrapError(dbus.Error{Name: "org.bluez.Error.InProgress", Body: []interface{}{"In Progress"}}, path, iface+".WriteValue"))
+
+	replayer, err := NewReplayer(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer replayer.Close()
+
+	client := NewClient(&Config{
+		Name:    "org.bluez",
+		Iface:   iface,
+		Path:    string(path),
+		Backend: replayer,
+	})
+
+	uuid, err := client.GetProperty("UUID")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uuid.Value().(string) != "f000aa01-0451-4000-b000-000000000000" {
+		t.Fatalf("Unexpected UUID %v", uuid)
+	}
+
+	channel, err := client.Register(string(path), PropertiesInterface)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-channel:
+		t.Fatal("Signal delivered before StartNotify")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err = client.Call("StartNotify", 0).Store(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case sig := <-channel:
+		changed := sig.Body[1].(map[string]dbus.Variant)
+		if !bytes.Equal(changed["Value"].Value().([]byte), []byte{1, 2}) {
+			t.Fatalf("Unexpected value %v", changed["Value"])
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Signal not delivered")
+	}
+
+	err = client.Call("WriteValue", 0, []byte{1}, map[string]interface{}{"offset": uint16(0)}).Store()
+	if !errors.Is(err, ErrInProgress) {
+		t.Fatalf("Expected ErrInProgress, got %v", err)
+	}
+
+	if err = client.Call("StopNotify", 0).Store(); !errors.Is(err, ErrNotRecorded) {
+		t.Fatalf("Expected ErrNotRecorded, got %v", err)
+	}
+
+	if remaining := replayer.Remaining(); remaining != 0 {
+		t.Fatalf("Expected the recording to be fully replayed, %d operations left", remaining)
+	}
+}
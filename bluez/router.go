@@ -0,0 +1,437 @@
+package bluez
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/godbus/dbus"
+)
+
+// busName the name of the bus daemon, the sender of its own signals
+const busName = "org.freedesktop.DBus"
+
+// SignalMatch select the signals delivered to a subscriber, empty fields match any value
+type SignalMatch struct {
+	// Sender a unique or well-known name, the router compares the unique name of the
+	// current owner of a well-known name with the sender of the signals
+	Sender    string
+	Path      dbus.ObjectPath
+	Interface string
+	Member    string
+	// Arg0 match the first argument of the signal, which must be a string
+	Arg0 string
+}
+
+// NameOwnerChangedMatch select the NameOwnerChanged signals emitted by the bus for name
+func NameOwnerChangedMatch(name string) SignalMatch {
+	return SignalMatch{
+		Sender:    "org.freedesktop.DBus",
+		Path:      "/org/freedesktop/DBus",
+		Interface: "org.freedesktop.DBus",
+		Member:    "NameOwnerChanged",
+		Arg0:      name,
+	}
+}
+
+// Rule return the DBus match rule for AddMatch / RemoveMatch
+func (m SignalMatch) Rule() string {
+	rule := "type='signal'"
+	if m.Sender != "" {
+		rule += ",sender='" + m.Sender + "'"
+	}
+	if m.Interface != "" {
+		rule += ",interface='" + m.Interface + "'"
+	}
+	if m.Member != "" {
+		rule += ",member='" + m.Member + "'"
+	}
+	if m.Path != "" {
+		rule += ",path='" + string(m.Path) + "'"
+	}
+	if m.Arg0 != "" {
+		rule += ",arg0='" + m.Arg0 + "'"
+	}
+	return rule
+}
+
+// MatchesFrom check if a signal is selected by the match, owner is the unique name
+// owning Sender. Signals from another sender are rejected, so peers cannot spoof them
+func (m SignalMatch) MatchesFrom(sig *dbus.Signal, owner string) bool {
+	if m.Sender != "" && (owner == "" || sig.Sender != owner) {
+		return false
+	}
+	return m.Matches(sig)
+}
+
+// Matches check if a signal is selected by the match, regardless of its sender
+func (m SignalMatch) Matches(sig *dbus.Signal) bool {
+	if m.Path != "" && m.Path != sig.Path {
+		return false
+	}
+	if m.Arg0 != "" {
+		if len(sig.Body) == 0 {
+			return false
+		}
+		if arg0, ok := sig.Body[0].(string); !ok || arg0 != m.Arg0 {
+			return false
+		}
+	}
+	if m.Interface == "" && m.Member == "" {
+		return true
+	}
+	pos := strings.LastIndex(sig.Name, ".")
+	if pos == -1 {
+		return false
+	}
+	if m.Interface != "" && m.Interface != sig.Name[:pos] {
+		return false
+	}
+	if m.Member != "" && m.Member != sig.Name[pos+1:] {
+		return false
+	}
+	return true
+}
+
+// subscriberQueueSize the number of signals queued for a slow subscriber before warning,
+// the queue grows further so no signal is lost
+const subscriberQueueSize = 32
+
+// isUniqueName check if a bus name is a unique connection name, eg. :1.42
+func isUniqueName(name string) bool {
+	return strings.HasPrefix(name, ":")
+}
+
+// subscriber receives the signals selected by its match
+type subscriber struct {
+	match   SignalMatch
+	channel chan *dbus.Signal
+	// wake is signaled when a signal is queued
+	wake chan struct{}
+	done chan struct{}
+
+	lock    sync.Mutex
+	pending []*dbus.Signal
+	warned  bool
+}
+
+func newSubscriber(match SignalMatch) *subscriber {
+	return &subscriber{
+		match:   match,
+		channel: make(chan *dbus.Signal, 1),
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+}
+
+// deliver queue a signal without blocking. The queue of a slow subscriber grows
+// instead of dropping signals, eg. notifications or InterfacesAdded
+func (s *subscriber) deliver(sig *dbus.Signal) {
+	s.lock.Lock()
+	s.pending = append(s.pending, sig)
+	backlog := len(s.pending)
+	warn := backlog >= subscriberQueueSize && !s.warned
+	if warn {
+		s.warned = true
+	} else if backlog < subscriberQueueSize {
+		s.warned = false
+	}
+	s.lock.Unlock()
+
+	if warn {
+		logger.Warningf("Slow subscriber to %s, %d signals queued", s.match.Rule(), backlog)
+	}
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// next return the oldest queued signal, nil if there is none
+func (s *subscriber) next() *dbus.Signal {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if len(s.pending) == 0 {
+		return nil
+	}
+	sig := s.pending[0]
+	s.pending[0] = nil
+	s.pending = s.pending[1:]
+	return sig
+}
+
+// queued return the number of signals waiting for delivery
+func (s *subscriber) queued() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return len(s.pending)
+}
+
+// forward deliver queued signals in order, the channel is closed once unsubscribed
+func (s *subscriber) forward() {
+	defer close(s.channel)
+	for {
+		sig := s.next()
+		if sig == nil {
+			select {
+			case <-s.wake:
+			case <-s.done:
+				return
+			}
+			continue
+		}
+		select {
+		case s.channel <- sig:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// nameOwner the unique name owning a well-known name, followed with NameOwnerChanged
+type nameOwner struct {
+	owner string
+	refs  int
+	// changed is set once the owner has been updated by a signal
+	changed bool
+}
+
+// router dispatch the signals received on a connection to the matching subscribers
+type router struct {
+	conn *dbus.Conn
+	lock sync.Mutex
+	// rulesLock guard rules and order the AddMatch and RemoveMatch calls, the bus is
+	// never called with lock held so the dispatch goes on while waiting for the bus
+	rulesLock   sync.Mutex
+	rules       map[string]int
+	subscribers map[chan *dbus.Signal]*subscriber
+	owners      map[string]*nameOwner
+	signals     chan *dbus.Signal
+}
+
+var routersLock sync.Mutex
+var routers = make(map[*dbus.Conn]*router)
+
+// getRouter return the signal router of a connection, creating it on first use
+func getRouter(conn *dbus.Conn) *router {
+	routersLock.Lock()
+	defer routersLock.Unlock()
+
+	if r, ok := routers[conn]; ok {
+		return r
+	}
+
+	r := &router{
+		conn:        conn,
+		rules:       make(map[string]int),
+		subscribers: make(map[chan *dbus.Signal]*subscriber),
+		owners:      make(map[string]*nameOwner),
+		signals:     make(chan *dbus.Signal, subscriberQueueSize),
+	}
+	routers[conn] = r
+	conn.Signal(r.signals)
+	go r.loop()
+
+	return r
+}
+
+func (r *router) loop() {
+	for sig := range r.signals {
+		r.dispatch(sig)
+	}
+
+	// the connection has been closed
+	routersLock.Lock()
+	delete(routers, r.conn)
+	routersLock.Unlock()
+
+	r.lock.Lock()
+	for channel, sub := range r.subscribers {
+		close(sub.done)
+		delete(r.subscribers, channel)
+	}
+	r.lock.Unlock()
+}
+
+// dispatch queue a signal to the matching subscribers, it never blocks so a slow
+// subscriber does not delay the others
+func (r *router) dispatch(sig *dbus.Signal) {
+	r.lock.Lock()
+	r.updateOwner(sig)
+	var matching []*subscriber
+	for _, sub := range r.subscribers {
+		if sub.match.MatchesFrom(sig, r.ownerOf(sub.match.Sender)) {
+			matching = append(matching, sub)
+		}
+	}
+	r.lock.Unlock()
+
+	for _, sub := range matching {
+		sub.deliver(sig)
+	}
+}
+
+// updateOwner follow the owner of the tracked names, the lock must be held
+func (r *router) updateOwner(sig *dbus.Signal) {
+	if sig.Sender != busName || sig.Name != busName+".NameOwnerChanged" || len(sig.Body) < 3 {
+		return
+	}
+	name, _ := sig.Body[0].(string)
+	if o, ok := r.owners[name]; ok {
+		o.owner, _ = sig.Body[2].(string)
+		o.changed = true
+	}
+}
+
+// ownerOf return the unique name owning name, empty if it has no owner. The lock must be held
+func (r *router) ownerOf(name string) string {
+	if name == "" || name == busName || isUniqueName(name) {
+		return name
+	}
+	if o, ok := r.owners[name]; ok {
+		return o.owner
+	}
+	return ""
+}
+
+// trackOwner follow the owner of a well-known name, it is shared by all the subscribers using it
+func (r *router) trackOwner(name string) error {
+
+	if name == "" || name == busName || isUniqueName(name) {
+		return nil
+	}
+
+	r.lock.Lock()
+	if o, ok := r.owners[name]; ok {
+		o.refs++
+		r.lock.Unlock()
+		return nil
+	}
+	o := &nameOwner{refs: 1}
+	r.owners[name] = o
+	r.lock.Unlock()
+
+	// watch the changes before reading the owner, so none is lost
+	err := r.addRule(NameOwnerChangedMatch(name).Rule())
+	if err != nil {
+		r.lock.Lock()
+		delete(r.owners, name)
+		r.lock.Unlock()
+		return err
+	}
+
+	var owner string
+	// the name may have no owner yet, eg. the service is not running
+	if r.conn.BusObject().Call(busName+".GetNameOwner", 0, name).Store(&owner) == nil {
+		r.lock.Lock()
+		if !o.changed {
+			o.owner = owner
+		}
+		r.lock.Unlock()
+	}
+	return nil
+}
+
+// untrackOwner drop a reference to a followed name
+func (r *router) untrackOwner(name string) error {
+	r.lock.Lock()
+	o, ok := r.owners[name]
+	if !ok {
+		r.lock.Unlock()
+		return nil
+	}
+	o.refs--
+	if o.refs > 0 {
+		r.lock.Unlock()
+		return nil
+	}
+	delete(r.owners, name)
+	r.lock.Unlock()
+	return r.removeRule(NameOwnerChangedMatch(name).Rule())
+}
+
+// addRule register a match rule on the bus, the rule is shared by all the subscribers using it
+func (r *router) addRule(rule string) error {
+	r.rulesLock.Lock()
+	defer r.rulesLock.Unlock()
+	if r.rules[rule] == 0 {
+		err := r.conn.BusObject().Call("org.freedesktop.DBus.AddMatch", 0, rule).Store()
+		if err != nil {
+			return err
+		}
+	}
+	r.rules[rule]++
+	return nil
+}
+
+// removeRule drop a reference to a match rule, removing it from the bus when unused
+func (r *router) removeRule(rule string) error {
+	r.rulesLock.Lock()
+	defer r.rulesLock.Unlock()
+	if r.rules[rule] == 0 {
+		return nil
+	}
+	r.rules[rule]--
+	if r.rules[rule] > 0 {
+		return nil
+	}
+	delete(r.rules, rule)
+	return r.conn.BusObject().Call("org.freedesktop.DBus.RemoveMatch", 0, rule).Store()
+}
+
+// restore apply again the match rules in use, eg. after the service emitting
+// the signals has been restarted. Rules are removed first so the bus keeps a single copy
+func (r *router) restore() error {
+	r.rulesLock.Lock()
+	defer r.rulesLock.Unlock()
+	for rule := range r.rules {
+		r.conn.BusObject().Call("org.freedesktop.DBus.RemoveMatch", 0, rule)
+		err := r.conn.BusObject().Call("org.freedesktop.DBus.AddMatch", 0, rule).Store()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Subscribe return a channel receiving the signals selected by match
+func (r *router) Subscribe(match SignalMatch) (chan *dbus.Signal, error) {
+
+	err := r.trackOwner(match.Sender)
+	if err != nil {
+		return nil, err
+	}
+	err = r.addRule(match.Rule())
+	if err != nil {
+		r.untrackOwner(match.Sender)
+		return nil, err
+	}
+
+	sub := newSubscriber(match)
+	go sub.forward()
+
+	r.lock.Lock()
+	r.subscribers[sub.channel] = sub
+	r.lock.Unlock()
+
+	return sub.channel, nil
+}
+
+// Unsubscribe stop the delivery of signals to channel and close it
+func (r *router) Unsubscribe(channel chan *dbus.Signal) error {
+	r.lock.Lock()
+	sub, ok := r.subscribers[channel]
+	if ok {
+		delete(r.subscribers, channel)
+		close(sub.done)
+	}
+	r.lock.Unlock()
+
+	if !ok {
+		return nil
+	}
+	err := r.removeRule(sub.match.Rule())
+	if err != nil {
+		return err
+	}
+	return r.untrackOwner(sub.match.Sender)
+}
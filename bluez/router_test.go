@@ -0,0 +1,138 @@
+package bluez
+
+import (
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus"
+)
+
+func TestMatchesFrom(t *testing.T) {
+
+	match := SignalMatch{Sender: "org.bluez", Path: "/org/bluez/hci0", Interface: PropertiesInterface}
+	sig := &dbus.Signal{Sender: ":1.7", Path: "/org/bluez/hci0", Name: PropertiesChanged}
+
+	if !match.MatchesFrom(sig, ":1.7") {
+		t.Fatal("Expected a signal from the owner to match")
+	}
+	if match.MatchesFrom(sig, ":1.8") {
+		t.Fatal("Expected a signal from another peer to be rejected")
+	}
+	if match.MatchesFrom(sig, "") {
+		t.Fatal("Expected a signal to be rejected when the name has no owner")
+	}
+	if !(SignalMatch{Path: "/org/bluez/hci0"}).MatchesFrom(sig, "") {
+		t.Fatal("Expected any sender to match without Sender")
+	}
+}
+
+func TestDispatchOwners(t *testing.T) {
+
+	r := &router{
+		rules:       make(map[string]int),
+		subscribers: make(map[chan *dbus.Signal]*subscriber),
+		owners:      map[string]*nameOwner{"org.bluez": {owner: ":1.7", refs: 1}},
+	}
+	sub := newSubscriber(SignalMatch{Sender: "org.bluez", Interface: PropertiesInterface})
+	r.subscribers[sub.channel] = sub
+
+	r.dispatch(&dbus.Signal{Sender: ":1.9", Name: PropertiesChanged})
+	if sub.queued() != 0 {
+		t.Fatal("Expected the spoofed signal to be dropped")
+	}
+
+	// bluetoothd restarted with another unique name
+	r.dispatch(&dbus.Signal{
+		Sender: busName,
+		Path:   "/org/freedesktop/DBus",
+		Name:   busName + ".NameOwnerChanged",
+		Body:   []interface{}{"org.bluez", ":1.7", ":1.9"},
+	})
+	r.dispatch(&dbus.Signal{Sender: ":1.9", Name: PropertiesChanged})
+	if sub.queued() != 1 {
+		t.Fatalf("Expected the signal of the new owner, got %d", sub.queued())
+	}
+}
+
+func TestDispatchSlowSubscriber(t *testing.T) {
+
+	r := &router{
+		rules:       make(map[string]int),
+		subscribers: make(map[chan *dbus.Signal]*subscriber),
+		owners:      make(map[string]*nameOwner),
+	}
+	// nobody reads the slow subscriber channel
+	slow := newSubscriber(SignalMatch{})
+	fast := newSubscriber(SignalMatch{})
+	r.subscribers[slow.channel] = slow
+	r.subscribers[fast.channel] = fast
+	go slow.forward()
+	go fast.forward()
+	defer close(slow.done)
+	defer close(fast.done)
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < subscriberQueueSize*2; i++ {
+			r.dispatch(&dbus.Signal{Name: PropertiesChanged, Body: []interface{}{i}})
+			<-fast.channel
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Dispatch blocked on a slow subscriber")
+	}
+
+	// no signal is dropped
+	for i := 0; i < subscriberQueueSize*2; i++ {
+		sig := <-slow.channel
+		if sig.Body[0].(int) != i {
+			t.Fatalf("Expected signal %d, got %v", i, sig.Body)
+		}
+	}
+}
+
+func TestRestoreDispatch(t *testing.T) {
+
+	bus := newFakeBus(t, nil)
+	defer bus.Close()
+	conn, err := dial(&Config{Address: bus.address})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	r := getRouter(conn)
+	channel, err := r.Subscribe(SignalMatch{Path: "/org/bluez/hci0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the bus is slow to answer, the signals are still dispatched
+	release := bus.holdReplies()
+	restored := make(chan error, 1)
+	go func() {
+		restored <- r.restore()
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	dispatched := make(chan struct{})
+	go func() {
+		r.dispatch(&dbus.Signal{Path: "/org/bluez/hci0", Name: PropertiesChanged})
+		close(dispatched)
+	}()
+	select {
+	case <-dispatched:
+	case <-time.After(time.Second):
+		t.Fatal("Dispatch blocked by a bus call")
+	}
+	<-channel
+
+	close(release)
+	if err := <-restored; err != nil {
+		t.Fatal(err)
+	}
+}
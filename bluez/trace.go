@@ -0,0 +1,100 @@
+package bluez
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus"
+)
+
+// Span describe the execution of an operation
+type Span struct {
+	Kind   string          `json:"kind"`
+	Method string          `json:"method"`
+	Path   dbus.ObjectPath `json:"path"`
+	// ArgsSize the size in bytes of the marshalled arguments
+	ArgsSize int           `json:"args_size"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// SpanExporter receive the spans recorded by TraceInterceptor
+type SpanExporter interface {
+	ExportSpan(span Span) error
+}
+
+// SpanExporterFunc adapt a function to a SpanExporter
+type SpanExporterFunc func(span Span) error
+
+// ExportSpan call f(span)
+func (f SpanExporterFunc) ExportSpan(span Span) error {
+	return f(span)
+}
+
+// TraceInterceptor return an interceptor recording a Span for each operation
+func TraceInterceptor(exporter SpanExporter) Interceptor {
+	return func(ctx context.Context, op *Operation, next Handler) error {
+
+		span := Span{
+			Kind:   op.Kind.String(),
+			Method: op.Method(),
+			Path:   op.Path,
+			Start:  time.Now(),
+		}
+
+		args := op.Args
+		if op.Kind == OperationSignal {
+			args = op.Reply
+		}
+		span.ArgsSize = encodedSize(args)
+
+		err := next(ctx, op)
+
+		span.Duration = time.Since(span.Start)
+		if err != nil {
+			span.Error = err.Error()
+		}
+
+		if exportErr := exporter.ExportSpan(span); exportErr != nil {
+			logger.Warningf("Failed to export span: %s", exportErr)
+		}
+
+		return err
+	}
+}
+
+// JSONExporter write the spans as JSON lines
+type JSONExporter struct {
+	lock    sync.Mutex
+	encoder *json.Encoder
+}
+
+// NewJSONExporter create an exporter writing a JSON object per line to w, eg. a file
+func NewJSONExporter(w io.Writer) *JSONExporter {
+	return &JSONExporter{encoder: json.NewEncoder(w)}
+}
+
+// ExportSpan write a span
+func (e *JSONExporter) ExportSpan(span Span) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.encoder.Encode(span)
+}
+
+// encodedSize return the size of values marshalled as a D-Bus message body, -1 if they cannot be encoded
+func encodedSize(values []interface{}) int {
+	if len(values) == 0 {
+		return 0
+	}
+	raw, err := encodeBody(values)
+	if err != nil {
+		return -1
+	}
+	// the body length is stored in the fixed header, after the endianness, type, flags and version bytes
+	return int(binary.LittleEndian.Uint32(raw[4:8]))
+}
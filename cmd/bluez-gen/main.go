@@ -0,0 +1,86 @@
+// bluez-gen generate typed clients of the BlueZ D-Bus interfaces
+//
+//	bluez-gen -o ./bluez/generated -pkg generated $BLUEZ_SRC/doc/adapter-api.txt $BLUEZ_SRC/doc/device-api.txt
+//	busctl introspect --xml-interface org.bluez /org/bluez/hci0 > hci0.xml && bluez-gen -o ./out hci0.xml
+//
+// API descriptions (*.txt) and introspection XML (*.xml) are accepted, a file is written for each interface
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/saurabh-newera/BLE/bluez/gen"
+)
+
+func main() {
+
+	output := flag.String("o", ".", "output directory")
+	pkg := flag.String("pkg", "generated", "package name of the generated code")
+	service := flag.String("service", "org.bluez", "service name for introspection XML")
+	only := flag.String("interfaces", "", "comma separated list of interfaces to generate, all if empty")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: bluez-gen [-o dir] [-pkg name] file.txt|file.xml ...")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+
+	selected := make(map[string]bool)
+	for _, name := range strings.Split(*only, ",") {
+		if name != "" {
+			selected[strings.TrimSpace(name)] = true
+		}
+	}
+
+	err := os.MkdirAll(*output, 0755)
+	if err != nil {
+		fail(err)
+	}
+
+	for _, path := range flag.Args() {
+
+		list, err := parse(path, *service)
+		if err != nil {
+			fail(fmt.Errorf("%s: %s", path, err.Error()))
+		}
+
+		for _, iface := range list {
+			if len(selected) > 0 && !selected[iface.Name] {
+				continue
+			}
+			src, err := gen.Generate(iface, gen.Options{Package: *pkg})
+			if err != nil {
+				fail(err)
+			}
+			dest := filepath.Join(*output, gen.FileName(iface))
+			err = ioutil.WriteFile(dest, src, 0644)
+			if err != nil {
+				fail(err)
+			}
+			fmt.Printf("%s -> %s\n", iface.Name, dest)
+		}
+	}
+}
+
+func parse(path string, service string) ([]gen.Interface, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if strings.HasSuffix(path, ".xml") {
+		return gen.ParseIntrospection(file, service)
+	}
+	return gen.ParseAPI(file)
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
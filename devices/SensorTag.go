@@ -12,7 +12,6 @@ import (
 	"github.com/saurabh-newera/BLE/bluez/profile"
 	"github.com/saurabh-newera/BLE/emitter"
 	"math"
-	"strings"
 	"time"
 )
 
@@ -182,8 +181,7 @@ func (s *HumiditySensor) Enable() error {
 	if enabled {
 		return nil
 	}
-	options := make(map[string]dbus.Variant)
-	err = s.cfg.WriteValue([]byte{1}, options)
+	err = s.cfg.Write([]byte{1}, nil)
 	if err != nil {
 		return err
 	}
@@ -200,8 +198,7 @@ func (s *HumiditySensor) Disable() error {
 	if !enabled {
 		return nil
 	}
-	options := make(map[string]dbus.Variant)
-	err = s.cfg.WriteValue([]byte{0}, options)
+	err = s.cfg.Write([]byte{0}, nil)
 	if err != nil {
 		return err
 	}
@@ -211,9 +208,8 @@ func (s *HumiditySensor) Disable() error {
 //.........IsEnabled check if humidity measurements are enabled...........
 
 func (s *HumiditySensor) IsEnabled() (bool, error) {
-	options := make(map[string]dbus.Variant)
 
-	val, err := s.cfg.ReadValue(options)
+	val, err := s.cfg.Read(nil)
 	if err != nil {
 		return false, err
 	}
@@ -248,8 +244,7 @@ func (s *HumiditySensor) Read() (float64, error) {
 		return 0, err
 	}
 
-	options := make(map[string]dbus.Variant)
-	b, err := s.data.ReadValue(options)
+	b, err := s.data.Read(nil)
 
 	fmt.Sprintf("Read data: %v", b)
 
@@ -270,25 +265,6 @@ func (s *HumiditySensor) Read() (float64, error) {
 
 func (s *HumiditySensor) StartNotify(macAddress string) error {
 
-	d := s.tag.Device
-	serv, err1 := d.GetAllServicesAndUUID()
-
-	if err1 != nil {
-
-	}
-	var uuidAndService string
-	serviceArrLength := len(serv)
-	for i := 0; i < serviceArrLength; i++ {
-
-		//log.Debug("value of all services and uuid's for Humidity sensor : ",serv[i])
-
-		val := strings.Split(serv[i], ":")
-
-		if val[0] == "F000AA22-0451-4000-B000-000000000000" {
-			//log.Debug("value of service and uuid for Humidity sensor : ",val)
-			uuidAndService = val[1]
-		}
-	}
 	fmt.Sprintf("Enabling dataChannel for humidity")
 
 	err := s.Enable()
@@ -310,65 +286,62 @@ func (s *HumiditySensor) StartNotify(macAddress string) error {
 				return
 			}
 
-			if strings.Contains(fmt.Sprint(event1.Path), uuidAndService) {
-
-				//log.Debug("Got update dataChannel: ", event1)
+			//log.Debug("Got update dataChannel: ", event1)
 
-				//log.Debug("Value of event1.name: ", event1.Name)
-				//log.Debug("name of service and char: ", event1.Path)
+			//log.Debug("Value of event1.name: ", event1.Name)
+			//log.Debug("name of service and char: ", event1.Path)
 
-				switch event1.Body[0].(type) {
+			switch event1.Body[0].(type) {
 
-				case dbus.ObjectPath:
-					//log.Debug("Received body type does not match: [0] %v -> [1] %v", event1.Body[0], event1.Body[1])
-					continue
+			case dbus.ObjectPath:
+				//log.Debug("Received body type does not match: [0] %v -> [1] %v", event1.Body[0], event1.Body[1])
+				continue
 
-				case string:
-					//log.Debug("body type match")
-				}
+			case string:
+				//log.Debug("body type match")
+			}
 
-				if event1.Body[0] != bluez.GattCharacteristic1Interface {
-					// fmt.Sprintf("Skip interface %s", event1.Body[0])
+			if event1.Body[0] != bluez.GattCharacteristic1Interface {
+				// fmt.Sprintf("Skip interface %s", event1.Body[0])
 
-					continue
-				}
+				continue
+			}
 
-				props1 := event1.Body[1].(map[string]dbus.Variant)
+			props1 := event1.Body[1].(map[string]dbus.Variant)
 
-				if _, ok := props1["Value"]; !ok {
-					// fmt.Sprintf("Cannot read Value property %v", props1)
-					continue
-				}
+			if _, ok := props1["Value"]; !ok {
+				// fmt.Sprintf("Cannot read Value property %v", props1)
+				continue
+			}
 
-				b1 := props1["Value"].Value().([]byte)
-				//log.Debug("length of data for humidity: ",len(b1)," ,humidity data: ",b1)
-				fmt.Sprintf("Read data: %v", b1)
+			b1 := props1["Value"].Value().([]byte)
+			//log.Debug("length of data for humidity: ",len(b1)," ,humidity data: ",b1)
+			fmt.Sprintf("Read data: %v", b1)
 
-				humid := binary.LittleEndian.Uint16(b1[2:])
+			humid := binary.LittleEndian.Uint16(b1[2:])
 
-				humidityValue := calcHumidLocal(uint16(humid))
+			humidityValue := calcHumidLocal(uint16(humid))
 
-				temperature := binary.LittleEndian.Uint16(b1[0:2])
+			temperature := binary.LittleEndian.Uint16(b1[0:2])
 
-				// log.Debug("temperature from humidity sensor: ",temperature)
+			// log.Debug("temperature from humidity sensor: ",temperature)
 
-				tempValue := calcTmpFromHumidSensor(uint16(temperature))
-				//log.Debug("temperature from humid: ",tempValue)
+			tempValue := calcTmpFromHumidSensor(uint16(temperature))
+			//log.Debug("temperature from humid: ",tempValue)
 
-				fmt.Sprintf("Got data %v", humidityValue)
-				//log.Debug("humidValue: ",humidityValue)
-				dataEvent := api.DataEvent{
+			fmt.Sprintf("Got data %v", humidityValue)
+			//log.Debug("humidValue: ",humidityValue)
+			dataEvent := api.DataEvent{
 
-					Device:            s.tag.Device,
-					SensorType:        "humidity",
-					HumidityValue:     humidityValue,
-					HumidityUnit:      "%RH",
-					HumidityTempValue: tempValue,
-					HumidityTempUnit:  "C",
-					SensorId:          macAddress,
-				}
-				s.tag.Device.Emit("data", dataEvent)
+				Device:            s.tag.Device,
+				SensorType:        "humidity",
+				HumidityValue:     humidityValue,
+				HumidityUnit:      "%RH",
+				HumidityTempValue: tempValue,
+				HumidityTempUnit:  "C",
+				SensorId:          macAddress,
 			}
+			s.tag.Device.Emit("data", dataEvent)
 		}
 	}()
 
@@ -484,7 +457,7 @@ func newMpuSensor(tag *SensorTag) (MpuSensor, error) {
 			return MpuSensor{}, errors.New("Cannot find MpuPeriod characteristic " + MpuPeriodUUID)
 		}
 
-		return MpuSensor{tag, cfg, data, period}, err
+		return MpuSensor{tag: tag, cfg: cfg, data: data, period: period}, err
 	}
 
 	return loadChars()
@@ -497,6 +470,8 @@ type MpuSensor struct {
 	cfg    *profile.GattCharacteristic1
 	data   *profile.GattCharacteristic1
 	period *profile.GattCharacteristic1
+	// reader receive the data notifications, through an acquired socket when available
+	reader *profile.GattNotifyReader
 }
 
 // ........GetName return's the sensor name..............
@@ -515,7 +490,6 @@ func (s *MpuSensor) Enable() error {
 	if enabled {
 		return nil
 	}
-	options := make(map[string]dbus.Variant)
 	//0x0007
 	//0x7f
 	/*
@@ -525,8 +499,8 @@ func (s *MpuSensor) Enable() error {
 		 b[1] = 0x0007f
 		 bs = b[:2]
 	*/
-	err = s.cfg.WriteValue([]byte{0x0007f, 0x0007f}, options)
-	//err = s.cfg.WriteValue(bs, options)
+	err = s.cfg.Write([]byte{0x0007f, 0x0007f}, nil)
+	//err = s.cfg.Write(bs, nil)
 	if err != nil {
 		//log.Debug("errorrr: ",err)
 		return err
@@ -544,8 +518,7 @@ func (s *MpuSensor) Disable() error {
 	if !enabled {
 		return nil
 	}
-	options := make(map[string]dbus.Variant)
-	err = s.cfg.WriteValue([]byte{0}, options)
+	err = s.cfg.Write([]byte{0}, nil)
 	if err != nil {
 		return err
 	}
@@ -555,9 +528,8 @@ func (s *MpuSensor) Disable() error {
 //.........IsEnabled check if mpu measurements are enabled...........
 
 func (s *MpuSensor) IsEnabled() (bool, error) {
-	options := make(map[string]dbus.Variant)
 
-	val, err := s.cfg.ReadValue(options)
+	val, err := s.cfg.Read(nil)
 	if err != nil {
 		return false, err
 	}
@@ -592,8 +564,7 @@ func (s *MpuSensor) Read() (float64, error) {
 		return 0, err
 	}
 
-	options := make(map[string]dbus.Variant)
-	b, err := s.data.ReadValue(options)
+	b, err := s.data.Read(nil)
 
 	fmt.Sprintf("Read data: %v", b)
 
@@ -615,24 +586,6 @@ func (s *MpuSensor) Read() (float64, error) {
 func (s *MpuSensor) StartNotify(macAddress string) error {
 
 	//log.Debug("MpuSensor tag value: ",s.tag.Device)
-	d := s.tag.Device
-	serv, err1 := d.GetAllServicesAndUUID()
-
-	if err1 != nil {
-
-	}
-	var uuidAndService string
-	serviceArrLength := len(serv)
-	for i := 0; i < serviceArrLength; i++ {
-
-		//log.Debug("value of all service's and uuid's for mpu sensor :",serv[i])
-		val := strings.Split(serv[i], ":")
-
-		if val[0] == "F000AA82-0451-4000-B000-000000000000" {
-			//log.Debug("value of service and uuid for mpu sensor : ",val)
-			uuidAndService = val[1]
-		}
-	}
 	fmt.Sprintf("Enabling mpuDataChannel")
 
 	err := s.Enable()
@@ -640,108 +593,76 @@ func (s *MpuSensor) StartNotify(macAddress string) error {
 		return err
 	}
 
-	dataChannel, err := s.data.Register()
+	if s.reader != nil {
+		return nil
+	}
+	reader, err := s.data.NewNotifyReader()
 	if err != nil {
 		return err
 	}
+	s.reader = reader
 
 	go func() {
-		for event1 := range dataChannel {
-
-			if event1 == nil {
+		for {
+			b1, err := reader.ReadPacket()
+			if err != nil {
 				return
 			}
+			if len(b1) < 18 {
+				continue
+			}
 
-			if strings.Contains(fmt.Sprint(event1.Path), uuidAndService) {
-
-				//log.Debug("Got update  mpu dataChannel: ", event1)
-				//log.Debug("Value of event1.name: ", event1.Name)
-				//log.Debug("name of service and char: ", event1.Path)
-
-				switch event1.Body[0].(type) {
-
-				case dbus.ObjectPath:
-					//log.Debug("Received body type does not match: [0] %v -> [1] %v", event1.Body[0], event1.Body[1])
-					continue
-				case string:
-					//log.Debug("body type match")
-				}
-
-				if event1.Body[0] != bluez.GattCharacteristic1Interface {
-					// fmt.Sprintf("Skip interface %s", event1.Body[0])
-
-					continue
-				}
-
-				props1 := event1.Body[1].(map[string]dbus.Variant)
-
-				if _, ok := props1["Value"]; !ok {
-					// fmt.Sprintf("Cannot read Value property %v", props1)
-					continue
-				}
-
-				b1 := props1["Value"].Value().([]byte)
-				//log.Debug("length of data for mpu: ",len(b1)," ,mpu data: ",b1)
-
-				var mpuAccelerometer string
-				var mpuGyroscope string
-				var mpuMagnetometer string
+			var mpuAccelerometer string
+			var mpuGyroscope string
+			var mpuMagnetometer string
 
-				//.......... calculate Gyroscope .................................
+			//.......... calculate Gyroscope .................................
 
-				mpuXg := binary.LittleEndian.Uint16(b1[0:2])
-				mpuYg := binary.LittleEndian.Uint16(b1[2:4])
-				mpuZg := binary.LittleEndian.Uint16(b1[4:6])
+			mpuXg := binary.LittleEndian.Uint16(b1[0:2])
+			mpuYg := binary.LittleEndian.Uint16(b1[2:4])
+			mpuZg := binary.LittleEndian.Uint16(b1[4:6])
 
-				mpuGyX, mpuGyY, mpuGyZ := calcMpuGyroscope(uint16(mpuXg), uint16(mpuYg), uint16(mpuZg))
-				//log.Debug("Gyroscope: ",mpuGyX,mpuGyY,mpuGyZ)
-				mpuGyroscope = fmt.Sprint(mpuGyX, " , ", mpuGyY, " , ", mpuGyZ)
+			mpuGyX, mpuGyY, mpuGyZ := calcMpuGyroscope(uint16(mpuXg), uint16(mpuYg), uint16(mpuZg))
+			//log.Debug("Gyroscope: ",mpuGyX,mpuGyY,mpuGyZ)
+			mpuGyroscope = fmt.Sprint(mpuGyX, " , ", mpuGyY, " , ", mpuGyZ)
 
-				//.......... calculate Accelerometer .............................
+			//.......... calculate Accelerometer .............................
 
-				mpuXa := binary.LittleEndian.Uint16(b1[6:8])
-				mpuYa := binary.LittleEndian.Uint16(b1[8:10])
-				mpuZa := binary.LittleEndian.Uint16(b1[10:12])
+			mpuXa := binary.LittleEndian.Uint16(b1[6:8])
+			mpuYa := binary.LittleEndian.Uint16(b1[8:10])
+			mpuZa := binary.LittleEndian.Uint16(b1[10:12])
 
-				mpuAcX, mpuAcY, mpuAcZ := calcMpuAccelerometer(uint16(mpuXa), uint16(mpuYa), uint16(mpuZa))
-				//log.Debug("Accelerometer: ",mpuAcX,mpuAcY,mpuAcZ)
-				mpuAccelerometer = fmt.Sprint(mpuAcX, " , ", mpuAcY, " , ", mpuAcZ)
+			mpuAcX, mpuAcY, mpuAcZ := calcMpuAccelerometer(uint16(mpuXa), uint16(mpuYa), uint16(mpuZa))
+			//log.Debug("Accelerometer: ",mpuAcX,mpuAcY,mpuAcZ)
+			mpuAccelerometer = fmt.Sprint(mpuAcX, " , ", mpuAcY, " , ", mpuAcZ)
 
-				//.......... calculate Magnetometer .............................
+			//.......... calculate Magnetometer .............................
 
-				mpuXm := binary.LittleEndian.Uint16(b1[12:14])
-				mpuYm := binary.LittleEndian.Uint16(b1[14:16])
-				mpuZm := binary.LittleEndian.Uint16(b1[16:18])
+			mpuXm := binary.LittleEndian.Uint16(b1[12:14])
+			mpuYm := binary.LittleEndian.Uint16(b1[14:16])
+			mpuZm := binary.LittleEndian.Uint16(b1[16:18])
 
-				mpuMgX, mpuMgY, mpuMgZ := calcMpuMagnetometer(uint16(mpuXm), uint16(mpuYm), uint16(mpuZm))
-				//log.Debug("Magnetometer: ",mpuMgX,mpuMgY,mpuMgZ)
-				mpuMagnetometer = fmt.Sprint(mpuMgX, " , ", mpuMgY, " , ", mpuMgZ)
-				//log.Debug(mpuMagnetometer ,mpuAccelerometer ,mpuGyroscope )
+			mpuMgX, mpuMgY, mpuMgZ := calcMpuMagnetometer(uint16(mpuXm), uint16(mpuYm), uint16(mpuZm))
+			//log.Debug("Magnetometer: ",mpuMgX,mpuMgY,mpuMgZ)
+			mpuMagnetometer = fmt.Sprint(mpuMgX, " , ", mpuMgY, " , ", mpuMgZ)
+			//log.Debug(mpuMagnetometer ,mpuAccelerometer ,mpuGyroscope )
 
-				dataEvent := api.DataEvent{
+			dataEvent := api.DataEvent{
 
-					Device:                s.tag.Device,
-					SensorType:            "mpu",
-					MpuGyroscopeValue:     mpuGyroscope,
-					MpuGyroscopeUnit:      "deg/s",
-					MpuAccelerometerValue: mpuAccelerometer,
-					MpuAccelerometerUnit:  "G",
-					MpuMagnetometerValue:  mpuMagnetometer,
-					MpuMagnetometerUnit:   "uT",
-					SensorId:              macAddress,
-				}
-				s.tag.Device.Emit("data", dataEvent)
+				Device:                s.tag.Device,
+				SensorType:            "mpu",
+				MpuGyroscopeValue:     mpuGyroscope,
+				MpuGyroscopeUnit:      "deg/s",
+				MpuAccelerometerValue: mpuAccelerometer,
+				MpuAccelerometerUnit:  "G",
+				MpuMagnetometerValue:  mpuMagnetometer,
+				MpuMagnetometerUnit:   "uT",
+				SensorId:              macAddress,
 			}
+			s.tag.Device.Emit("data", dataEvent)
 		}
 	}()
 
-	n, err := s.IsNotifying()
-	if err != nil {
-		return err
-	}
-	if !n {
-		return s.data.StartNotify()
-	}
 	return nil
 }
 
@@ -756,18 +677,12 @@ func (s *MpuSensor) StopNotify() error {
 		return err
 	}
 
-	if dataChannel != nil {
-		close(dataChannel)
-	}
-
-	n, err := s.IsNotifying()
-	if err != nil {
-		return err
-	}
-	if n {
-		return s.data.StopNotify()
+	if s.reader == nil {
+		return nil
 	}
-	return nil
+	reader := s.reader
+	s.reader = nil
+	return reader.Close()
 }
 
 // Port from http://processors.wiki.ti.com/index.php/SensorTag_User_Guide#IR_Temperature_Sensor
@@ -896,9 +811,8 @@ func (s *BarometricSensor) Enable() error {
 	if enabled {
 		return nil
 	}
-	options := make(map[string]dbus.Variant)
 
-	err = s.cfg.WriteValue([]byte{1}, options)
+	err = s.cfg.Write([]byte{1}, nil)
 	if err != nil {
 		//log.Debug("errorrr: ",err)
 		return err
@@ -916,8 +830,7 @@ func (s *BarometricSensor) Disable() error {
 	if !enabled {
 		return nil
 	}
-	options := make(map[string]dbus.Variant)
-	err = s.cfg.WriteValue([]byte{0}, options)
+	err = s.cfg.Write([]byte{0}, nil)
 	if err != nil {
 		return err
 	}
@@ -927,9 +840,8 @@ func (s *BarometricSensor) Disable() error {
 //.........IsEnabled check if BarometricSensor measurements are enabled...........
 
 func (s *BarometricSensor) IsEnabled() (bool, error) {
-	options := make(map[string]dbus.Variant)
 
-	val, err := s.cfg.ReadValue(options)
+	val, err := s.cfg.Read(nil)
 	if err != nil {
 		return false, err
 	}
@@ -964,8 +876,7 @@ func (s *BarometricSensor) Read() (float64, error) {
 		return 0, err
 	}
 
-	options := make(map[string]dbus.Variant)
-	b, err := s.data.ReadValue(options)
+	b, err := s.data.Read(nil)
 
 	fmt.Sprintf("Read data: %v", b)
 
@@ -986,24 +897,6 @@ func (s *BarometricSensor) Read() (float64, error) {
 
 func (s *BarometricSensor) StartNotify(macAddress string) error {
 
-	d := s.tag.Device
-	serv, err1 := d.GetAllServicesAndUUID()
-
-	if err1 != nil {
-
-	}
-	var uuidAndService string
-	serviceArrLength := len(serv)
-	for i := 0; i < serviceArrLength; i++ {
-
-		//log.Debug("value of all service's and uuid's for barometeric sensor : ",serv[i])
-		val := strings.Split(serv[i], ":")
-
-		if val[0] == "F000AA42-0451-4000-B000-000000000000" {
-			//log.Debug("value of service and uuid for barometeric sensor : ",val)
-			uuidAndService = val[1]
-		}
-	}
 	fmt.Sprintf("Enabling BarometricSensorDataChannel")
 
 	err := s.Enable()
@@ -1022,57 +915,55 @@ func (s *BarometricSensor) StartNotify(macAddress string) error {
 			if event1 == nil {
 				return
 			}
-			if strings.Contains(fmt.Sprint(event1.Path), uuidAndService) {
 
-				//log.Debug("Got update  BarometricSensor dataChannel: ", event1)
-				//log.Debug("Value of event1.name: ", event1.Name)
-				//log.Debug("name of service and char: ", event1.Path)
+			//log.Debug("Got update  BarometricSensor dataChannel: ", event1)
+			//log.Debug("Value of event1.name: ", event1.Name)
+			//log.Debug("name of service and char: ", event1.Path)
 
-				switch event1.Body[0].(type) {
+			switch event1.Body[0].(type) {
 
-				case dbus.ObjectPath:
-					//log.Debug("Received body type does not match: [0] %v -> [1] %v", event1.Body[0], event1.Body[1])
-					continue
-				case string:
-					//log.Debug("body type match")
-				}
+			case dbus.ObjectPath:
+				//log.Debug("Received body type does not match: [0] %v -> [1] %v", event1.Body[0], event1.Body[1])
+				continue
+			case string:
+				//log.Debug("body type match")
+			}
 
-				if event1.Body[0] != bluez.GattCharacteristic1Interface {
-					// fmt.Sprintf("Skip interface %s", event1.Body[0])
+			if event1.Body[0] != bluez.GattCharacteristic1Interface {
+				// fmt.Sprintf("Skip interface %s", event1.Body[0])
 
-					continue
-				}
+				continue
+			}
 
-				props1 := event1.Body[1].(map[string]dbus.Variant)
+			props1 := event1.Body[1].(map[string]dbus.Variant)
 
-				if _, ok := props1["Value"]; !ok {
-					// fmt.Sprintf("Cannot read Value property %v", props1)
-					continue
-				}
+			if _, ok := props1["Value"]; !ok {
+				// fmt.Sprintf("Cannot read Value property %v", props1)
+				continue
+			}
 
-				b1 := props1["Value"].Value().([]byte)
-				//log.Debug("length of data for barometer: ",len(b1)," ,barometer data: ",b1)
+			b1 := props1["Value"].Value().([]byte)
+			//log.Debug("length of data for barometer: ",len(b1)," ,barometer data: ",b1)
 
-				barometer := binary.LittleEndian.Uint32(b1[2:])
-				barometericPressureValue := calcBarometricPressure(uint32(barometer))
+			barometer := binary.LittleEndian.Uint32(b1[2:])
+			barometericPressureValue := calcBarometricPressure(uint32(barometer))
 
-				barometerTemperature := binary.LittleEndian.Uint32(b1[0:4])
-				barometerTempValue := calcBarometricTemperature(uint32(barometerTemperature))
+			barometerTemperature := binary.LittleEndian.Uint32(b1[0:4])
+			barometerTempValue := calcBarometricTemperature(uint32(barometerTemperature))
 
-				fmt.Sprintf("Got data %v", barometericPressureValue)
+			fmt.Sprintf("Got data %v", barometericPressureValue)
 
-				dataEvent := api.DataEvent{
+			dataEvent := api.DataEvent{
 
-					Device:                   s.tag.Device,
-					SensorType:               "pressure",
-					BarometericPressureValue: barometericPressureValue,
-					BarometericPressureUnit:  "hPa",
-					BarometericTempValue:     barometerTempValue,
-					BarometericTempUnit:      "C",
-					SensorId:                 macAddress,
-				}
-				s.tag.Device.Emit("data", dataEvent)
+				Device:                   s.tag.Device,
+				SensorType:               "pressure",
+				BarometericPressureValue: barometericPressureValue,
+				BarometericPressureUnit:  "hPa",
+				BarometericTempValue:     barometerTempValue,
+				BarometericTempUnit:      "C",
+				SensorId:                 macAddress,
 			}
+			s.tag.Device.Emit("data", dataEvent)
 		}
 	}()
 
@@ -1218,8 +1109,7 @@ func (s *TemperatureSensor) Enable() error {
 	if enabled {
 		return nil
 	}
-	options := make(map[string]dbus.Variant)
-	err = s.cfg.WriteValue([]byte{1}, options)
+	err = s.cfg.Write([]byte{1}, nil)
 
 	if err != nil {
 
@@ -1237,8 +1127,7 @@ func (s *TemperatureSensor) Disable() error {
 	if !enabled {
 		return nil
 	}
-	options := make(map[string]dbus.Variant)
-	err = s.cfg.WriteValue([]byte{0}, options)
+	err = s.cfg.Write([]byte{0}, nil)
 	if err != nil {
 		return err
 	}
@@ -1247,9 +1136,8 @@ func (s *TemperatureSensor) Disable() error {
 
 //IsEnabled check if measurements are enabled
 func (s *TemperatureSensor) IsEnabled() (bool, error) {
-	options := make(map[string]dbus.Variant)
 
-	val, err := s.cfg.ReadValue(options)
+	val, err := s.cfg.Read(nil)
 	if err != nil {
 		return false, err
 	}
@@ -1299,8 +1187,7 @@ func (s *TemperatureSensor) Read() (float64, error) {
 		return 0, err
 	}
 
-	options := make(map[string]dbus.Variant)
-	b, err := s.data.ReadValue(options)
+	b, err := s.data.Read(nil)
 
 	fmt.Sprintf("Read data: %v", b)
 
@@ -1321,24 +1208,6 @@ func (s *TemperatureSensor) Read() (float64, error) {
 
 func (s *TemperatureSensor) StartNotify(macAddress string) error {
 
-	d := s.tag.Device
-	serv, err1 := d.GetAllServicesAndUUID()
-
-	if err1 != nil {
-
-	}
-	var uuidAndService string
-	serviceArrLength := len(serv)
-	for i := 0; i < serviceArrLength; i++ {
-
-		val := strings.Split(serv[i], ":")
-
-		if val[0] == "F000AA01-0451-4000-B000-000000000000" {
-			//log.Debug("value of service and uuid for Temperature sensor : ",val)
-			uuidAndService = val[1]
-		}
-	}
-
 	fmt.Sprintf("Enabling DataChannel")
 
 	err := s.Enable()
@@ -1357,56 +1226,54 @@ func (s *TemperatureSensor) StartNotify(macAddress string) error {
 			if event == nil {
 				return
 			}
-			if strings.Contains(fmt.Sprint(event.Path), uuidAndService) {
 
-				// fmt.Sprintf("Got update %v", event)
-				//log.Debug("Got update temperature DataChannel: ", event)
+			// fmt.Sprintf("Got update %v", event)
+			//log.Debug("Got update temperature DataChannel: ", event)
 
-				switch event.Body[0].(type) {
+			switch event.Body[0].(type) {
 
-				case dbus.ObjectPath:
-					// fmt.Sprintf("Received body type does not match: [0] %v -> [1] %v", event.Body[0], event.Body[1])
-					continue
+			case dbus.ObjectPath:
+				// fmt.Sprintf("Received body type does not match: [0] %v -> [1] %v", event.Body[0], event.Body[1])
+				continue
 
-				case string:
-					// fmt.Sprintf("body type match")
-				}
+			case string:
+				// fmt.Sprintf("body type match")
+			}
 
-				if event.Body[0] != bluez.GattCharacteristic1Interface {
-					// fmt.Sprintf("Skip interface %s", event.Body[0])
-					continue
-				}
+			if event.Body[0] != bluez.GattCharacteristic1Interface {
+				// fmt.Sprintf("Skip interface %s", event.Body[0])
+				continue
+			}
 
-				props := event.Body[1].(map[string]dbus.Variant)
+			props := event.Body[1].(map[string]dbus.Variant)
 
-				if _, ok := props["Value"]; !ok {
-					// fmt.Sprintf("Cannot read Value property %v", props)
-					continue
-				}
+			if _, ok := props["Value"]; !ok {
+				// fmt.Sprintf("Cannot read Value property %v", props)
+				continue
+			}
 
-				b := props["Value"].Value().([]byte)
-				//log.Debug("length of temperature data: ",len(b)," ,data: ",b)
+			b := props["Value"].Value().([]byte)
+			//log.Debug("length of temperature data: ",len(b)," ,data: ",b)
 
-				amb := binary.LittleEndian.Uint16(b[2:])
-				ambientValue := calcTmpLocal(uint16(amb))
+			amb := binary.LittleEndian.Uint16(b[2:])
+			ambientValue := calcTmpLocal(uint16(amb))
 
-				die := binary.LittleEndian.Uint16(b[0:2])
-				dieValue := calcTmpTarget(uint16(die))
+			die := binary.LittleEndian.Uint16(b[0:2])
+			dieValue := calcTmpTarget(uint16(die))
 
-				//log.Debug("ambientValue: ",ambientValue)
+			//log.Debug("ambientValue: ",ambientValue)
 
-				dataEvent := api.DataEvent{
+			dataEvent := api.DataEvent{
 
-					Device:           s.tag.Device,
-					SensorType:       "temperature",
-					AmbientTempValue: ambientValue,
-					AmbientTempUnit:  "C",
-					ObjectTempValue:  dieValue,
-					ObjectTempUnit:   "C",
-					SensorId:         macAddress,
-				}
-				s.tag.Device.Emit("data", dataEvent)
+				Device:           s.tag.Device,
+				SensorType:       "temperature",
+				AmbientTempValue: ambientValue,
+				AmbientTempUnit:  "C",
+				ObjectTempValue:  dieValue,
+				ObjectTempUnit:   "C",
+				SensorId:         macAddress,
 			}
+			s.tag.Device.Emit("data", dataEvent)
 		}
 	}()
 
@@ -1533,8 +1400,7 @@ func (s *LuxometerSensor) Enable() error {
 	if enabled {
 		return nil
 	}
-	options := make(map[string]dbus.Variant)
-	err = s.cfg.WriteValue([]byte{1}, options)
+	err = s.cfg.Write([]byte{1}, nil)
 	if err != nil {
 		return err
 	}
@@ -1551,8 +1417,7 @@ func (s *LuxometerSensor) Disable() error {
 	if !enabled {
 		return nil
 	}
-	options := make(map[string]dbus.Variant)
-	err = s.cfg.WriteValue([]byte{0}, options)
+	err = s.cfg.Write([]byte{0}, nil)
 	if err != nil {
 		return err
 	}
@@ -1562,9 +1427,8 @@ func (s *LuxometerSensor) Disable() error {
 //.........IsEnabled check if LuxometerSensor measurements are enabled.......
 
 func (s *LuxometerSensor) IsEnabled() (bool, error) {
-	options := make(map[string]dbus.Variant)
 
-	val, err := s.cfg.ReadValue(options)
+	val, err := s.cfg.Read(nil)
 	if err != nil {
 		return false, err
 	}
@@ -1599,8 +1463,7 @@ func (s *LuxometerSensor) Read() (float64, error) {
 		return 0, err
 	}
 
-	options := make(map[string]dbus.Variant)
-	b, err := s.data.ReadValue(options)
+	b, err := s.data.Read(nil)
 
 	fmt.Sprintf("Read data: %v", b)
 
@@ -1622,24 +1485,6 @@ func (s *LuxometerSensor) Read() (float64, error) {
 func (s *LuxometerSensor) StartNotify(macAddress string) error {
 
 	//log.Debug("LuxometerSensor tag value: ",s.tag.Device)
-	d := s.tag.Device
-	serv, err1 := d.GetAllServicesAndUUID()
-
-	if err1 != nil {
-
-	}
-	var uuidAndService string
-	serviceArrLength := len(serv)
-	for i := 0; i < serviceArrLength; i++ {
-
-		//log.Debug("value of all service's and uuid's for Luxometer sensor : ",serv[i])
-		val := strings.Split(serv[i], ":")
-
-		if val[0] == "F000AA71-0451-4000-B000-000000000000" {
-			//log.Debug("value of service and uuid for Luxometer sensor : ",val)
-			uuidAndService = val[1]
-		}
-	}
 	fmt.Sprintf("Enabling LuxometerSensorDataChannel")
 
 	err := s.Enable()
@@ -1658,54 +1503,52 @@ func (s *LuxometerSensor) StartNotify(macAddress string) error {
 			if event1 == nil {
 				return
 			}
-			if strings.Contains(fmt.Sprint(event1.Path), uuidAndService) {
 
-				//log.Debug("Got update  LuxometerSensor dataChannel: ", event1)
-				//log.Debug("Value of event1.name: ", event1.Name)
-				//log.Debug("name of service and char: ", event1.Path)
+			//log.Debug("Got update  LuxometerSensor dataChannel: ", event1)
+			//log.Debug("Value of event1.name: ", event1.Name)
+			//log.Debug("name of service and char: ", event1.Path)
 
-				switch event1.Body[0].(type) {
+			switch event1.Body[0].(type) {
 
-				case dbus.ObjectPath:
-					//log.Debug("Received body type does not match: [0] %v -> [1] %v", event1.Body[0], event1.Body[1])
-					continue
+			case dbus.ObjectPath:
+				//log.Debug("Received body type does not match: [0] %v -> [1] %v", event1.Body[0], event1.Body[1])
+				continue
 
-				case string:
-					//log.Debug("body type match")
-				}
+			case string:
+				//log.Debug("body type match")
+			}
 
-				if event1.Body[0] != bluez.GattCharacteristic1Interface {
+			if event1.Body[0] != bluez.GattCharacteristic1Interface {
 
-					// fmt.Sprintf("Skip interface %s", event1.Body[0])
-					continue
-				}
+				// fmt.Sprintf("Skip interface %s", event1.Body[0])
+				continue
+			}
 
-				props1 := event1.Body[1].(map[string]dbus.Variant)
+			props1 := event1.Body[1].(map[string]dbus.Variant)
 
-				if _, ok := props1["Value"]; !ok {
+			if _, ok := props1["Value"]; !ok {
 
-					// fmt.Sprintf("Cannot read Value property %v", props1)
-					continue
-				}
+				// fmt.Sprintf("Cannot read Value property %v", props1)
+				continue
+			}
 
-				b1 := props1["Value"].Value().([]byte)
-				//log.Debug("length of data for luxometer: ",len(b1)," ,luxometer data: ",b1)
+			b1 := props1["Value"].Value().([]byte)
+			//log.Debug("length of data for luxometer: ",len(b1)," ,luxometer data: ",b1)
 
-				luxometer := binary.LittleEndian.Uint16(b1[0:])
-				luxometerValue := calcLuxometer(uint16(luxometer))
+			luxometer := binary.LittleEndian.Uint16(b1[0:])
+			luxometerValue := calcLuxometer(uint16(luxometer))
 
-				//log.Debug("luxometerValue: ",luxometerValue )
+			//log.Debug("luxometerValue: ",luxometerValue )
 
-				dataEvent := api.DataEvent{
+			dataEvent := api.DataEvent{
 
-					Device:         s.tag.Device,
-					SensorType:     "luxometer",
-					LuxometerValue: luxometerValue,
-					LuxometerUnit:  "candela",
-					SensorId:       macAddress,
-				}
-				s.tag.Device.Emit("data", dataEvent)
+				Device:         s.tag.Device,
+				SensorType:     "luxometer",
+				LuxometerValue: luxometerValue,
+				LuxometerUnit:  "candela",
+				SensorId:       macAddress,
 			}
+			s.tag.Device.Emit("data", dataEvent)
 		}
 	}()
 
@@ -1944,17 +1787,13 @@ type SensorTagDeviceInfo struct {
 
 func (s *SensorTagDeviceInfo) Read() (api.DataEvent, error) {
 
-	options1 := make(map[string]dbus.Variant)
-	fw, err := s.firmwareInfo.ReadValue(options1)
+	fw, err := s.firmwareInfo.Read(nil)
 
-	options2 := make(map[string]dbus.Variant)
-	hw, err := s.hardwareInfo.ReadValue(options2)
+	hw, err := s.hardwareInfo.Read(nil)
 
-	options3 := make(map[string]dbus.Variant)
-	manufacturer, err := s.manufacturerInfo.ReadValue(options3)
+	manufacturer, err := s.manufacturerInfo.Read(nil)
 
-	options4 := make(map[string]dbus.Variant)
-	model, err := s.modelInfo.ReadValue(options4)
+	model, err := s.modelInfo.Read(nil)
 
 	//log.Debug(" system info for sensorTag: ",string(fw),string(hw),string(manufacturer),string(model),)
 
@@ -3,8 +3,6 @@ package linux
 import (
 	"errors"
 	sddbus "github.com/coreos/go-systemd/dbus"
-	"github.com/godbus/dbus"
-	"github.com/saurabh-newera/BLE/bluez"
 )
 
 type result string
@@ -28,10 +26,10 @@ const bluetoothUnitName = "bluetooth.service"
 // @see https://github.com/coreos/go-systemd/blob/master/dbus/methods.go#L65
 const defaultMode = "replace"
 
+// getConnection use a private connection as closing it would otherwise
+// break the connection shared by the bluez clients
 func getConnection() (*sddbus.Conn, error) {
-	return sddbus.NewConnection(func() (*dbus.Conn, error) {
-		return bluez.GetConnection(bluez.SystemBus)
-	})
+	return sddbus.NewSystemConnection()
 }
 
 func watchOperation() {
@@ -0,0 +1,123 @@
+package sdp
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Element a data element of an SDP record, eg. an UUID or a sequence
+type Element struct {
+	XMLName  xml.Name
+	Value    string    `xml:"value,attr,omitempty"`
+	Children []Element `xml:",omitempty"`
+	// err is set by the constructors for invalid values and reported by Record.XML
+	err error
+}
+
+var (
+	uuid16  = regexp.MustCompile(`^(?i)(0x)?[0-9a-f]{4}$`)
+	uuid32  = regexp.MustCompile(`^(?i)(0x)?[0-9a-f]{8}$`)
+	uuid128 = regexp.MustCompile(`^(?i)[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+)
+
+func element(name string, value string) Element {
+	return Element{XMLName: xml.Name{Local: name}, Value: value}
+}
+
+// UUID a 16, 32 or 128 bit UUID, eg. 1101, 0x1101 or 00001101-0000-1000-8000-00805f9b34fb
+func UUID(uuid string) Element {
+	switch {
+	case uuid16.MatchString(uuid), uuid32.MatchString(uuid):
+		return element("uuid", "0x"+strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(uuid, "0x"), "0X")))
+	case uuid128.MatchString(uuid):
+		return element("uuid", strings.ToLower(uuid))
+	}
+	e := element("uuid", uuid)
+	e.err = fmt.Errorf("Invalid UUID %q", uuid)
+	return e
+}
+
+// UUID16 a 16 bit UUID, eg. L2CAP
+func UUID16(uuid uint16) Element {
+	return element("uuid", fmt.Sprintf("0x%04x", uuid))
+}
+
+// Uint8 an unsigned 8 bit integer
+func Uint8(v uint8) Element {
+	return element("uint8", fmt.Sprintf("0x%02x", v))
+}
+
+// Uint16 an unsigned 16 bit integer
+func Uint16(v uint16) Element {
+	return element("uint16", fmt.Sprintf("0x%04x", v))
+}
+
+// Uint32 an unsigned 32 bit integer
+func Uint32(v uint32) Element {
+	return element("uint32", fmt.Sprintf("0x%08x", v))
+}
+
+// Uint64 an unsigned 64 bit integer
+func Uint64(v uint64) Element {
+	return element("uint64", fmt.Sprintf("0x%016x", v))
+}
+
+// Int8 a signed 8 bit integer
+func Int8(v int8) Element {
+	return element("int8", strconv.Itoa(int(v)))
+}
+
+// Int16 a signed 16 bit integer
+func Int16(v int16) Element {
+	return element("int16", strconv.Itoa(int(v)))
+}
+
+// Int32 a signed 32 bit integer
+func Int32(v int32) Element {
+	return element("int32", strconv.Itoa(int(v)))
+}
+
+// Bool a boolean
+func Bool(v bool) Element {
+	return element("boolean", strconv.FormatBool(v))
+}
+
+// Text a string
+func Text(v string) Element {
+	return element("text", v)
+}
+
+// URL an URL
+func URL(v string) Element {
+	return element("url", v)
+}
+
+// Sequence a list of elements
+func Sequence(children ...Element) Element {
+	e := element("sequence", "")
+	e.Children = children
+	return e
+}
+
+// Alternate a list of elements, one of them is selected
+func Alternate(children ...Element) Element {
+	e := element("alternate", "")
+	e.Children = children
+	return e
+}
+
+// Err return the first invalid value of the element or its children
+func (e Element) Err() error {
+	if e.err != nil {
+		return e.err
+	}
+	for _, child := range e.Children {
+		if err := child.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+// Package sdp build SDP service records in the XML format of bluez, eg. for the
+// ServiceRecord of profile.ProfileOptions
+package sdp
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// The universal attribute IDs
+const (
+	AttrServiceRecordHandle              uint16 = 0x0000
+	AttrServiceClassIDList               uint16 = 0x0001
+	AttrServiceRecordState               uint16 = 0x0002
+	AttrServiceID                        uint16 = 0x0003
+	AttrProtocolDescriptorList           uint16 = 0x0004
+	AttrBrowseGroupList                  uint16 = 0x0005
+	AttrLanguageBaseAttributeIDList      uint16 = 0x0006
+	AttrServiceInfoTimeToLive            uint16 = 0x0007
+	AttrServiceAvailability              uint16 = 0x0008
+	AttrProfileDescriptorList            uint16 = 0x0009
+	AttrDocumentationURL                 uint16 = 0x000a
+	AttrClientExecutableURL              uint16 = 0x000b
+	AttrIconURL                          uint16 = 0x000c
+	AttrAdditionalProtocolDescriptorList uint16 = 0x000d
+	AttrServiceName                      uint16 = 0x0100
+	AttrServiceDescription               uint16 = 0x0101
+	AttrProviderName                     uint16 = 0x0102
+	AttrSupportedFeatures                uint16 = 0x0311
+)
+
+// The protocol UUIDs of a protocol descriptor list
+const (
+	ProtocolL2CAP  uint16 = 0x0100
+	ProtocolRFCOMM uint16 = 0x0003
+	ProtocolOBEX   uint16 = 0x0008
+)
+
+// PublicBrowseGroup the browse group listing the services of a device
+const PublicBrowseGroup = "1002"
+
+// Record an SDP service record, the attributes are sorted by ID in the XML
+type Record struct {
+	attributes map[uint16]Element
+}
+
+// NewRecord create an empty record
+func NewRecord() *Record {
+	return &Record{attributes: make(map[uint16]Element)}
+}
+
+// Set the value of an attribute
+func (r *Record) Set(id uint16, value Element) *Record {
+	r.attributes[id] = value
+	return r
+}
+
+// Get return the value of an attribute
+func (r *Record) Get(id uint16) (Element, bool) {
+	e, ok := r.attributes[id]
+	return e, ok
+}
+
+// ServiceClasses set the service class UUIDs, the most specific first
+func (r *Record) ServiceClasses(uuids ...string) *Record {
+	list := make([]Element, len(uuids))
+	for i, uuid := range uuids {
+		list[i] = UUID(uuid)
+	}
+	return r.Set(AttrServiceClassIDList, Sequence(list...))
+}
+
+// Protocols set the protocol descriptor list, each protocol is a sequence
+// of its UUID and parameters
+func (r *Record) Protocols(protocols ...Element) *Record {
+	return r.Set(AttrProtocolDescriptorList, Sequence(protocols...))
+}
+
+// RFCOMM set the protocol descriptor list to RFCOMM over L2CAP on a channel
+func (r *Record) RFCOMM(channel uint8) *Record {
+	return r.Protocols(
+		Sequence(UUID16(ProtocolL2CAP)),
+		Sequence(UUID16(ProtocolRFCOMM), Uint8(channel)),
+	)
+}
+
+// L2CAP set the protocol descriptor list to L2CAP on a PSM
+func (r *Record) L2CAP(psm uint16) *Record {
+	return r.Protocols(
+		Sequence(UUID16(ProtocolL2CAP), Uint16(psm)),
+	)
+}
+
+// OBEX set the protocol descriptor list to OBEX over RFCOMM on a channel
+func (r *Record) OBEX(channel uint8) *Record {
+	return r.Protocols(
+		Sequence(UUID16(ProtocolL2CAP)),
+		Sequence(UUID16(ProtocolRFCOMM), Uint8(channel)),
+		Sequence(UUID16(ProtocolOBEX)),
+	)
+}
+
+// BrowseGroups set the browse groups, eg. PublicBrowseGroup
+func (r *Record) BrowseGroups(uuids ...string) *Record {
+	list := make([]Element, len(uuids))
+	for i, uuid := range uuids {
+		list[i] = UUID(uuid)
+	}
+	return r.Set(AttrBrowseGroupList, Sequence(list...))
+}
+
+// Profile add a profile descriptor with its version, eg. 0x0102 for 1.2
+func (r *Record) Profile(uuid string, version uint16) *Record {
+	descriptor := Sequence(UUID(uuid), Uint16(version))
+	list, ok := r.attributes[AttrProfileDescriptorList]
+	if !ok {
+		list = Sequence()
+	}
+	list.Children = append(list.Children, descriptor)
+	return r.Set(AttrProfileDescriptorList, list)
+}
+
+// Name set the service name
+func (r *Record) Name(name string) *Record {
+	return r.Set(AttrServiceName, Text(name))
+}
+
+// Description set the service description
+func (r *Record) Description(description string) *Record {
+	return r.Set(AttrServiceDescription, Text(description))
+}
+
+// Provider set the provider name
+func (r *Record) Provider(provider string) *Record {
+	return r.Set(AttrProviderName, Text(provider))
+}
+
+// Features set the supported features of the profile
+func (r *Record) Features(features uint16) *Record {
+	return r.Set(AttrSupportedFeatures, Uint16(features))
+}
+
+// xmlAttribute an attribute of the XML record
+type xmlAttribute struct {
+	ID    string  `xml:"id,attr"`
+	Value Element `xml:",any"`
+}
+
+// xmlRecord the root of the XML record
+type xmlRecord struct {
+	XMLName    xml.Name       `xml:"record"`
+	Attributes []xmlAttribute `xml:"attribute"`
+}
+
+// XML return the record in the format of bluez, the first invalid value is returned as error
+func (r *Record) XML() (string, error) {
+
+	if _, ok := r.attributes[AttrServiceClassIDList]; !ok {
+		return "", errors.New("A record needs a service class list")
+	}
+
+	ids := make([]int, 0, len(r.attributes))
+	for id := range r.attributes {
+		ids = append(ids, int(id))
+	}
+	sort.Ints(ids)
+
+	record := xmlRecord{}
+	for _, id := range ids {
+		value := r.attributes[uint16(id)]
+		if err := value.Err(); err != nil {
+			return "", fmt.Errorf("Attribute 0x%04x: %w", id, err)
+		}
+		record.Attributes = append(record.Attributes, xmlAttribute{
+			ID:    fmt.Sprintf("0x%04x", id),
+			Value: value,
+		})
+	}
+
+	b, err := xml.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(b) + "\n", nil
+}
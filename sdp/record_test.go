@@ -0,0 +1,74 @@
+package sdp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRecordXML(t *testing.T) {
+
+	record := NewRecord().
+		ServiceClasses("1101").
+		RFCOMM(3).
+		BrowseGroups(PublicBrowseGroup).
+		Profile("0x1101", 0x0102).
+		Name("Serial & Console")
+
+	out, err := record.XML()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<record>
+  <attribute id="0x0001">
+    <sequence>
+      <uuid value="0x1101"></uuid>
+    </sequence>
+  </attribute>
+  <attribute id="0x0004">
+    <sequence>
+      <sequence>
+        <uuid value="0x0100"></uuid>
+      </sequence>
+      <sequence>
+        <uuid value="0x0003"></uuid>
+        <uint8 value="0x03"></uint8>
+      </sequence>
+    </sequence>
+  </attribute>
+  <attribute id="0x0005">
+    <sequence>
+      <uuid value="0x1002"></uuid>
+    </sequence>
+  </attribute>
+  <attribute id="0x0009">
+    <sequence>
+      <sequence>
+        <uuid value="0x1101"></uuid>
+        <uint16 value="0x0102"></uint16>
+      </sequence>
+    </sequence>
+  </attribute>
+  <attribute id="0x0100">
+    <text value="Serial &amp; Console"></text>
+  </attribute>
+</record>
+`
+	if out != expected {
+		t.Fatalf("Unexpected record:\n%s", out)
+	}
+}
+
+func TestRecordErrors(t *testing.T) {
+
+	_, err := NewRecord().Name("no class").XML()
+	if err == nil {
+		t.Fatal("Expected an error for a record without service class")
+	}
+
+	_, err = NewRecord().ServiceClasses("1101").Profile("serial", 0x0100).XML()
+	if err == nil || !strings.Contains(err.Error(), "0x0009") {
+		t.Fatalf("Expected an invalid UUID in attribute 0x0009, got %v", err)
+	}
+}
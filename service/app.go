@@ -0,0 +1,246 @@
+// Package service expose GATT services from Go, bluez acting as a peripheral.
+// Services, characteristics and descriptors are defined in an App, exported under
+// an ObjectManager and registered with the org.bluez.GattManager1 of an adapter
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/bluez"
+	"github.com/saurabh-newera/BLE/bluez/profile"
+)
+
+// BasePath the object path prefix of the applications
+const BasePath = "/org/bluez/go/app"
+
+var (
+	appsLock sync.Mutex
+	appsSeq  int
+)
+
+// AppOptions configure an application
+type AppOptions struct {
+	// AdapterID is the adapter exposing the services, eg. hci0
+	AdapterID string
+	// Path of the application object, a unique path under BasePath is used by default
+	Path dbus.ObjectPath
+	// Options customize the D-Bus connection, eg. profile.WithAddress
+	Options []profile.Option
+}
+
+// NewApp create a new GATT application
+func NewApp(options AppOptions) *App {
+
+	if options.AdapterID == "" {
+		options.AdapterID = "hci0"
+	}
+	if options.Path == "" {
+		appsLock.Lock()
+		options.Path = dbus.ObjectPath(fmt.Sprintf("%s%d", BasePath, appsSeq))
+		appsSeq++
+		appsLock.Unlock()
+	}
+
+	config := &bluez.Config{
+		Name:  "org.bluez",
+		Iface: bluez.ObjectManagerInterface,
+		Path:  string(options.Path),
+		Bus:   bluez.SystemBus,
+	}
+	for _, opt := range options.Options {
+		opt(config)
+	}
+
+	return &App{
+		options: options,
+		client:  bluez.NewClient(config),
+		manager: profile.NewGattManager1(options.AdapterID, options.Options...),
+	}
+}
+
+// App a GATT application, a set of services registered at once
+type App struct {
+	options AppOptions
+	client  *bluez.Client
+	manager *profile.GattManager1
+
+	lock     sync.Mutex
+	services []*Service
+	exported []object
+
+	// runLock serialize Run and Close, bluez calls back the application while registering
+	runLock    sync.Mutex
+	registered bool
+}
+
+// Path return the object path of the application
+func (a *App) Path() dbus.ObjectPath {
+	return a.options.Path
+}
+
+// NewService add a primary service, services added after Run are exposed on the next Run
+func (a *App) NewService(uuid string) *Service {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	s := &Service{
+		app:     a,
+		path:    dbus.ObjectPath(fmt.Sprintf("%s/service%d", a.options.Path, len(a.services))),
+		UUID:    uuid,
+		Primary: true,
+	}
+	a.services = append(a.services, s)
+	return s
+}
+
+// Services return the services of the application
+func (a *App) Services() []*Service {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return append([]*Service{}, a.services...)
+}
+
+// objects return the services, characteristics and descriptors
+func (a *App) objects() []object {
+	var list []object
+	for _, s := range a.Services() {
+		list = append(list, s)
+		for _, c := range s.Characteristics() {
+			list = append(list, c)
+			for _, d := range c.Descriptors() {
+				list = append(list, d)
+			}
+		}
+	}
+	return list
+}
+
+//GetManagedObjects is called by bluez to list the objects of the application
+func (a *App) GetManagedObjects() (map[dbus.ObjectPath]map[string]map[string]dbus.Variant, *dbus.Error) {
+	objects := make(map[dbus.ObjectPath]map[string]map[string]dbus.Variant)
+	for _, obj := range a.objects() {
+		objects[obj.objectPath()] = map[string]map[string]dbus.Variant{
+			obj.objectInterface(): obj.properties(),
+		}
+	}
+	return objects, nil
+}
+
+func (a *App) objectPath() dbus.ObjectPath {
+	return a.options.Path
+}
+
+func (a *App) objectInterface() string {
+	return bluez.ObjectManagerInterface
+}
+
+func (a *App) properties() map[string]dbus.Variant {
+	return map[string]dbus.Variant{}
+}
+
+// children return the direct children names of path among the objects
+func children(path dbus.ObjectPath, objects []object) []string {
+	var list []string
+	prefix := string(path) + "/"
+	for _, obj := range objects {
+		name := strings.TrimPrefix(string(obj.objectPath()), prefix)
+		if name != string(obj.objectPath()) && !strings.Contains(name, "/") {
+			list = append(list, name)
+		}
+	}
+	return list
+}
+
+// Run export the application and register it with the adapter
+func (a *App) Run() error {
+	return a.RunContext(context.Background())
+}
+
+// RunContext export the application and register it, aborting when ctx is done
+func (a *App) RunContext(ctx context.Context) error {
+
+	a.runLock.Lock()
+	defer a.runLock.Unlock()
+
+	if a.registered {
+		a.manager.UnregisterApplicationContext(ctx, a.options.Path)
+		a.registered = false
+	}
+
+	objects := a.objects()
+	err := a.client.Export(a, a.options.Path, bluez.ObjectManagerInterface)
+	if err != nil {
+		return err
+	}
+	err = a.client.Export(introspectable(a, children(a.options.Path, objects)), a.options.Path, bluez.IntrospectableInterface)
+	if err != nil {
+		a.unexport()
+		return err
+	}
+	for i, obj := range objects {
+		err = export(a.client, obj, children(obj.objectPath(), objects))
+		if err != nil {
+			// remove the root and the objects exported so far
+			a.lock.Lock()
+			a.exported = objects[:i+1]
+			a.lock.Unlock()
+			a.unexport()
+			return err
+		}
+	}
+	a.lock.Lock()
+	a.exported = objects
+	a.lock.Unlock()
+
+	err = a.manager.RegisterApplicationContext(ctx, a.options.Path, map[string]interface{}{})
+	if err != nil {
+		a.unexport()
+		return err
+	}
+	a.registered = true
+	return nil
+}
+
+// unexport remove the objects of the application from the bus
+func (a *App) unexport() {
+	a.lock.Lock()
+	exported := a.exported
+	a.exported = nil
+	a.lock.Unlock()
+	for _, obj := range exported {
+		unexport(a.client, obj)
+	}
+	unexport(a.client, a)
+}
+
+// emit send a PropertiesChanged signal for an object, once the application is exported
+func (a *App) emit(path dbus.ObjectPath, iface string, changed map[string]dbus.Variant) error {
+	a.lock.Lock()
+	exported := a.exported != nil
+	a.lock.Unlock()
+	if !exported {
+		return nil
+	}
+	return a.client.Emit(path, bluez.PropertiesChanged, iface, changed, []string{})
+}
+
+// Close unregister the application and remove its objects from the bus
+func (a *App) Close() error {
+
+	a.runLock.Lock()
+	defer a.runLock.Unlock()
+
+	var err error
+	if a.registered {
+		err = a.manager.UnregisterApplication(a.options.Path)
+		a.registered = false
+	}
+	a.unexport()
+
+	a.client.Disconnect()
+	a.manager.Close()
+	return err
+}
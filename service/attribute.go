@@ -0,0 +1,426 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/bluez"
+	"github.com/saurabh-newera/BLE/util"
+)
+
+// The flags of characteristics and descriptors
+const (
+	FlagBroadcast                 = "broadcast"
+	FlagRead                      = "read"
+	FlagWriteWithoutResponse      = "write-without-response"
+	FlagWrite                     = "write"
+	FlagNotify                    = "notify"
+	FlagIndicate                  = "indicate"
+	FlagAuthenticatedSignedWrites = "authenticated-signed-writes"
+	FlagReliableWrite             = "reliable-write"
+	FlagWritableAuxiliaries       = "writable-auxiliaries"
+	FlagEncryptRead               = "encrypt-read"
+	FlagEncryptWrite              = "encrypt-write"
+	FlagEncryptAuthenticatedRead  = "encrypt-authenticated-read"
+	FlagEncryptAuthenticatedWrite = "encrypt-authenticated-write"
+	FlagSecureRead                = "secure-read"
+	FlagSecureWrite               = "secure-write"
+	FlagAuthorize                 = "authorize"
+)
+
+// Request the options sent by bluez with a read or write request
+type Request struct {
+	// Device is the remote device
+	Device dbus.ObjectPath `dbus:"device"`
+	// Offset in the value
+	Offset uint16 `dbus:"offset"`
+	// MTU of the link
+	MTU uint16 `dbus:"mtu"`
+	// Link type, eg. LE or BR/EDR
+	Link string `dbus:"link"`
+	// Type of write, eg. command, request or reliable
+	Type string `dbus:"type"`
+	// PrepareAuthorize is set when the write is only checked for authorization
+	PrepareAuthorize bool `dbus:"prepare-authorize"`
+}
+
+// parseRequest read the options of a request, unknown or malformed options are ignored
+func parseRequest(options map[string]dbus.Variant) Request {
+	var req Request
+	util.MapToStruct(&req, options)
+	return req
+}
+
+// ReadHandler return the value of an attribute. Return eg. bluez.ErrNotPermitted to refuse the read
+type ReadHandler func(req Request) ([]byte, error)
+
+// WriteHandler handle a write of an attribute, the value is stored when nil is returned
+type WriteHandler func(value []byte, req Request) error
+
+// attribute the value and handlers shared by characteristics and descriptors
+type attribute struct {
+	lock    sync.RWMutex
+	value   []byte
+	onRead  ReadHandler
+	onWrite WriteHandler
+}
+
+// getValue return a copy of the value
+func (a *attribute) getValue() []byte {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	return append([]byte{}, a.value...)
+}
+
+// setValue replace the value
+func (a *attribute) setValue(value []byte) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.value = append([]byte{}, value...)
+}
+
+// read handle ReadValue, the stored value is returned without handler
+func (a *attribute) read(options map[string]dbus.Variant) ([]byte, error) {
+
+	req := parseRequest(options)
+
+	a.lock.RLock()
+	handler := a.onRead
+	a.lock.RUnlock()
+
+	if handler != nil {
+		return handler(req)
+	}
+
+	value := a.getValue()
+	if int(req.Offset) > len(value) {
+		return nil, bluez.ErrInvalidOffset
+	}
+	return value[req.Offset:], nil
+}
+
+// write handle WriteValue, the value is stored at the requested offset once accepted
+func (a *attribute) write(value []byte, options map[string]dbus.Variant) error {
+
+	req := parseRequest(options)
+
+	a.lock.RLock()
+	handler := a.onWrite
+	a.lock.RUnlock()
+
+	if handler != nil {
+		err := handler(value, req)
+		if err != nil {
+			return err
+		}
+	}
+	if req.PrepareAuthorize {
+		return nil
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if int(req.Offset) > len(a.value) {
+		return bluez.ErrInvalidOffset
+	}
+	a.value = append(append([]byte{}, a.value[:req.Offset]...), value...)
+	return nil
+}
+
+// Service a GATT service of an application
+type Service struct {
+	app     *App
+	path    dbus.ObjectPath
+	UUID    string
+	Primary bool
+
+	lock  sync.Mutex
+	chars []*Characteristic
+}
+
+// Path return the object path of the service
+func (s *Service) Path() dbus.ObjectPath {
+	return s.path
+}
+
+// NewCharacteristic add a characteristic to the service, eg. with FlagRead and FlagNotify
+func (s *Service) NewCharacteristic(uuid string, flags ...string) *Characteristic {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	c := &Characteristic{
+		service: s,
+		path:    dbus.ObjectPath(fmt.Sprintf("%s/char%d", s.path, len(s.chars))),
+		UUID:    uuid,
+		Flags:   flags,
+	}
+	s.chars = append(s.chars, c)
+	return c
+}
+
+// Characteristics return the characteristics of the service
+func (s *Service) Characteristics() []*Characteristic {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]*Characteristic{}, s.chars...)
+}
+
+func (s *Service) objectPath() dbus.ObjectPath {
+	return s.path
+}
+
+func (s *Service) objectInterface() string {
+	return bluez.GattService1Interface
+}
+
+func (s *Service) properties() map[string]dbus.Variant {
+	return map[string]dbus.Variant{
+		"UUID":     dbus.MakeVariant(s.UUID),
+		"Primary":  dbus.MakeVariant(s.Primary),
+		"Includes": dbus.MakeVariant([]dbus.ObjectPath{}),
+	}
+}
+
+// Characteristic a GATT characteristic of an application
+type Characteristic struct {
+	attribute
+	service *Service
+	path    dbus.ObjectPath
+	UUID    string
+	Flags   []string
+
+	notifyLock sync.Mutex
+	notifying  bool
+	onNotify   func(notifying bool)
+
+	descrLock sync.Mutex
+	descrs    []*Descriptor
+}
+
+// Path return the object path of the characteristic
+func (c *Characteristic) Path() dbus.ObjectPath {
+	return c.path
+}
+
+// Service return the service of the characteristic
+func (c *Characteristic) Service() *Service {
+	return c.service
+}
+
+// OnRead set the handler of the reads, the stored value is returned otherwise
+func (c *Characteristic) OnRead(handler ReadHandler) *Characteristic {
+	c.attribute.lock.Lock()
+	defer c.attribute.lock.Unlock()
+	c.onRead = handler
+	return c
+}
+
+// OnWrite set the handler of the writes, the value is stored when the handler returns nil
+func (c *Characteristic) OnWrite(handler WriteHandler) *Characteristic {
+	c.attribute.lock.Lock()
+	defer c.attribute.lock.Unlock()
+	c.onWrite = handler
+	return c
+}
+
+// OnNotify set a function called when a device starts or stops the notifications
+func (c *Characteristic) OnNotify(handler func(notifying bool)) *Characteristic {
+	c.notifyLock.Lock()
+	defer c.notifyLock.Unlock()
+	c.onNotify = handler
+	return c
+}
+
+// NewDescriptor add a descriptor to the characteristic, eg. with FlagRead
+func (c *Characteristic) NewDescriptor(uuid string, flags ...string) *Descriptor {
+	c.descrLock.Lock()
+	defer c.descrLock.Unlock()
+	d := &Descriptor{
+		char:  c,
+		path:  dbus.ObjectPath(fmt.Sprintf("%s/desc%d", c.path, len(c.descrs))),
+		UUID:  uuid,
+		Flags: flags,
+	}
+	c.descrs = append(c.descrs, d)
+	return d
+}
+
+// Descriptors return the descriptors of the characteristic
+func (c *Characteristic) Descriptors() []*Descriptor {
+	c.descrLock.Lock()
+	defer c.descrLock.Unlock()
+	return append([]*Descriptor{}, c.descrs...)
+}
+
+// Value return the stored value
+func (c *Characteristic) Value() []byte {
+	return c.getValue()
+}
+
+// SetValue store the value, the subscribed devices are notified
+func (c *Characteristic) SetValue(value []byte) error {
+	c.setValue(value)
+	return c.notify()
+}
+
+// Notifying check if a device subscribed to the notifications
+func (c *Characteristic) Notifying() bool {
+	c.notifyLock.Lock()
+	defer c.notifyLock.Unlock()
+	return c.notifying
+}
+
+// notify signal the value change, when notifying and exported
+func (c *Characteristic) notify() error {
+	if !c.Notifying() {
+		return nil
+	}
+	return c.service.app.emit(c.path, bluez.GattCharacteristic1Interface, map[string]dbus.Variant{
+		"Value": dbus.MakeVariant(c.getValue()),
+	})
+}
+
+// setNotifying update Notifying and signal the change
+func (c *Characteristic) setNotifying(notifying bool) error {
+	c.notifyLock.Lock()
+	if c.notifying == notifying {
+		c.notifyLock.Unlock()
+		return nil
+	}
+	c.notifying = notifying
+	handler := c.onNotify
+	c.notifyLock.Unlock()
+
+	if handler != nil {
+		handler(notifying)
+	}
+	return c.service.app.emit(c.path, bluez.GattCharacteristic1Interface, map[string]dbus.Variant{
+		"Notifying": dbus.MakeVariant(notifying),
+	})
+}
+
+//ReadValue is called by bluez to read the value
+func (c *Characteristic) ReadValue(options map[string]dbus.Variant) ([]byte, *dbus.Error) {
+	value, err := c.read(options)
+	return value, bluez.ToDBusError(err)
+}
+
+//WriteValue is called by bluez to write the value, the subscribed devices are notified
+// only when the application changes the value with SetValue
+func (c *Characteristic) WriteValue(value []byte, options map[string]dbus.Variant) *dbus.Error {
+	return bluez.ToDBusError(c.write(value, options))
+}
+
+//StartNotify is called by bluez when a device subscribes to the notifications
+func (c *Characteristic) StartNotify() *dbus.Error {
+	return bluez.ToDBusError(c.setNotifying(true))
+}
+
+//StopNotify is called by bluez when the last device unsubscribes
+func (c *Characteristic) StopNotify() *dbus.Error {
+	return bluez.ToDBusError(c.setNotifying(false))
+}
+
+//Confirm is called by bluez when a device confirms an indication
+func (c *Characteristic) Confirm() *dbus.Error {
+	return nil
+}
+
+func (c *Characteristic) objectPath() dbus.ObjectPath {
+	return c.path
+}
+
+func (c *Characteristic) objectInterface() string {
+	return bluez.GattCharacteristic1Interface
+}
+
+func (c *Characteristic) properties() map[string]dbus.Variant {
+	flags := c.Flags
+	if flags == nil {
+		flags = []string{}
+	}
+	return map[string]dbus.Variant{
+		"UUID":      dbus.MakeVariant(c.UUID),
+		"Service":   dbus.MakeVariant(c.service.path),
+		"Value":     dbus.MakeVariant(c.getValue()),
+		"Notifying": dbus.MakeVariant(c.Notifying()),
+		"Flags":     dbus.MakeVariant(flags),
+	}
+}
+
+// Descriptor a GATT descriptor of an application
+type Descriptor struct {
+	attribute
+	char  *Characteristic
+	path  dbus.ObjectPath
+	UUID  string
+	Flags []string
+}
+
+// Path return the object path of the descriptor
+func (d *Descriptor) Path() dbus.ObjectPath {
+	return d.path
+}
+
+// Characteristic return the characteristic of the descriptor
+func (d *Descriptor) Characteristic() *Characteristic {
+	return d.char
+}
+
+// OnRead set the handler of the reads, the stored value is returned otherwise
+func (d *Descriptor) OnRead(handler ReadHandler) *Descriptor {
+	d.attribute.lock.Lock()
+	defer d.attribute.lock.Unlock()
+	d.onRead = handler
+	return d
+}
+
+// OnWrite set the handler of the writes, the value is stored when the handler returns nil
+func (d *Descriptor) OnWrite(handler WriteHandler) *Descriptor {
+	d.attribute.lock.Lock()
+	defer d.attribute.lock.Unlock()
+	d.onWrite = handler
+	return d
+}
+
+// Value return the stored value
+func (d *Descriptor) Value() []byte {
+	return d.getValue()
+}
+
+// SetValue store the value
+func (d *Descriptor) SetValue(value []byte) {
+	d.setValue(value)
+}
+
+//ReadValue is called by bluez to read the value
+func (d *Descriptor) ReadValue(options map[string]dbus.Variant) ([]byte, *dbus.Error) {
+	value, err := d.read(options)
+	return value, bluez.ToDBusError(err)
+}
+
+//WriteValue is called by bluez to write the value
+func (d *Descriptor) WriteValue(value []byte, options map[string]dbus.Variant) *dbus.Error {
+	return bluez.ToDBusError(d.write(value, options))
+}
+
+func (d *Descriptor) objectPath() dbus.ObjectPath {
+	return d.path
+}
+
+func (d *Descriptor) objectInterface() string {
+	return bluez.GattDescriptor1Interface
+}
+
+func (d *Descriptor) properties() map[string]dbus.Variant {
+	flags := d.Flags
+	if flags == nil {
+		flags = []string{}
+	}
+	return map[string]dbus.Variant{
+		"UUID":           dbus.MakeVariant(d.UUID),
+		"Characteristic": dbus.MakeVariant(d.char.path),
+		"Value":          dbus.MakeVariant(d.getValue()),
+		"Flags":          dbus.MakeVariant(flags),
+	}
+}
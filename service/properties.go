@@ -0,0 +1,79 @@
+package service
+
+import (
+	"sort"
+
+	"github.com/godbus/dbus"
+	"github.com/godbus/dbus/introspect"
+	"github.com/saurabh-newera/BLE/bluez"
+)
+
+// object an object of the application exported on the bus
+type object interface {
+	objectPath() dbus.ObjectPath
+	objectInterface() string
+	properties() map[string]dbus.Variant
+}
+
+// introspectable describe an object, its properties and the standard interfaces
+func introspectable(obj object, children []string) introspect.Introspectable {
+
+	props := obj.properties()
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	iface := introspect.Interface{
+		Name:    obj.objectInterface(),
+		Methods: introspect.Methods(obj),
+	}
+	for _, name := range names {
+		iface.Properties = append(iface.Properties, introspect.Property{
+			Name:   name,
+			Type:   props[name].Signature().String(),
+			Access: "read",
+		})
+	}
+
+	node := &introspect.Node{
+		Name: string(obj.objectPath()),
+		Interfaces: []introspect.Interface{
+			iface,
+			{
+				Name:    bluez.PropertiesInterface,
+				Methods: introspect.Methods(&bluez.ExportedProperties{}),
+			},
+		},
+	}
+	for _, child := range children {
+		node.Children = append(node.Children, introspect.Node{Name: child})
+	}
+	return introspect.NewIntrospectable(node)
+}
+
+// export expose an object with its properties and introspection data
+func export(client *bluez.Client, obj object, children []string) error {
+	path := obj.objectPath()
+	err := client.Export(obj, path, obj.objectInterface())
+	if err != nil {
+		return err
+	}
+	err = client.Export(&bluez.ExportedProperties{
+		Interface: obj.objectInterface(),
+		Values:    obj.properties,
+	}, path, bluez.PropertiesInterface)
+	if err != nil {
+		return err
+	}
+	return client.Export(introspectable(obj, children), path, bluez.IntrospectableInterface)
+}
+
+// unexport remove an object exported by export
+func unexport(client *bluez.Client, obj object) {
+	path := obj.objectPath()
+	client.Unexport(path, obj.objectInterface())
+	client.Unexport(path, bluez.PropertiesInterface)
+	client.Unexport(path, bluez.IntrospectableInterface)
+}
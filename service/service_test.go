@@ -0,0 +1,90 @@
+package service
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/bluez"
+)
+
+func TestApp(t *testing.T) {
+
+	app := NewApp(AppOptions{Path: "/test/app"})
+	battery := app.NewService("180f")
+	level := battery.NewCharacteristic("2a19", FlagRead, FlagNotify)
+	level.SetValue([]byte{80})
+	descr := level.NewDescriptor("2901", FlagRead)
+	descr.SetValue([]byte("Battery level"))
+
+	objects, err := app.GetManagedObjects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 3 {
+		t.Fatalf("Expected 3 objects, got %d", len(objects))
+	}
+
+	char := objects["/test/app/service0/char0"][bluez.GattCharacteristic1Interface]
+	if char["Service"].Value() != dbus.ObjectPath("/test/app/service0") || !bytes.Equal(char["Value"].Value().([]byte), []byte{80}) {
+		t.Fatalf("Unexpected characteristic %v", char)
+	}
+	if objects["/test/app/service0/char0/desc0"][bluez.GattDescriptor1Interface]["Characteristic"].Value() != level.Path() {
+		t.Fatal("Unexpected descriptor characteristic")
+	}
+
+	node := string(introspectable(app, children(app.Path(), app.objects())))
+	if !bytes.Contains([]byte(node), []byte(`<node name="service0"></node>`)) {
+		t.Fatalf("Missing service in introspection %s", node)
+	}
+}
+
+func TestCharacteristic(t *testing.T) {
+
+	app := NewApp(AppOptions{Path: "/test/app"})
+	c := app.NewService("fff0").NewCharacteristic("fff1", FlagRead, FlagWrite, FlagNotify)
+
+	var written []byte
+	c.OnWrite(func(value []byte, req Request) error {
+		if len(value) > 4 {
+			return bluez.ErrInvalidValueLength
+		}
+		written = value
+		return nil
+	})
+
+	if err := c.WriteValue([]byte{1, 2, 3}, nil); err != nil {
+		t.Fatal(err)
+	}
+	err := c.WriteValue([]byte{1, 2, 3, 4, 5}, nil)
+	if err == nil || err.Name != "org.bluez.Error.InvalidValueLength" {
+		t.Fatalf("Expected org.bluez.Error.InvalidValueLength, got %v", err)
+	}
+	if !bytes.Equal(written, []byte{1, 2, 3}) || !bytes.Equal(c.Value(), written) {
+		t.Fatalf("Unexpected value %v", c.Value())
+	}
+
+	// long read
+	value, err := c.ReadValue(map[string]dbus.Variant{"offset": dbus.MakeVariant(uint16(1))})
+	if err != nil || !bytes.Equal(value, []byte{2, 3}) {
+		t.Fatalf("Unexpected value %v, %v", value, err)
+	}
+	_, err = c.ReadValue(map[string]dbus.Variant{"offset": dbus.MakeVariant(uint16(9))})
+	if err == nil || err.Name != "org.bluez.Error.InvalidOffset" {
+		t.Fatalf("Expected org.bluez.Error.InvalidOffset, got %v", err)
+	}
+
+	var notifying []bool
+	c.OnNotify(func(n bool) {
+		notifying = append(notifying, n)
+	})
+	c.StartNotify()
+	c.StartNotify()
+	if !c.Notifying() {
+		t.Fatal("Expected notifying")
+	}
+	c.StopNotify()
+	if c.Notifying() || len(notifying) != 2 || notifying[0] != true {
+		t.Fatalf("Unexpected notify changes %v", notifying)
+	}
+}
@@ -0,0 +1,175 @@
+package spp
+
+import (
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/bluez"
+	"github.com/saurabh-newera/BLE/bluez/profile"
+)
+
+var (
+	profilesLock sync.Mutex
+	profilesSeq  int
+)
+
+// sppProfile a registered Serial Port profile dispatching its connections to the
+// pending dials or to a listener
+type sppProfile struct {
+	profile *profile.Profile1
+	manager *profile.ProfileManager1
+
+	// key and refs of a shared client profile, guarded by clientLock
+	key  string
+	refs int
+
+	lock    sync.Mutex
+	dials   map[dbus.ObjectPath]chan *Conn
+	accept  chan *Conn
+	conns   map[*Conn]bool
+	closed  bool
+	onClose func()
+}
+
+func newSPPProfile() *sppProfile {
+	return &sppProfile{
+		dials: make(map[dbus.ObjectPath]chan *Conn),
+		conns: make(map[*Conn]bool),
+	}
+}
+
+// register export and register the profile with a role, client or server. The
+// connections may be handed as soon as it is registered, so accept and onClose must be set
+func (p *sppProfile) register(config *Config, role string) error {
+
+	profilesLock.Lock()
+	path := fmt.Sprintf("%s%d", BasePath, profilesSeq)
+	profilesSeq++
+	profilesLock.Unlock()
+
+	p.profile = profile.NewProfile1(path, p, config.Options...)
+	p.manager = profile.NewProfileManager1(config.AdapterID, config.Options...)
+
+	err := p.profile.Export()
+	if err != nil {
+		p.profile.Close()
+		p.manager.Close()
+		return err
+	}
+
+	err = p.manager.RegisterProfileWithOptions(path, UUID, config.profileOptions(role))
+	if err != nil {
+		p.profile.Close()
+		p.manager.Close()
+		return err
+	}
+	return nil
+}
+
+// close unregister the profile and close its connections
+func (p *sppProfile) close() error {
+
+	p.lock.Lock()
+	if p.closed {
+		p.lock.Unlock()
+		return nil
+	}
+	p.closed = true
+	conns := p.conns
+	p.conns = make(map[*Conn]bool)
+	if p.accept != nil {
+		close(p.accept)
+	}
+	p.lock.Unlock()
+
+	for conn := range conns {
+		conn.Conn.Close()
+	}
+
+	err := p.manager.UnregisterProfile(string(p.profile.Path))
+	p.profile.Close()
+	p.manager.Close()
+	return err
+}
+
+// remove forget a closed connection
+func (p *sppProfile) remove(conn *Conn) {
+	p.lock.Lock()
+	delete(p.conns, conn)
+	p.lock.Unlock()
+}
+
+// dial wait for the connection of device, the returned function stops waiting
+func (p *sppProfile) dial(device dbus.ObjectPath) (chan *Conn, func()) {
+	ch := make(chan *Conn, 1)
+	p.lock.Lock()
+	p.dials[device] = ch
+	p.lock.Unlock()
+	return ch, func() {
+		p.lock.Lock()
+		if p.dials[device] == ch {
+			delete(p.dials, device)
+		}
+		p.lock.Unlock()
+	}
+}
+
+//NewConnection hand the connection to the dial waiting for the device, or to the listener
+func (p *sppProfile) NewConnection(device dbus.ObjectPath, conn net.Conn, properties map[string]dbus.Variant) error {
+
+	c := &Conn{Conn: conn, device: device, profile: p}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.closed {
+		return bluez.ErrRejected
+	}
+
+	if ch, ok := p.dials[device]; ok {
+		delete(p.dials, device)
+		p.conns[c] = true
+		ch <- c
+		return nil
+	}
+
+	if p.accept != nil {
+		select {
+		case p.accept <- c:
+			p.conns[c] = true
+			return nil
+		default:
+		}
+	}
+
+	return bluez.ErrRejected
+}
+
+//RequestDisconnection close the connections of device
+func (p *sppProfile) RequestDisconnection(device dbus.ObjectPath) error {
+	p.lock.Lock()
+	var conns []*Conn
+	for conn := range p.conns {
+		if conn.device == device {
+			conns = append(conns, conn)
+		}
+	}
+	p.lock.Unlock()
+
+	for _, conn := range conns {
+		conn.Close()
+	}
+	return nil
+}
+
+//Release is called when bluez drops the profile
+func (p *sppProfile) Release() {
+	p.lock.Lock()
+	onClose := p.onClose
+	p.lock.Unlock()
+	if onClose != nil {
+		onClose()
+	}
+}
@@ -0,0 +1,274 @@
+// Package spp talk to classic serial devices with the Serial Port Profile. The RFCOMM
+// connections are handed by bluez to an exported org.bluez.Profile1 and exposed as net.Conn
+package spp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/bluez"
+	"github.com/saurabh-newera/BLE/bluez/profile"
+)
+
+// UUID of the Serial Port Profile
+const UUID = "00001101-0000-1000-8000-00805f9b34fb"
+
+// BasePath the object path prefix of the registered profiles
+const BasePath = "/org/bluez/spp/go"
+
+// ErrClosed is returned by Accept once the listener is closed
+var ErrClosed = errors.New("spp: listener closed")
+
+// Config of the profile registered by Listen and Dial, nil uses the defaults
+type Config struct {
+	// AdapterID of the device to connect to, hci0 by default
+	AdapterID string
+	// Name of the service in the SDP record
+	Name string
+	// Channel is the RFCOMM channel of a server, chosen by bluez when 0
+	Channel uint16
+	// RequireAuthentication and RequireAuthorization of the remote device
+	RequireAuthentication bool
+	RequireAuthorization  bool
+	// Options customize the D-Bus connection, eg. profile.WithAddress
+	Options []profile.Option
+}
+
+// withDefaults return a copy of config with the default values set
+func (config *Config) withDefaults() *Config {
+	c := Config{}
+	if config != nil {
+		c = *config
+	}
+	if c.AdapterID == "" {
+		c.AdapterID = "hci0"
+	}
+	if c.Name == "" {
+		c.Name = "Serial Port"
+	}
+	return &c
+}
+
+// clientKey identify the client profile shared by the dials with the same configuration
+func (config *Config) clientKey() string {
+	bus := &bluez.Config{Bus: bluez.SystemBus}
+	for _, opt := range config.Options {
+		opt(bus)
+	}
+	key := fmt.Sprintf("%s %q %d %t %t %d %q", config.AdapterID, config.Name, config.Channel,
+		config.RequireAuthentication, config.RequireAuthorization, bus.Bus, bus.Address)
+	if bus.Conn != nil {
+		key += fmt.Sprintf(" conn:%p", bus.Conn)
+	}
+	if bus.Backend != nil {
+		key += fmt.Sprintf(" backend:%p", bus.Backend)
+	}
+	return key
+}
+
+// profileOptions return the options of RegisterProfile
+func (config *Config) profileOptions(role string) *profile.ProfileOptions {
+	autoConnect := false
+	return &profile.ProfileOptions{
+		Name:                  config.Name,
+		Role:                  role,
+		Channel:               config.Channel,
+		AutoConnect:           &autoConnect,
+		RequireAuthentication: config.RequireAuthentication,
+		RequireAuthorization:  config.RequireAuthorization,
+	}
+}
+
+// Conn an RFCOMM connection to a device
+type Conn struct {
+	net.Conn
+	device  dbus.ObjectPath
+	profile *sppProfile
+	// dialed connections release their client profile once closed
+	dialed    bool
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// Device return the object path of the remote device
+func (c *Conn) Device() dbus.ObjectPath {
+	return c.device
+}
+
+// Close the connection
+func (c *Conn) Close() error {
+	c.closeOnce.Do(func() {
+		c.profile.remove(c)
+		c.closeErr = c.Conn.Close()
+		if c.dialed {
+			releaseClient(c.profile)
+		}
+	})
+	return c.closeErr
+}
+
+// Listener accept the SPP connections of remote devices
+type Listener struct {
+	profile *sppProfile
+	addr    net.Addr
+}
+
+// Listen register a server profile and accept its connections
+func Listen(config *Config) (*Listener, error) {
+
+	config = config.withDefaults()
+	p := newSPPProfile()
+	p.accept = make(chan *Conn, 8)
+	err := p.register(config, profile.ProfileRoleServer)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Listener{
+		profile: p,
+		addr:    &profileAddr{config.AdapterID},
+	}, nil
+}
+
+// Accept wait for the next connection
+func (l *Listener) Accept() (net.Conn, error) {
+	conn, ok := <-l.profile.accept
+	if !ok {
+		return nil, ErrClosed
+	}
+	return conn, nil
+}
+
+// Close unregister the profile and close its connections
+func (l *Listener) Close() error {
+	return l.profile.close()
+}
+
+// Addr return the adapter of the listener
+func (l *Listener) Addr() net.Addr {
+	return l.addr
+}
+
+// profileAddr the address of a listener, its adapter
+type profileAddr struct {
+	adapterID string
+}
+
+func (a *profileAddr) Network() string {
+	return profile.ProfileNetwork
+}
+
+func (a *profileAddr) String() string {
+	return a.adapterID
+}
+
+var (
+	clientLock sync.Mutex
+	clients    = make(map[string]*sppProfile)
+)
+
+// acquireClient return the client profile of config, registered on first use.
+// Each call must be paired with releaseClient
+func acquireClient(config *Config) (*sppProfile, error) {
+	clientLock.Lock()
+	defer clientLock.Unlock()
+	key := config.clientKey()
+	p, ok := clients[key]
+	if !ok {
+		p = newSPPProfile()
+		p.key = key
+		p.onClose = func() {
+			dropClient(p)
+		}
+		err := p.register(config, profile.ProfileRoleClient)
+		if err != nil {
+			return nil, err
+		}
+		clients[key] = p
+	}
+	p.refs++
+	return p, nil
+}
+
+// releaseClient unregister a client profile once it is not used anymore. A profile
+// already dropped is left as is, so a stale release cannot affect a newer profile
+func releaseClient(p *sppProfile) {
+	clientLock.Lock()
+	if clients[p.key] != p {
+		clientLock.Unlock()
+		return
+	}
+	p.refs--
+	if p.refs > 0 {
+		clientLock.Unlock()
+		return
+	}
+	delete(clients, p.key)
+	clientLock.Unlock()
+	p.close()
+}
+
+// dropClient forget a client profile when bluez releases it, the next dial registers a new one
+func dropClient(p *sppProfile) {
+	clientLock.Lock()
+	if clients[p.key] == p {
+		delete(clients, p.key)
+	}
+	clientLock.Unlock()
+	p.close()
+}
+
+// devicePath return the object path of a device address on an adapter
+func devicePath(adapterID, address string) dbus.ObjectPath {
+	return dbus.ObjectPath("/org/bluez/" + adapterID + "/dev_" + strings.Replace(strings.ToUpper(address), ":", "_", -1))
+}
+
+// Dial connect to the SPP service of a device, eg. 00:11:22:33:44:55. The device must be
+// paired if the service requires authentication
+func Dial(address string, config *Config) (*Conn, error) {
+	return DialContext(context.Background(), address, config)
+}
+
+// DialContext connect to the SPP service of a device, aborting when ctx is done
+func DialContext(ctx context.Context, address string, config *Config) (*Conn, error) {
+
+	config = config.withDefaults()
+	p, err := acquireClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	path := devicePath(config.AdapterID, address)
+	ch, cancel := p.dial(path)
+	defer cancel()
+
+	device := profile.NewDevice1(string(path), config.Options...)
+	defer device.Close()
+
+	err = device.ConnectProfileContext(ctx, UUID)
+	if err != nil {
+		releaseClient(p)
+		return nil, err
+	}
+
+	select {
+	case conn := <-ch:
+		conn.dialed = true
+		return conn, nil
+	case <-ctx.Done():
+		cancel()
+		select {
+		case conn := <-ch:
+			conn.Conn.Close()
+			p.remove(conn)
+		default:
+		}
+		releaseClient(p)
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,68 @@
+package spp
+
+import (
+	"testing"
+
+	"github.com/godbus/dbus"
+	"github.com/saurabh-newera/BLE/bluez/profile"
+)
+
+func TestDevicePath(t *testing.T) {
+	path := devicePath("hci1", "00:1a:7d:da:71:13")
+	if path != "/org/bluez/hci1/dev_00_1A_7D_DA_71_13" {
+		t.Fatalf("Unexpected path %s", path)
+	}
+	if address := profile.DeviceAddress(path); address != "00:1A:7D:DA:71:13" {
+		t.Fatalf("Unexpected address %s", address)
+	}
+}
+
+func TestProfileOptions(t *testing.T) {
+
+	options, err := (*Config)(nil).withDefaults().profileOptions(profile.ProfileRoleServer).ToMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if options["Role"].Value() != "server" || options["Name"].Value() != "Serial Port" {
+		t.Fatalf("Unexpected options %v", options)
+	}
+	if options["AutoConnect"].Value() != false {
+		t.Fatalf("Expected AutoConnect to be disabled, got %v", options["AutoConnect"])
+	}
+	if _, ok := options["Channel"]; ok {
+		t.Fatal("Expected no channel by default")
+	}
+
+	options, err = (&Config{Channel: 3}).withDefaults().profileOptions(profile.ProfileRoleClient).ToMap()
+	if err != nil || options["Channel"].Value() != uint16(3) {
+		t.Fatalf("Expected channel 3, got %v %v", options["Channel"], err)
+	}
+}
+
+func TestRejectUnexpectedConnection(t *testing.T) {
+	p := &sppProfile{
+		dials: make(map[dbus.ObjectPath]chan *Conn),
+		conns: make(map[*Conn]bool),
+	}
+	err := p.NewConnection("/org/bluez/hci0/dev_00_11_22_33_44_55", nil, nil)
+	if err == nil {
+		t.Fatal("Expected a connection without dial or listener to be rejected")
+	}
+}
+
+func TestClientKey(t *testing.T) {
+	key := (*Config)(nil).withDefaults().clientKey()
+	if key != (&Config{AdapterID: "hci0"}).withDefaults().clientKey() {
+		t.Fatal("Expected the defaults to share a client profile")
+	}
+	for _, config := range []*Config{
+		{AdapterID: "hci1"},
+		{Channel: 3},
+		{RequireAuthentication: true},
+		{Options: []profile.Option{profile.WithAddress("unix:path=/tmp/test.sock")}},
+	} {
+		if config.withDefaults().clientKey() == key {
+			t.Fatalf("Expected a distinct client profile for %+v", config)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package util
+
+import (
+	"net"
+	"os"
+	"syscall"
+	"time"
+)
+
+// Addr a bluetooth address, eg. the remote device of an RFCOMM connection
+type Addr struct {
+	// Net is the transport, eg. rfcomm
+	Net string
+	// Address is the device address, eg. 00:11:22:33:44:55
+	Address string
+}
+
+// Network return the transport
+func (a *Addr) Network() string {
+	return a.Net
+}
+
+func (a *Addr) String() string {
+	return a.Address
+}
+
+// SocketConn a net.Conn over a connected socket, eg. a file descriptor passed by bluez
+type SocketConn struct {
+	file   *os.File
+	local  net.Addr
+	remote net.Addr
+}
+
+// NewSocketConn take ownership of a connected socket and wrap it as a net.Conn. The socket
+// is switched to non blocking mode so that deadlines and Close interrupt pending calls
+func NewSocketConn(fd int, local, remote net.Addr) (*SocketConn, error) {
+	err := syscall.SetNonblock(fd, true)
+	if err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+	return &SocketConn{
+		file:   os.NewFile(uintptr(fd), remote.String()),
+		local:  local,
+		remote: remote,
+	}, nil
+}
+
+// Read data from the connection
+func (c *SocketConn) Read(b []byte) (int, error) {
+	return c.file.Read(b)
+}
+
+// Write data to the connection
+func (c *SocketConn) Write(b []byte) (int, error) {
+	return c.file.Write(b)
+}
+
+// Close the socket
+func (c *SocketConn) Close() error {
+	return c.file.Close()
+}
+
+// LocalAddr return the local address
+func (c *SocketConn) LocalAddr() net.Addr {
+	return c.local
+}
+
+// RemoteAddr return the remote address
+func (c *SocketConn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+// SetDeadline set the read and write deadlines
+func (c *SocketConn) SetDeadline(t time.Time) error {
+	return c.file.SetDeadline(t)
+}
+
+// SetReadDeadline set the deadline of Read
+func (c *SocketConn) SetReadDeadline(t time.Time) error {
+	return c.file.SetReadDeadline(t)
+}
+
+// SetWriteDeadline set the deadline of Write
+func (c *SocketConn) SetWriteDeadline(t time.Time) error {
+	return c.file.SetWriteDeadline(t)
+}
+
+// File return the underlying file, eg. to call ioctls on the socket
+func (c *SocketConn) File() *os.File {
+	return c.file
+}
@@ -2,39 +2,435 @@ package util
 
 import (
 	"errors"
-	"github.com/godbus/dbus"
+	"fmt"
 	"reflect"
+	"strings"
+
+	"github.com/godbus/dbus"
 )
 
-func mapStructField(obj interface{}, name string, value dbus.Variant) error {
-	structValue := reflect.ValueOf(obj).Elem()
-	structFieldValue := structValue.FieldByName(name)
+// TagName the struct tag used to map the fields, eg.
+//
+// 	type Properties struct {
+// 		Alias    string                  `dbus:"Alias,omitempty"`
+// 		TxPower  int16                   `dbus:"TxPower"`
+// 		Internal bool                    `dbus:"-"`
+// 		Unknown  map[string]dbus.Variant `dbus:",unknown"`
+// 	}
+//
+// Fields without a tag are mapped by name. The omitempty option skip zero values in
+// StructToMap, the unknown option mark a map collecting the keys without a matching field
+const TagName = "dbus"
+
+var (
+	variantType = reflect.TypeOf(dbus.Variant{})
+	// ErrNotStruct is returned when the target is not a pointer to a struct
+	ErrNotStruct = errors.New("Expected a pointer to a struct")
+)
+
+// FieldError describe a value which cannot be mapped
+type FieldError struct {
+	Key   string
+	Field string
+	Err   error
+}
 
-	if !structFieldValue.IsValid() {
-		return errors.New("No such field: " + name + " in obj")
+func (e *FieldError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("%s: %s", e.Key, e.Err.Error())
 	}
+	return fmt.Sprintf("%s (field %s): %s", e.Key, e.Field, e.Err.Error())
+}
+
+// MappingError collect all the errors of a mapping
+type MappingError struct {
+	Errors []*FieldError
+}
 
-	if !structFieldValue.CanSet() {
-		return errors.New("Cannot set " + name + " field value")
+func (e *MappingError) Error() string {
+	list := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		list[i] = err.Error()
 	}
+	return fmt.Sprintf("Failed to map %d value(s): %s", len(e.Errors), strings.Join(list, "; "))
+}
+
+// add record an error
+func (e *MappingError) add(key, field string, err error) {
+	e.Errors = append(e.Errors, &FieldError{Key: key, Field: field, Err: err})
+}
 
-	structFieldType := structFieldValue.Type()
-	val := reflect.ValueOf(value.Value())
-	if structFieldType != val.Type() {
-		return errors.New("Provided value type didn't match obj field type")
+// result return e if any error has been recorded
+func (e *MappingError) result() error {
+	if len(e.Errors) == 0 {
+		return nil
 	}
+	return e
+}
+
+// fieldInfo a mapped field of a struct
+type fieldInfo struct {
+	index     int
+	name      string
+	omitEmpty bool
+}
 
-	structFieldValue.Set(val)
-	return nil
+// structInfo the mapped fields of a struct type
+type structInfo struct {
+	fields map[string]fieldInfo
+	order  []fieldInfo
+	// unknown is the index of the field collecting unknown keys, -1 if none
+	unknown int
+}
+
+// parseStruct read the tags of a struct type
+func parseStruct(t reflect.Type) structInfo {
+	info := structInfo{
+		fields:  make(map[string]fieldInfo),
+		unknown: -1,
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			// unexported
+			continue
+		}
+		tag := field.Tag.Get(TagName)
+		if tag == "-" {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		f := fieldInfo{index: i, name: parts[0]}
+		if f.name == "" {
+			f.name = field.Name
+		}
+		unknown := false
+		for _, opt := range parts[1:] {
+			switch opt {
+			case "omitempty":
+				f.omitEmpty = true
+			case "unknown":
+				unknown = true
+			}
+		}
+		if unknown {
+			if field.Type.Kind() == reflect.Map && field.Type.Key().Kind() == reflect.String {
+				info.unknown = i
+			}
+			continue
+		}
+		info.fields[f.name] = f
+		info.order = append(info.order, f)
+	}
+	return info
 }
 
-// MapToStruct converts a map[string]interface{} to a struct
+// MapToStruct fill the struct pointed by s with the values of m. Keys without a matching
+// field are collected by the field tagged `dbus:",unknown"`, if any, otherwise ignored.
+// Compatible types are converted and variants are unwrapped; the values which cannot be
+// mapped are skipped and reported together in a *MappingError
 func MapToStruct(s interface{}, m map[string]dbus.Variant) error {
-	for k, v := range m {
-		err := mapStructField(s, k, v)
+
+	value := reflect.ValueOf(s)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return ErrNotStruct
+	}
+
+	errs := new(MappingError)
+	mapToStruct(value.Elem(), m, "", errs)
+	return errs.result()
+}
+
+// mapToStruct fill dst with m, prefix is the path of dst in the errors
+func mapToStruct(dst reflect.Value, m map[string]dbus.Variant, prefix string, errs *MappingError) {
+
+	info := parseStruct(dst.Type())
+	for key, v := range m {
+		f, ok := info.fields[key]
+		if !ok {
+			if info.unknown >= 0 {
+				collect(dst.Field(info.unknown), key, v, prefix, errs)
+			}
+			continue
+		}
+		field := dst.Field(f.index)
+		err := assign(field, v)
+		if err != nil {
+			errs.add(prefix+key, prefix+dst.Type().Field(f.index).Name, err)
+		}
+	}
+}
+
+// collect store an unknown key in the unknown field
+func collect(field reflect.Value, key string, v dbus.Variant, prefix string, errs *MappingError) {
+	if field.IsNil() {
+		field.Set(reflect.MakeMap(field.Type()))
+	}
+	elem := reflect.New(field.Type().Elem()).Elem()
+	err := assign(elem, v)
+	if err != nil {
+		errs.add(prefix+key, "", err)
+		return
+	}
+	field.SetMapIndex(reflect.ValueOf(key).Convert(field.Type().Key()), elem)
+}
+
+// assign convert src to the type of dst and store it
+func assign(dst reflect.Value, src interface{}) error {
+
+	t := dst.Type()
+
+	if t == variantType {
+		if v, ok := src.(dbus.Variant); ok {
+			dst.Set(reflect.ValueOf(v))
+			return nil
+		}
+		if src == nil {
+			return errors.New("Cannot wrap nil in a variant")
+		}
+		dst.Set(reflect.ValueOf(dbus.MakeVariant(src)))
+		return nil
+	}
+
+	// unwrap nested variants
+	for {
+		v, ok := src.(dbus.Variant)
+		if !ok {
+			break
+		}
+		src = v.Value()
+	}
+
+	if src == nil {
+		dst.Set(reflect.Zero(t))
+		return nil
+	}
+
+	val := reflect.ValueOf(src)
+	if val.Type().AssignableTo(t) {
+		dst.Set(val)
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		elem := reflect.New(t.Elem())
+		err := assign(elem.Elem(), src)
 		if err != nil {
 			return err
 		}
+		dst.Set(elem)
+		return nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch val.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if dst.OverflowInt(val.Int()) {
+				return fmt.Errorf("Value %d overflows %s", val.Int(), t)
+			}
+			dst.SetInt(val.Int())
+			return nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if val.Uint() > 1<<63-1 || dst.OverflowInt(int64(val.Uint())) {
+				return fmt.Errorf("Value %d overflows %s", val.Uint(), t)
+			}
+			dst.SetInt(int64(val.Uint()))
+			return nil
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		switch val.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if val.Int() < 0 || dst.OverflowUint(uint64(val.Int())) {
+				return fmt.Errorf("Value %d overflows %s", val.Int(), t)
+			}
+			dst.SetUint(uint64(val.Int()))
+			return nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if dst.OverflowUint(val.Uint()) {
+				return fmt.Errorf("Value %d overflows %s", val.Uint(), t)
+			}
+			dst.SetUint(val.Uint())
+			return nil
+		}
+
+	case reflect.Float32, reflect.Float64:
+		switch val.Kind() {
+		case reflect.Float32, reflect.Float64:
+			dst.SetFloat(val.Float())
+			return nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			dst.SetFloat(float64(val.Int()))
+			return nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			dst.SetFloat(float64(val.Uint()))
+			return nil
+		}
+
+	case reflect.String, reflect.Bool:
+		// eg. dbus.ObjectPath to string
+		if val.Kind() == t.Kind() {
+			dst.Set(val.Convert(t))
+			return nil
+		}
+
+	case reflect.Slice:
+		if val.Kind() != reflect.Slice && val.Kind() != reflect.Array {
+			break
+		}
+		list := reflect.MakeSlice(t, val.Len(), val.Len())
+		for i := 0; i < val.Len(); i++ {
+			err := assign(list.Index(i), val.Index(i).Interface())
+			if err != nil {
+				return fmt.Errorf("Index %d: %s", i, err.Error())
+			}
+		}
+		dst.Set(list)
+		return nil
+
+	case reflect.Map:
+		if val.Kind() != reflect.Map {
+			break
+		}
+		m := reflect.MakeMapWithSize(t, val.Len())
+		iter := val.MapRange()
+		for iter.Next() {
+			key := reflect.New(t.Key()).Elem()
+			err := assign(key, iter.Key().Interface())
+			if err != nil {
+				return fmt.Errorf("Key %v: %s", iter.Key().Interface(), err.Error())
+			}
+			elem := reflect.New(t.Elem()).Elem()
+			err = assign(elem, iter.Value().Interface())
+			if err != nil {
+				return fmt.Errorf("Key %v: %s", iter.Key().Interface(), err.Error())
+			}
+			m.SetMapIndex(key, elem)
+		}
+		dst.Set(m)
+		return nil
+
+	case reflect.Struct:
+		switch v := src.(type) {
+		case map[string]dbus.Variant:
+			// a nested dictionary
+			errs := new(MappingError)
+			fresh := reflect.New(t).Elem()
+			mapToStruct(fresh, v, "", errs)
+			dst.Set(fresh)
+			return errs.result()
+		case []interface{}:
+			// a D-Bus struct, mapped to the fields in order
+			info := parseStruct(t)
+			if len(v) != len(info.order) {
+				return fmt.Errorf("Expected %d struct fields, got %d", len(info.order), len(v))
+			}
+			fresh := reflect.New(t).Elem()
+			for i, f := range info.order {
+				err := assign(fresh.Field(f.index), v[i])
+				if err != nil {
+					return fmt.Errorf("Field %s: %s", t.Field(f.index).Name, err.Error())
+				}
+			}
+			dst.Set(fresh)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("Cannot convert %s to %s", val.Type(), t)
+}
+
+// StructToMap convert the struct s, or a pointer to it, to a D-Bus property dictionary.
+// Fields tagged with omitempty are skipped when empty, as nil pointers and
+// interfaces. The keys collected by the unknown field are included
+func StructToMap(s interface{}) (map[string]dbus.Variant, error) {
+
+	value := reflect.ValueOf(s)
+	if value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil, ErrNotStruct
+	}
+
+	info := parseStruct(value.Type())
+	m := make(map[string]dbus.Variant)
+	errs := new(MappingError)
+
+	if info.unknown >= 0 {
+		iter := value.Field(info.unknown).MapRange()
+		for iter.Next() {
+			key := iter.Key().String()
+			v, err := makeVariant(iter.Value())
+			if err != nil {
+				errs.add(key, "", err)
+				continue
+			}
+			if v != nil {
+				m[key] = *v
+			}
+		}
+	}
+
+	for _, f := range info.order {
+		field := value.Field(f.index)
+		if f.omitEmpty && isEmpty(field) {
+			continue
+		}
+		v, err := makeVariant(field)
+		if err != nil {
+			errs.add(f.name, value.Type().Field(f.index).Name, err)
+			continue
+		}
+		if v != nil {
+			m[f.name] = *v
+		}
+	}
+
+	return m, errs.result()
+}
+
+// makeVariant wrap a field value, nil is returned for nil pointers and interfaces
+func makeVariant(field reflect.Value) (v *dbus.Variant, err error) {
+
+	for field.Kind() == reflect.Ptr || field.Kind() == reflect.Interface {
+		if field.IsNil() {
+			return nil, nil
+		}
+		field = field.Elem()
+	}
+
+	if field.Type() == variantType {
+		variant := field.Interface().(dbus.Variant)
+		return &variant, nil
+	}
+
+	// godbus panics on types without a D-Bus signature, eg. chan
+	defer func() {
+		if r := recover(); r != nil {
+			v = nil
+			err = fmt.Errorf("Cannot convert %s to a D-Bus value: %v", field.Type(), r)
+		}
+	}()
+	variant := dbus.MakeVariant(field.Interface())
+	return &variant, nil
+}
+
+// isEmpty check if a field has the zero value, empty slices and maps included
+func isEmpty(field reflect.Value) bool {
+	switch field.Kind() {
+	case reflect.Slice, reflect.Map, reflect.String, reflect.Array:
+		return field.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return field.IsNil()
+	case reflect.Bool:
+		return !field.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return field.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return field.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return field.Float() == 0
 	}
-	return nil
+	return false
 }
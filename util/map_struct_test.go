@@ -0,0 +1,122 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/godbus/dbus"
+)
+
+type testProperties struct {
+	Alias            string
+	Address          string `dbus:"Address,omitempty"`
+	RSSI             int16
+	TxPower          int32
+	Adapter          string
+	UUIDs            []string
+	ManufacturerData map[uint16][]byte
+	Internal         bool                    `dbus:"-"`
+	Unknown          map[string]dbus.Variant `dbus:",unknown"`
+}
+
+func TestMapToStruct(t *testing.T) {
+
+	props := new(testProperties)
+	err := MapToStruct(props, map[string]dbus.Variant{
+		"Alias":   dbus.MakeVariant(dbus.MakeVariant("sensor")),
+		"RSSI":    dbus.MakeVariant(int16(-60)),
+		"TxPower": dbus.MakeVariant(int16(4)),
+		"Adapter": dbus.MakeVariant(dbus.ObjectPath("/org/bluez/hci0")),
+		"UUIDs":   dbus.MakeVariant([]interface{}{"180f", dbus.MakeVariant("180a")}),
+		"ManufacturerData": dbus.MakeVariant(map[uint16]dbus.Variant{
+			0x004c: dbus.MakeVariant([]byte{1, 2}),
+		}),
+		"Internal":         dbus.MakeVariant(true),
+		"AdvertisingFlags": dbus.MakeVariant([]byte{6}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &testProperties{
+		Alias:            "sensor",
+		RSSI:             -60,
+		TxPower:          4,
+		Adapter:          "/org/bluez/hci0",
+		UUIDs:            []string{"180f", "180a"},
+		ManufacturerData: map[uint16][]byte{0x004c: {1, 2}},
+		Unknown: map[string]dbus.Variant{
+			"AdvertisingFlags": dbus.MakeVariant([]byte{6}),
+			// skipped fields are not mapped
+			"Internal": dbus.MakeVariant(true),
+		},
+	}
+	if !reflect.DeepEqual(props, expected) {
+		t.Fatalf("Expected %+v, got %+v", expected, props)
+	}
+}
+
+func TestMapToStructErrors(t *testing.T) {
+
+	props := new(testProperties)
+	err := MapToStruct(props, map[string]dbus.Variant{
+		"Alias":   dbus.MakeVariant(true),
+		"RSSI":    dbus.MakeVariant(int32(70000)),
+		"Address": dbus.MakeVariant("00:11:22:33:44:55"),
+	})
+
+	mappingErr, ok := err.(*MappingError)
+	if !ok || len(mappingErr.Errors) != 2 {
+		t.Fatalf("Expected 2 mapping errors, got %v", err)
+	}
+	// valid values are mapped anyway
+	if props.Address != "00:11:22:33:44:55" {
+		t.Fatalf("Address not mapped: %+v", props)
+	}
+
+	if MapToStruct(*props, nil) != ErrNotStruct {
+		t.Fatal("Expected ErrNotStruct")
+	}
+}
+
+func TestStructToMap(t *testing.T) {
+
+	props := &testProperties{
+		Alias:    "sensor",
+		RSSI:     -60,
+		UUIDs:    []string{"180f"},
+		Internal: true,
+		Unknown: map[string]dbus.Variant{
+			"AdvertisingFlags": dbus.MakeVariant([]byte{6}),
+		},
+	}
+	m, err := StructToMap(props)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := m["Address"]; ok {
+		t.Fatal("Empty Address should be omitted")
+	}
+	if _, ok := m["Internal"]; ok {
+		t.Fatal("Internal should be skipped")
+	}
+	if m["RSSI"].Value() != int16(-60) || m["AdvertisingFlags"].Signature().String() != "ay" {
+		t.Fatalf("Unexpected map %v", m)
+	}
+
+	roundtrip := new(testProperties)
+	err = MapToStruct(roundtrip, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	props.Internal = false
+	if !reflect.DeepEqual(roundtrip, props) {
+		t.Fatalf("Expected %+v, got %+v", props, roundtrip)
+	}
+
+	_, err = StructToMap(struct{ Done chan bool }{make(chan bool)})
+	if err == nil {
+		t.Fatal("Expected an error for a type without D-Bus signature")
+	}
+}